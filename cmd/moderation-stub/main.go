@@ -0,0 +1,103 @@
+// Command moderation-stub is a local stand-in for the external moderation classifier
+// Point MODERATION_WEBHOOK_URL at http://localhost:8090/check to try the HTTP hook without a real classifier
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"goswift/internal/moderation"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "Address to listen on")
+	reject := flag.String("reject", "spam", "Comma-separated words that get messages rejected")
+	flagged := flag.String("flag", "scam", "Comma-separated words that get messages flagged for review")
+	redact := flag.String("redact", "secret", "Comma-separated words that are masked in messages")
+	flag.Parse()
+
+	classifier := &stubClassifier{
+		reject: splitWords(*reject),
+		flag:   splitWords(*flagged),
+		redact: splitWords(*redact),
+	}
+
+	http.HandleFunc("/check", classifier.check)
+
+	log.Printf("🛡️ Moderation stub listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Fatal("❌ Failed to start moderation stub:", err)
+	}
+}
+
+// stubClassifier decides on messages by looking for configured words
+type stubClassifier struct {
+	reject []string
+	flag   []string
+	redact []string
+}
+
+func (s *stubClassifier) check(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input moderation.Input
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "invalid message", http.StatusBadRequest)
+		return
+	}
+
+	verdict := s.classify(input.Content)
+	log.Printf("Message from %s in %s: %s", input.SenderID, input.ConversationID, verdict.Action)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verdict)
+}
+
+// classify rejects before redacting and redacts before flagging, like a real classifier would prioritize
+func (s *stubClassifier) classify(content string) *moderation.Verdict {
+	lower := strings.ToLower(content)
+
+	for _, word := range s.reject {
+		if strings.Contains(lower, word) {
+			return &moderation.Verdict{Action: moderation.ActionReject, Reason: "contains " + word}
+		}
+	}
+
+	redacted := content
+	for _, word := range s.redact {
+		for {
+			i := strings.Index(strings.ToLower(redacted), word)
+			if i < 0 {
+				break
+			}
+			redacted = redacted[:i] + strings.Repeat("*", len(word)) + redacted[i+len(word):]
+		}
+	}
+	if redacted != content {
+		return &moderation.Verdict{Action: moderation.ActionRedact, Reason: "sensitive words", Content: redacted}
+	}
+
+	for _, word := range s.flag {
+		if strings.Contains(lower, word) {
+			return &moderation.Verdict{Action: moderation.ActionFlag, Reason: "possible " + word}
+		}
+	}
+
+	return &moderation.Verdict{Action: moderation.ActionAllow}
+}
+
+func splitWords(list string) []string {
+	var words []string
+	for _, word := range strings.Split(list, ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
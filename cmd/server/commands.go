@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"goswift/internal/database"
+	"goswift/internal/repository"
+	"goswift/internal/service"
+	"goswift/pkg/utils"
+)
+
+// usage describes the maintenance commands of the server binary
+const usage = `Usage:
+  server                              Start the server
+  server import-slack <archive.zip>   Import a Slack export archive`
+
+// runCommand runs a maintenance command instead of starting the server
+func runCommand(config *utils.Config, db *database.DB, args []string) error {
+	switch args[0] {
+	case "import-slack":
+		if len(args) != 2 {
+			return fmt.Errorf("missing archive path\n%s", usage)
+		}
+		return importSlack(config, db, args[1])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+// importSlack imports a Slack export archive and prints the report
+// The command is run by operators, so no admin account is required and the archive size is not limited
+func importSlack(config *utils.Config, db *database.DB, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	importService := service.NewImportService(repository.NewSlackImportRepository(db), config.AdminUserIDs, config.ImportMaxArchiveSize)
+	report, err := importService.ImportSlack(file, info.Size())
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
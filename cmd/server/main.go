@@ -2,10 +2,13 @@ package main
 
 import (
 	"log"
+	"os"
+	_ "time/tzdata" // Reminder timezones work on hosts without a zoneinfo database
 
 	"goswift/internal/cache"
 	"goswift/internal/database"
 	"goswift/internal/router"
+	"goswift/internal/storage"
 	"goswift/pkg/utils"
 
 	_ "goswift/docs" // This is generated by swag
@@ -44,6 +47,14 @@ func main() {
 	}
 	defer db.Close()
 
+	// Run a maintenance command instead of the server, e.g. import-slack
+	if len(os.Args) > 1 {
+		if err := runCommand(config, db, os.Args[1:]); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		return
+	}
+
 	// Connect to Redis
 	redisClient, err := cache.NewRedisConnection(config)
 	if err != nil {
@@ -51,8 +62,14 @@ func main() {
 	}
 	defer redisClient.Close()
 
+	// Initialize file storage
+	fileStorage, err := storage.NewStorage(config)
+	if err != nil {
+		log.Fatal("❌ Failed to initialize file storage:", err)
+	}
+
 	// Setup router
-	r := router.SetupRouter(config, db, redisClient)
+	r := router.SetupRouter(config, db, redisClient, fileStorage)
 
 	// Start server
 	addr := ":" + config.ServerPort
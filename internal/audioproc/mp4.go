@@ -0,0 +1,364 @@
+package audioproc
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// aacMaxFramesPerSecond bounds the frames per second of AAC, which holds at least 960 samples per frame at up to 96 kHz
+const aacMaxFramesPerSecond = 100
+
+// aacObjectTypes are the object type indications of AAC streams in MP4 files
+var aacObjectTypes = map[byte]bool{
+	0x40: true, // MPEG-4 audio
+	0x66: true, // MPEG-2 AAC main profile
+	0x67: true, // MPEG-2 AAC low complexity profile
+	0x68: true, // MPEG-2 AAC scalable sampling rate profile
+}
+
+// errBoxTruncated is returned when a box extends past its parent
+var errBoxTruncated = errors.New("truncated box")
+
+// mp4Track is the audio track of an M4A file
+type mp4Track struct {
+	timeScale int64
+	duration  int64
+	frames    []frame
+	size      int64 // Total size of the samples
+}
+
+// nextBox splits the first box off data
+// Returns the box type, its payload and the boxes after it
+func nextBox(data []byte) (string, []byte, []byte, error) {
+	if len(data) < 8 {
+		return "", nil, nil, errBoxTruncated
+	}
+
+	size := uint64(binary.BigEndian.Uint32(data[0:4]))
+	boxType := string(data[4:8])
+	headerSize := uint64(8)
+
+	switch size {
+	case 0: // Extends to the end of the file
+		size = uint64(len(data))
+	case 1: // 64-bit size after the type
+		if len(data) < 16 {
+			return "", nil, nil, errBoxTruncated
+		}
+		size = binary.BigEndian.Uint64(data[8:16])
+		headerSize = 16
+	}
+
+	if size < headerSize || size > uint64(len(data)) {
+		return "", nil, nil, errBoxTruncated
+	}
+
+	return boxType, data[headerSize:size], data[size:], nil
+}
+
+// findBox gets the payload of the first box at a path of nested box types, nil if there is none
+func findBox(data []byte, path ...string) []byte {
+	for _, boxType := range path {
+		found := false
+		for len(data) > 0 {
+			t, payload, rest, err := nextBox(data)
+			if err != nil {
+				return nil
+			}
+			if t == boxType {
+				data, found = payload, true
+				break
+			}
+			data = rest
+		}
+		if !found {
+			return nil
+		}
+	}
+	return data
+}
+
+// processMP4 parses an M4A recording of at most maxDuration, an MP4 file holding a single AAC track
+// The sample tables are checked against the media data, so truncated recordings are rejected
+func processMP4(data []byte, maxDuration time.Duration) (*Result, error) {
+	var moov []byte
+	var mediaSize int64
+	truncated := false
+
+	for rest := data; len(rest) > 0; {
+		boxType, payload, next, err := nextBox(rest)
+		if err != nil {
+			truncated = true
+			break
+		}
+		switch boxType {
+		case "moov":
+			moov = payload
+		case "mdat":
+			mediaSize += int64(len(payload))
+		}
+		rest = next
+	}
+
+	// The movie box may follow the media data, which is cut off in truncated files
+	if moov == nil {
+		return nil, ErrUnsupportedAudio
+	}
+
+	// Recordings with more frames than fit in the maximum duration are too long, whatever their sample durations
+	maxFrames := (maxSeconds(maxDuration) + 1) * aacMaxFramesPerSecond
+
+	track, err := parseMoov(moov, mediaSize, maxFrames)
+	if err != nil {
+		return nil, err
+	}
+
+	if truncated || track.size > mediaSize {
+		return nil, ErrInvalidAudio
+	}
+
+	return newResult(ContentTypeMP4, "aac", track.frames, track.duration, track.timeScale, maxDuration)
+}
+
+// parseMoov finds the audio track of a movie box, with at most maxFrames samples
+// Files with video or several audio tracks are not voice recordings
+func parseMoov(moov []byte, mediaSize, maxFrames int64) (*mp4Track, error) {
+	var track *mp4Track
+
+	for rest := moov; len(rest) > 0; {
+		boxType, payload, next, err := nextBox(rest)
+		if err != nil {
+			return nil, ErrUnsupportedAudio
+		}
+		rest = next
+		if boxType != "trak" {
+			continue
+		}
+
+		mdia := findBox(payload, "mdia")
+		hdlr := findBox(mdia, "hdlr")
+		if len(hdlr) < 12 {
+			return nil, ErrUnsupportedAudio
+		}
+
+		switch string(hdlr[8:12]) {
+		case "vide":
+			return nil, ErrUnsupportedAudio
+		case "soun":
+			if track != nil {
+				return nil, ErrUnsupportedAudio
+			}
+			if track, err = parseAudioTrack(mdia, mediaSize, maxFrames); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if track == nil {
+		return nil, ErrUnsupportedAudio
+	}
+	return track, nil
+}
+
+// parseAudioTrack parses the media box of an AAC track whose samples are stored in mediaSize bytes
+func parseAudioTrack(mdia []byte, mediaSize, maxFrames int64) (*mp4Track, error) {
+	stbl := findBox(mdia, "minf", "stbl")
+	if !isAACSampleDescription(findBox(stbl, "stsd")) {
+		return nil, ErrUnsupportedAudio
+	}
+
+	// The track is AAC audio, anything wrong from now on is corruption
+	track := &mp4Track{}
+
+	mdhd := findBox(mdia, "mdhd")
+	switch {
+	case len(mdhd) >= 20 && mdhd[0] == 0:
+		track.timeScale = int64(binary.BigEndian.Uint32(mdhd[12:16]))
+		track.duration = int64(binary.BigEndian.Uint32(mdhd[16:20]))
+	case len(mdhd) >= 32 && mdhd[0] == 1:
+		track.timeScale = int64(binary.BigEndian.Uint32(mdhd[20:24]))
+		track.duration = int64(binary.BigEndian.Uint64(mdhd[24:32]))
+	default:
+		return nil, ErrInvalidAudio
+	}
+
+	sizes, err := parseSampleSizes(findBox(stbl, "stsz"), mediaSize, maxFrames)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sample durations are run-length encoded as (count, delta) pairs
+	stts := findBox(stbl, "stts")
+	if len(stts) < 8 {
+		return nil, ErrInvalidAudio
+	}
+	entries := int(binary.BigEndian.Uint32(stts[4:8]))
+	if len(stts) < 8+entries*8 {
+		return nil, ErrInvalidAudio
+	}
+
+	track.frames = make([]frame, 0, len(sizes))
+	var position int64
+	for i := 0; i < entries; i++ {
+		entry := stts[8+i*8:]
+		count := int(binary.BigEndian.Uint32(entry[0:4]))
+		delta := int64(binary.BigEndian.Uint32(entry[4:8]))
+		if count > len(sizes)-len(track.frames) {
+			return nil, ErrInvalidAudio
+		}
+
+		for j := 0; j < count; j++ {
+			size := sizes[len(track.frames)]
+			track.frames = append(track.frames, frame{start: position, duration: delta, size: size})
+			track.size += size
+			position += delta
+		}
+	}
+	if len(track.frames) != len(sizes) {
+		return nil, ErrInvalidAudio
+	}
+
+	// The media duration is unset in some files, the sample durations add up to it
+	if track.duration <= 0 || track.duration > position {
+		track.duration = position
+	}
+
+	return track, nil
+}
+
+// parseSampleSizes reads the size of every sample of a track from its sample size box
+// Tracks with more than maxCount samples are too long, they are rejected before allocating the sizes
+func parseSampleSizes(stsz []byte, mediaSize, maxCount int64) ([]int64, error) {
+	if len(stsz) < 12 {
+		return nil, ErrInvalidAudio
+	}
+
+	constant := int64(binary.BigEndian.Uint32(stsz[4:8]))
+	count := int(binary.BigEndian.Uint32(stsz[8:12]))
+	if int64(count) > maxCount {
+		return nil, ErrTooLong
+	}
+
+	if constant != 0 {
+		// Check the count against the media data before allocating the sizes
+		if int64(count) > mediaSize/constant {
+			return nil, ErrInvalidAudio
+		}
+		sizes := make([]int64, count)
+		for i := range sizes {
+			sizes[i] = constant
+		}
+		return sizes, nil
+	}
+
+	if len(stsz) < 12+count*4 {
+		return nil, ErrInvalidAudio
+	}
+	sizes := make([]int64, count)
+	for i := range sizes {
+		sizes[i] = int64(binary.BigEndian.Uint32(stsz[12+i*4:]))
+	}
+	return sizes, nil
+}
+
+// isAACSampleDescription reports whether a sample description box describes an AAC stream
+func isAACSampleDescription(stsd []byte) bool {
+	if len(stsd) < 8 || binary.BigEndian.Uint32(stsd[4:8]) != 1 {
+		return false
+	}
+
+	boxType, entry, _, err := nextBox(stsd[8:])
+	if err != nil || boxType != "mp4a" || len(entry) < 28 {
+		return false
+	}
+
+	// QuickTime sound descriptions grow with their version before the child boxes
+	offset := 28
+	switch binary.BigEndian.Uint16(entry[8:10]) {
+	case 1:
+		offset += 16
+	case 2:
+		offset += 36
+	}
+	if len(entry) < offset {
+		return false
+	}
+
+	children := entry[offset:]
+	esds := findBox(children, "esds")
+	if esds == nil {
+		esds = findBox(children, "wave", "esds")
+	}
+
+	objectType, ok := esdsObjectType(esds)
+	return ok && aacObjectTypes[objectType]
+}
+
+// esdsObjectType reads the object type indication of an elementary stream descriptor box
+// See ISO/IEC 14496-1 section 7.2.6
+func esdsObjectType(esds []byte) (byte, bool) {
+	if len(esds) < 4 {
+		return 0, false
+	}
+	data := esds[4:]
+
+	tag, body, ok := readDescriptor(data)
+	if !ok || tag != 0x03 || len(body) < 3 {
+		return 0, false
+	}
+
+	// ES_ID, then flags announcing optional fields
+	flags := body[2]
+	body = body[3:]
+	if flags&0x80 != 0 { // Stream dependence
+		if len(body) < 2 {
+			return 0, false
+		}
+		body = body[2:]
+	}
+	if flags&0x40 != 0 { // URL
+		if len(body) < 1 || len(body) < 1+int(body[0]) {
+			return 0, false
+		}
+		body = body[1+int(body[0]):]
+	}
+	if flags&0x20 != 0 { // OCR stream
+		if len(body) < 2 {
+			return 0, false
+		}
+		body = body[2:]
+	}
+
+	tag, body, ok = readDescriptor(body)
+	if !ok || tag != 0x04 || len(body) < 1 {
+		return 0, false
+	}
+	return body[0], true
+}
+
+// readDescriptor reads the tag and body of an MPEG-4 descriptor, whose size is encoded on up to 4 bytes of 7 bits
+func readDescriptor(data []byte) (byte, []byte, bool) {
+	if len(data) < 2 {
+		return 0, nil, false
+	}
+	tag := data[0]
+
+	size, end := 0, 0
+	for i := 1; i < len(data) && i <= 4; i++ {
+		size = size<<7 | int(data[i]&0x7F)
+		if data[i]&0x80 == 0 {
+			end = i + 1
+			break
+		}
+	}
+	if end == 0 {
+		return 0, nil, false
+	}
+
+	body := data[end:]
+	if size > len(body) {
+		return 0, nil, false
+	}
+	return tag, body[:size], true
+}
@@ -0,0 +1,242 @@
+package audioproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+const (
+	// oggHeaderSize is the size of an Ogg page header without its segment table
+	oggHeaderSize = 27
+
+	// Ogg page header flags
+	oggContinued = 0x01
+	oggFirstPage = 0x02
+
+	// opusTimeScale is the sample rate of Opus granule positions, whatever the input rate
+	opusTimeScale = 48000
+
+	// opusMaxPacketSamples is the longest packet allowed, 120 ms
+	opusMaxPacketSamples = 5760
+)
+
+// opusFrameSamples are the frame sizes at 48 kHz of the SILK, hybrid and CELT configurations
+var (
+	opusSilkFrameSamples   = [4]int64{480, 960, 1920, 2880}
+	opusHybridFrameSamples = [2]int64{480, 960}
+	opusCeltFrameSamples   = [4]int64{120, 240, 480, 960}
+)
+
+// oggCRCTable is the lookup table of the CRC-32 of Ogg pages, polynomial 0x04c11db7 without reflection
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// oggCRC updates the checksum of a page with data
+func oggCRC(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// processOgg parses an Ogg/Opus recording of at most maxDuration as specified by RFC 7845
+// Every page is checked, so truncated, reordered or altered recordings are rejected
+func processOgg(data []byte, maxDuration time.Duration) (*Result, error) {
+	// Packets hold at least 120 samples, so bounding the samples also bounds the frames kept
+	maxSamples := maxSeconds(maxDuration) * opusTimeScale
+
+	var (
+		serial      uint32
+		sequence    uint32
+		granule     int64 = -1
+		preSkip     int64
+		position    int64 // Samples of the packets read so far
+		packets     int
+		packet      []byte
+		pending     bool // The last packet continues on the next page
+		frames      []frame
+		isOpus      bool
+		invalidPage = ErrUnsupportedAudio // Until the stream is known to be Opus
+	)
+
+	for offset := 0; offset < len(data); {
+		page := data[offset:]
+		if len(page) < oggHeaderSize || !isOgg(page) || page[4] != 0 {
+			return nil, invalidPage
+		}
+
+		flags := page[5]
+		pageGranule := int64(binary.LittleEndian.Uint64(page[6:14]))
+		pageSerial := binary.LittleEndian.Uint32(page[14:18])
+		pageSequence := binary.LittleEndian.Uint32(page[18:22])
+		checksum := binary.LittleEndian.Uint32(page[22:26])
+		segments := int(page[26])
+
+		headerSize := oggHeaderSize + segments
+		if len(page) < headerSize {
+			return nil, invalidPage
+		}
+		lacing := page[oggHeaderSize:headerSize]
+
+		bodySize := 0
+		for _, size := range lacing {
+			bodySize += int(size)
+		}
+		if len(page) < headerSize+bodySize {
+			return nil, invalidPage
+		}
+		page = page[:headerSize+bodySize]
+
+		// The checksum is computed with its own field zeroed
+		crc := oggCRC(0, page[:22])
+		crc = oggCRC(crc, []byte{0, 0, 0, 0})
+		crc = oggCRC(crc, page[26:])
+		if crc != checksum {
+			return nil, invalidPage
+		}
+
+		if offset == 0 {
+			if flags&oggFirstPage == 0 {
+				return nil, invalidPage
+			}
+			serial = pageSerial
+		} else {
+			// Other logical streams would be video or other tracks
+			if pageSerial != serial {
+				return nil, ErrUnsupportedAudio
+			}
+			if pageSequence != sequence+1 || flags&oggFirstPage != 0 {
+				return nil, invalidPage
+			}
+		}
+		sequence = pageSequence
+
+		if (flags&oggContinued != 0) != pending {
+			return nil, invalidPage
+		}
+
+		body := page[headerSize:]
+		for _, size := range lacing {
+			packet = append(packet, body[:size]...)
+			body = body[size:]
+			if size == 255 {
+				continue
+			}
+
+			// The packet is complete
+			switch packets {
+			case 0:
+				skip, err := parseOpusHead(packet)
+				if err != nil {
+					return nil, err
+				}
+				preSkip = skip
+				isOpus = true
+				invalidPage = ErrInvalidAudio
+			case 1:
+				if !bytes.HasPrefix(packet, []byte("OpusTags")) {
+					return nil, ErrInvalidAudio
+				}
+			default:
+				samples, err := opusPacketSamples(packet)
+				if err != nil {
+					return nil, err
+				}
+				frames = append(frames, frame{start: position - preSkip, duration: samples, size: int64(len(packet))})
+				position += samples
+				if position-preSkip > maxSamples {
+					return nil, ErrTooLong
+				}
+			}
+			packets++
+			packet = nil
+		}
+		pending = len(lacing) > 0 && lacing[len(lacing)-1] == 255
+
+		// Pages where no packet ends have no granule position
+		if pageGranule != -1 && packets > 2 {
+			granule = pageGranule
+		}
+
+		offset += len(page)
+	}
+
+	if !isOpus {
+		return nil, ErrUnsupportedAudio
+	}
+	if pending || granule > position {
+		return nil, ErrInvalidAudio
+	}
+
+	// The granule position of the last page trims the padding of the last packet
+	return newResult(ContentTypeOgg, "opus", frames, granule-preSkip, opusTimeScale, maxDuration)
+}
+
+// parseOpusHead parses the identification header of an Opus stream
+// Returns the number of samples to skip at the start of the stream
+func parseOpusHead(packet []byte) (int64, error) {
+	if len(packet) < 19 || !bytes.HasPrefix(packet, []byte("OpusHead")) {
+		return 0, ErrUnsupportedAudio
+	}
+
+	// Only the major version 0 is defined, minor versions stay compatible
+	if packet[8]&0xF0 != 0 || packet[9] == 0 {
+		return 0, ErrInvalidAudio
+	}
+
+	return int64(binary.LittleEndian.Uint16(packet[10:12])), nil
+}
+
+// opusPacketSamples returns the duration in 48 kHz samples of an Opus packet from its TOC byte
+// See RFC 6716 section 3.1
+func opusPacketSamples(packet []byte) (int64, error) {
+	if len(packet) == 0 {
+		return 0, ErrInvalidAudio
+	}
+
+	toc := packet[0]
+	config := toc >> 3
+
+	var frameSamples int64
+	switch {
+	case config < 12:
+		frameSamples = opusSilkFrameSamples[config%4]
+	case config < 16:
+		frameSamples = opusHybridFrameSamples[config%2]
+	default:
+		frameSamples = opusCeltFrameSamples[config%4]
+	}
+
+	var count int64
+	switch toc & 0x03 {
+	case 0:
+		count = 1
+	case 1, 2:
+		count = 2
+	default:
+		if len(packet) < 2 {
+			return 0, ErrInvalidAudio
+		}
+		count = int64(packet[1] & 0x3F)
+	}
+
+	samples := frameSamples * count
+	if samples == 0 || samples > opusMaxPacketSamples {
+		return 0, ErrInvalidAudio
+	}
+	return samples, nil
+}
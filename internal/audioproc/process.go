@@ -0,0 +1,99 @@
+// Package audioproc reads the metadata of voice recordings
+// Ogg/Opus and M4A/AAC containers are parsed and checked without decoding the audio,
+// which gives the exact duration and a waveform approximated from the compressed frames
+package audioproc
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// Content types of the supported recordings
+const (
+	ContentTypeOgg = "audio/ogg"
+	ContentTypeMP4 = "audio/mp4"
+)
+
+var (
+	// ErrUnsupportedAudio is returned for files that are not Opus or AAC recordings, like videos or Vorbis audio
+	ErrUnsupportedAudio = errors.New("not an Ogg/Opus or M4A/AAC recording")
+	// ErrInvalidAudio is returned for recordings with a corrupt or truncated container
+	ErrInvalidAudio = errors.New("corrupt audio recording")
+	// ErrTooLong is returned for recordings longer than the maximum duration, their frames are not all read
+	ErrTooLong = errors.New("audio recording is too long")
+)
+
+// Result is the metadata of a recording
+type Result struct {
+	ContentType string
+	Codec       string // "opus" or "aac"
+	Duration    time.Duration
+	Waveform    []int // WaveformBars values from 0 to WaveformMax
+}
+
+// frame is a compressed audio frame, or a packet of frames, of a recording
+type frame struct {
+	start    int64 // In samples of the track time scale
+	duration int64
+	size     int64 // In bytes
+}
+
+// Detect reports whether the start of a file looks like an Ogg or MP4 container
+func Detect(head []byte) bool {
+	return isOgg(head) || isMP4(head)
+}
+
+// isOgg reports whether data starts with an Ogg page
+func isOgg(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("OggS"))
+}
+
+// isMP4 reports whether data starts with an ISO base media file type box
+func isMP4(data []byte) bool {
+	return len(data) >= 8 && string(data[4:8]) == "ftyp"
+}
+
+// Process parses a recording of at most maxDuration and computes its duration and waveform
+// The frame tables of longer recordings are not loaded, so crafted headers cannot make it allocate much
+func Process(data []byte, maxDuration time.Duration) (*Result, error) {
+	switch {
+	case isOgg(data):
+		return processOgg(data, maxDuration)
+	case isMP4(data):
+		return processMP4(data, maxDuration)
+	default:
+		return nil, ErrUnsupportedAudio
+	}
+}
+
+// maxSeconds returns the whole seconds of the maximum duration of a recording, rounded up
+func maxSeconds(maxDuration time.Duration) int64 {
+	return int64((maxDuration + time.Second - 1) / time.Second)
+}
+
+// newResult builds the result of a recording from its frames
+func newResult(contentType, codec string, frames []frame, samples, timeScale int64, maxDuration time.Duration) (*Result, error) {
+	if samples <= 0 || timeScale <= 0 || len(frames) == 0 {
+		return nil, ErrInvalidAudio
+	}
+
+	// Whole seconds are checked first, so converting the samples to nanoseconds cannot overflow
+	if samples/timeScale > maxSeconds(maxDuration) {
+		return nil, ErrTooLong
+	}
+	duration := time.Duration(samples/timeScale)*time.Second + time.Duration(samples%timeScale*int64(time.Second)/timeScale)
+	if duration <= 0 {
+		return nil, ErrInvalidAudio
+	}
+	if duration > maxDuration {
+		return nil, ErrTooLong
+	}
+
+	return &Result{
+		ContentType: contentType,
+		Codec:       codec,
+		Duration:    duration,
+		Waveform:    waveform(frames, samples),
+	}, nil
+}
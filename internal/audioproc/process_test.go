@@ -0,0 +1,487 @@
+package audioproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func u32(values ...uint32) []byte {
+	out := make([]byte, 0, len(values)*4)
+	for _, value := range values {
+		out = binary.BigEndian.AppendUint32(out, value)
+	}
+	return out
+}
+
+// box builds an MP4 box from its type and payload
+func box(boxType string, parts ...[]byte) []byte {
+	payload := bytes.Join(parts, nil)
+	return append(append(u32(uint32(8+len(payload))), boxType...), payload...)
+}
+
+// esds builds an elementary stream descriptor box with an object type indication
+func esds(objectType byte) []byte {
+	decoderConfig := append([]byte{0x04, 13, objectType, 0x15}, make([]byte, 11)...)
+	specificInfo := []byte{0x06, 0x01, 0x02}
+	body := append([]byte{0x00, 0x01, 0x00}, append(decoderConfig, specificInfo...)...)
+	return box("esds", u32(0), []byte{0x03, byte(len(body))}, body)
+}
+
+// m4aSpec describes an M4A file to build, the zero value of each field has a usable default
+type m4aSpec struct {
+	handler    string // "soun" by default
+	objectType byte   // AAC by default
+	mdhd       []byte // Version 0 with the time scale and no duration by default
+	timeScale  uint32
+	stsz       []byte
+	stts       []byte
+	mdatSize   int // Total of the sample sizes by default
+}
+
+// sampleSizes builds a sample size box listing every size
+func sampleSizes(sizes ...uint32) []byte {
+	return box("stsz", u32(0, 0, uint32(len(sizes))), u32(sizes...))
+}
+
+// constantSampleSizes builds a sample size box where all samples have the same size
+func constantSampleSizes(size, count uint32) []byte {
+	return box("stsz", u32(0, size, count))
+}
+
+// sampleDurations builds a time to sample box from (count, delta) pairs
+func sampleDurations(pairs ...uint32) []byte {
+	return box("stts", u32(0, uint32(len(pairs)/2)), u32(pairs...))
+}
+
+// build builds the M4A file with a single track
+func (spec m4aSpec) build() []byte {
+	if spec.handler == "" {
+		spec.handler = "soun"
+	}
+	if spec.objectType == 0 {
+		spec.objectType = 0x40
+	}
+	if spec.mdhd == nil {
+		spec.mdhd = box("mdhd", u32(0, 0, 0, spec.timeScale, 0), make([]byte, 4))
+	}
+
+	mp4a := box("mp4a", make([]byte, 28), esds(spec.objectType))
+	stsd := box("stsd", u32(0, 1), mp4a)
+	stbl := box("stbl", stsd, spec.stts, spec.stsz)
+	hdlr := box("hdlr", u32(0, 0), []byte(spec.handler), make([]byte, 12))
+	mdia := box("mdia", spec.mdhd, hdlr, box("minf", stbl))
+	moov := box("moov", box("trak", mdia))
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("M4A "), u32(0), []byte("M4A isom")),
+		moov,
+		box("mdat", make([]byte, spec.mdatSize)),
+	}, nil)
+}
+
+// voiceM4A returns the spec of a recording of 500 frames of 20 ms at a time scale of 1000
+func voiceM4A() m4aSpec {
+	sizes := make([]uint32, 500)
+	total := 0
+	for i := range sizes {
+		// Louder in the middle
+		sizes[i] = uint32(40 + 200 - abs(i-250)*200/250)
+		total += int(sizes[i])
+	}
+
+	return m4aSpec{
+		timeScale: 1000,
+		stsz:      sampleSizes(sizes...),
+		stts:      sampleDurations(500, 20),
+		mdatSize:  total,
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func TestProcessMP4(t *testing.T) {
+	valid := voiceM4A()
+
+	zeroTimeScale := voiceM4A()
+	zeroTimeScale.timeScale = 0
+
+	noTimeScale := voiceM4A()
+	noTimeScale.mdhd = []byte{}
+
+	shortMdhd := voiceM4A()
+	shortMdhd.mdhd = box("mdhd", u32(0, 0, 0))
+
+	version1 := voiceM4A()
+	version1.mdhd = box("mdhd", u32(0x01000000), make([]byte, 16), u32(1000), make([]byte, 8), make([]byte, 4))
+
+	withDuration := voiceM4A()
+	withDuration.mdhd = box("mdhd", u32(0, 0, 0, 1000, 9500), make([]byte, 4))
+
+	hugeCount := m4aSpec{
+		timeScale: 1000,
+		stsz:      constantSampleSizes(100, 10_000_000),
+		stts:      sampleDurations(10_000_000, 1),
+		mdatSize:  1000,
+	}
+
+	countPastMedia := m4aSpec{
+		timeScale: 1000,
+		stsz:      constantSampleSizes(1000, 3000),
+		stts:      sampleDurations(3000, 20),
+		mdatSize:  1000,
+	}
+
+	countPastBox := voiceM4A()
+	countPastBox.stsz = box("stsz", u32(0, 0, 500), u32(100, 100))
+
+	hugeDelta := m4aSpec{
+		timeScale: 1,
+		stsz:      sampleSizes(100),
+		stts:      sampleDurations(1, 0xFFFFFFFF),
+		mdatSize:  100,
+	}
+
+	missingDurations := voiceM4A()
+	missingDurations.stts = sampleDurations(400, 20)
+
+	extraDurations := voiceM4A()
+	extraDurations.stts = sampleDurations(500, 20, 1, 20)
+
+	zeroDurations := voiceM4A()
+	zeroDurations.stts = sampleDurations(500, 0)
+
+	shortMedia := voiceM4A()
+	shortMedia.mdatSize -= 1
+
+	mp3 := voiceM4A()
+	mp3.objectType = 0x6B
+
+	video := voiceM4A()
+	video.handler = "vide"
+
+	tests := []struct {
+		name        string
+		data        []byte
+		maxDuration time.Duration
+		duration    time.Duration
+		err         error
+	}{
+		{name: "valid", data: valid.build(), maxDuration: time.Minute, duration: 10 * time.Second},
+		{name: "exactly the maximum duration", data: valid.build(), maxDuration: 10 * time.Second, duration: 10 * time.Second},
+		{name: "64-bit media header", data: version1.build(), maxDuration: time.Minute, duration: 10 * time.Second},
+		{name: "media duration shorter than the samples", data: withDuration.build(), maxDuration: time.Minute, duration: 9500 * time.Millisecond},
+		{name: "zero time scale", data: zeroTimeScale.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "absent media header", data: noTimeScale.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "truncated media header", data: shortMdhd.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "too long", data: valid.build(), maxDuration: 5 * time.Second, err: ErrTooLong},
+		{name: "too long by a fraction of a second", data: valid.build(), maxDuration: 9500 * time.Millisecond, err: ErrTooLong},
+		{name: "huge sample count", data: hugeCount.build(), maxDuration: time.Minute, err: ErrTooLong},
+		{name: "sample count past the media data", data: countPastMedia.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "sample count past the size box", data: countPastBox.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "huge sample duration", data: hugeDelta.build(), maxDuration: time.Minute, err: ErrTooLong},
+		{name: "samples without duration", data: missingDurations.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "durations without sample", data: extraDurations.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "zero duration", data: zeroDurations.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "media data smaller than the samples", data: shortMedia.build(), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "truncated media data", data: valid.build()[:len(valid.build())-100], maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "truncated movie box", data: valid.build()[:100], maxDuration: time.Minute, err: ErrUnsupportedAudio},
+		{name: "MP3 in MP4", data: mp3.build(), maxDuration: time.Minute, err: ErrUnsupportedAudio},
+		{name: "video track", data: video.build(), maxDuration: time.Minute, err: ErrUnsupportedAudio},
+		{name: "file type box only", data: box("ftyp", []byte("M4A ")), maxDuration: time.Minute, err: ErrUnsupportedAudio},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data, tt.maxDuration)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Process() = %+v, %v, want error %v", result, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if result.ContentType != ContentTypeMP4 || result.Codec != "aac" {
+				t.Errorf("got %s %s, want %s aac", result.ContentType, result.Codec, ContentTypeMP4)
+			}
+			if result.Duration != tt.duration {
+				t.Errorf("Duration = %v, want %v", result.Duration, tt.duration)
+			}
+			checkWaveform(t, result.Waveform)
+		})
+	}
+}
+
+const (
+	// opusCELT20ms is the TOC byte of a packet holding a single 20 ms CELT frame
+	opusCELT20ms = 31 << 3
+
+	// opusPreSkip is the pre-skip of the generated recordings
+	opusPreSkip = 312
+)
+
+// oggPage builds an Ogg page holding complete packets
+func oggPage(sequence uint32, flags byte, granule int64, packets ...[]byte) []byte {
+	var lacing, body []byte
+	for _, packet := range packets {
+		size := len(packet)
+		for ; size >= 255; size -= 255 {
+			lacing = append(lacing, 255)
+		}
+		lacing = append(lacing, byte(size))
+		body = append(body, packet...)
+	}
+
+	page := []byte("OggS\x00")
+	page = append(page, flags)
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, 0x1234) // Serial
+	page = binary.LittleEndian.AppendUint32(page, sequence)
+	page = append(page, 0, 0, 0, 0, byte(len(lacing)))
+	page = append(page, lacing...)
+	page = append(page, body...)
+
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(0, page))
+	return page
+}
+
+func opusHead(preSkip uint16) []byte {
+	head := []byte("OpusHead\x01\x01")
+	head = binary.LittleEndian.AppendUint16(head, preSkip)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	return append(head, 0, 0, 0)
+}
+
+// opusRecording builds an Ogg/Opus recording of 20 ms packets, 50 per page
+// The granule position of the last page is moved by trim samples
+func opusRecording(packets int, trim int64) [][]byte {
+	pages := [][]byte{
+		oggPage(0, oggFirstPage, 0, opusHead(opusPreSkip)),
+		oggPage(1, 0, 0, []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")),
+	}
+
+	var position int64
+	for written := 0; written < packets; {
+		var page [][]byte
+		for ; written < packets && len(page) < 50; written++ {
+			// Louder in the middle
+			page = append(page, append([]byte{opusCELT20ms}, make([]byte, 20+120-abs(written-packets/2)*120/max(1, packets/2))...))
+			position += 960
+		}
+
+		granule := position
+		if written == packets {
+			granule -= trim
+		}
+		pages = append(pages, oggPage(uint32(len(pages)), 0, granule, page...))
+	}
+	return pages
+}
+
+func TestProcessOgg(t *testing.T) {
+	// 100 packets of 20 ms make 2 s, minus the pre-skip
+	valid := bytes.Join(opusRecording(100, 0), nil)
+	validDuration := 2*time.Second - opusPreSkip*time.Second/opusTimeScale
+
+	trimmed := bytes.Join(opusRecording(100, 480), nil)
+
+	corrupt := append([]byte{}, valid...)
+	corrupt[len(corrupt)-1] ^= 0xFF
+
+	pages := opusRecording(100, 0)
+	skipped := bytes.Join(append(pages[:2:2], pages[3:]...), nil)
+
+	vorbis := oggPage(0, oggFirstPage, 0, []byte("\x01vorbis\x00\x00\x00\x00\x01\x44\xac\x00\x00"))
+
+	granulePastPackets := bytes.Join(opusRecording(100, -960), nil)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		maxDuration time.Duration
+		duration    time.Duration
+		err         error
+	}{
+		{name: "valid", data: valid, maxDuration: time.Minute, duration: validDuration},
+		{name: "last packet trimmed", data: trimmed, maxDuration: time.Minute, duration: validDuration - 10*time.Millisecond},
+		{name: "too long", data: valid, maxDuration: time.Second, err: ErrTooLong},
+		{name: "too long by a fraction of a second", data: valid, maxDuration: 1900 * time.Millisecond, err: ErrTooLong},
+		{name: "huge recording stops at the maximum", data: bytes.Join(opusRecording(10_000, 0), nil), maxDuration: 5 * time.Second, err: ErrTooLong},
+		{name: "truncated page", data: valid[:len(valid)-10], maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "truncated page header", data: valid[:len(valid)-len(pages[len(pages)-1])+10], maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "truncated first page", data: valid[:20], maxDuration: time.Minute, err: ErrUnsupportedAudio},
+		{name: "corrupt checksum", data: corrupt, maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "missing page", data: skipped, maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "granule position past the packets", data: granulePastPackets, maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "headers only", data: bytes.Join(pages[:2], nil), maxDuration: time.Minute, err: ErrInvalidAudio},
+		{name: "Vorbis", data: vorbis, maxDuration: time.Minute, err: ErrUnsupportedAudio},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data, tt.maxDuration)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Process() = %+v, %v, want error %v", result, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if result.ContentType != ContentTypeOgg || result.Codec != "opus" {
+				t.Errorf("got %s %s, want %s opus", result.ContentType, result.Codec, ContentTypeOgg)
+			}
+			if result.Duration != tt.duration {
+				t.Errorf("Duration = %v, want %v", result.Duration, tt.duration)
+			}
+			checkWaveform(t, result.Waveform)
+		})
+	}
+}
+
+// checkWaveform checks that a waveform of the generated recordings peaks in the middle
+func checkWaveform(t *testing.T, bars []int) {
+	t.Helper()
+
+	if len(bars) != WaveformBars {
+		t.Fatalf("got %d bars, want %d", len(bars), WaveformBars)
+	}
+	for i, bar := range bars {
+		if bar < 0 || bar > WaveformMax {
+			t.Fatalf("bar %d = %d, out of range", i, bar)
+		}
+	}
+	if middle := bars[WaveformBars/2]; middle < WaveformMax*3/4 || bars[0] > middle || bars[WaveformBars-1] > middle {
+		t.Errorf("waveform does not peak in the middle: %v", bars)
+	}
+}
+
+func TestProcessUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"WAV", []byte("RIFF\x24\x00\x00\x00WAVEfmt ")},
+		{"short MP4 header", []byte("\x00\x00\x00")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data, time.Minute); !errors.Is(err, ErrUnsupportedAudio) {
+				t.Errorf("Process() error = %v, want %v", err, ErrUnsupportedAudio)
+			}
+		})
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	frames := []frame{{start: 0, duration: 1, size: 1}}
+
+	tests := []struct {
+		name      string
+		samples   int64
+		timeScale int64
+		duration  time.Duration
+		err       error
+	}{
+		{name: "whole seconds", samples: 96000, timeScale: 48000, duration: 2 * time.Second},
+		{name: "fraction of a second", samples: 48001, timeScale: 48000, duration: time.Second + 20833},
+		{name: "zero samples", samples: 0, timeScale: 48000, err: ErrInvalidAudio},
+		{name: "negative samples", samples: -1, timeScale: 48000, err: ErrInvalidAudio},
+		{name: "zero time scale", samples: 48000, timeScale: 0, err: ErrInvalidAudio},
+		{name: "rounds down to nothing", samples: 1, timeScale: 1 << 40, err: ErrInvalidAudio},
+		{name: "would overflow nanoseconds", samples: 1 << 62, timeScale: 1, err: ErrTooLong},
+		{name: "just over the maximum", samples: 60*48000 + 1, timeScale: 48000, err: ErrTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newResult(ContentTypeOgg, "opus", frames, tt.samples, tt.timeScale, time.Minute)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("newResult() = %+v, %v, want error %v", result, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newResult() error = %v", err)
+			}
+			if result.Duration != tt.duration {
+				t.Errorf("Duration = %v, want %v", result.Duration, tt.duration)
+			}
+		})
+	}
+}
+
+func TestOpusPacketSamples(t *testing.T) {
+	tests := []struct {
+		name    string
+		packet  []byte
+		samples int64
+		err     error
+	}{
+		{name: "SILK 60 ms", packet: []byte{3 << 3}, samples: 2880},
+		{name: "hybrid 20 ms", packet: []byte{13 << 3}, samples: 960},
+		{name: "CELT 2.5 ms", packet: []byte{16 << 3}, samples: 120},
+		{name: "two frames", packet: []byte{opusCELT20ms | 1}, samples: 1920},
+		{name: "counted frames", packet: []byte{opusCELT20ms | 3, 6}, samples: 5760},
+		{name: "more than 120 ms", packet: []byte{opusCELT20ms | 3, 7}, err: ErrInvalidAudio},
+		{name: "no frames", packet: []byte{opusCELT20ms | 3, 0}, err: ErrInvalidAudio},
+		{name: "missing frame count", packet: []byte{opusCELT20ms | 3}, err: ErrInvalidAudio},
+		{name: "empty", packet: nil, err: ErrInvalidAudio},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples, err := opusPacketSamples(tt.packet)
+			if !errors.Is(err, tt.err) || samples != tt.samples {
+				t.Errorf("opusPacketSamples() = %d, %v, want %d, %v", samples, err, tt.samples, tt.err)
+			}
+		})
+	}
+}
+
+func TestNextBox(t *testing.T) {
+	largeSize := append(u32(1), []byte("free")...)
+	largeSize = binary.BigEndian.AppendUint64(largeSize, 20)
+	largeSize = append(largeSize, 1, 2, 3, 4)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		boxType string
+		payload []byte
+		err     error
+	}{
+		{name: "box", data: box("free", []byte{1, 2}), boxType: "free", payload: []byte{1, 2}},
+		{name: "extends to the end", data: append(u32(0), "mdat\x01\x02\x03"...), boxType: "mdat", payload: []byte{1, 2, 3}},
+		{name: "64-bit size", data: largeSize, boxType: "free", payload: []byte{1, 2, 3, 4}},
+		{name: "short header", data: []byte("\x00\x00\x00\x08fre"), err: errBoxTruncated},
+		{name: "short 64-bit header", data: append(u32(1), "free\x00\x00"...), err: errBoxTruncated},
+		{name: "size past the end", data: append(u32(100), "free"...), err: errBoxTruncated},
+		{name: "size smaller than the header", data: append(u32(4), "free"...), err: errBoxTruncated},
+		{name: "huge 64-bit size", data: binary.BigEndian.AppendUint64(append(u32(1), "free"...), 1<<63), err: errBoxTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			boxType, payload, _, err := nextBox(tt.data)
+			if !errors.Is(err, tt.err) || boxType != tt.boxType || !bytes.Equal(payload, tt.payload) {
+				t.Errorf("nextBox() = %q, %v, %v, want %q, %v, %v", boxType, payload, err, tt.boxType, tt.payload, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package audioproc
+
+const (
+	// WaveformBars is the number of values of a waveform
+	WaveformBars = 64
+
+	// WaveformMax is the value of the loudest bar
+	WaveformMax = 100
+)
+
+// waveform computes the bars shown by players from the frames of a recording
+// Opus and AAC encoders spend more bytes on louder and busier passages, so the bitrate of
+// each bar follows the loudness of the recording closely enough for a preview.
+// Constant bitrate recordings carry no such hint and get a flat waveform
+func waveform(frames []frame, samples int64) []int {
+	rates := make([]float64, WaveformBars)
+	barLength := float64(samples) / WaveformBars
+
+	// Spread the bytes of every frame over the bars it overlaps
+	for _, f := range frames {
+		if f.duration <= 0 {
+			continue
+		}
+		start, end := float64(f.start), float64(f.start+f.duration)
+		rate := float64(f.size) / float64(f.duration)
+
+		for bar := max(0, int(start/barLength)); bar < WaveformBars; bar++ {
+			barStart, barEnd := float64(bar)*barLength, float64(bar+1)*barLength
+			if barStart >= end {
+				break
+			}
+			overlap := min(end, barEnd) - max(start, barStart)
+			if overlap > 0 {
+				rates[bar] += rate * overlap / barLength
+			}
+		}
+	}
+
+	// The quietest bar is the bitrate of silence, which is not zero
+	lowest, highest := rates[0], rates[0]
+	for _, rate := range rates {
+		lowest = min(lowest, rate)
+		highest = max(highest, rate)
+	}
+
+	bars := make([]int, WaveformBars)
+	if highest == lowest {
+		return bars
+	}
+	for i, rate := range rates {
+		bars[i] = int((rate-lowest)/(highest-lowest)*WaveformMax + 0.5)
+	}
+	return bars
+}
@@ -0,0 +1,261 @@
+// Package commands runs slash commands: messages like "/topic Release planning" run a command instead of being sent
+// Commands are registered in a registry with their usage, help text and the permission needed to run them
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Response types
+const (
+	ResponseEphemeral = "ephemeral"  // Only shown to the caller
+	ResponseInChannel = "in_channel" // Posted to the conversation as a message of the caller
+)
+
+// Permissions needed to run a command
+const (
+	PermissionMember            = "member"             // Any participant of the conversation
+	PermissionConversationAdmin = "conversation_admin" // Group admins, or any participant of a direct conversation
+	PermissionWorkspaceAdmin    = "workspace_admin"    // Workspace admins participating in the conversation
+)
+
+// Unlimited is the MaxArgs of commands taking any number of arguments
+const Unlimited = -1
+
+// namePattern matches valid command names
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// commandPattern matches a command at the start of a message
+var commandPattern = regexp.MustCompile(`^/([a-zA-Z0-9][a-zA-Z0-9_-]*)(?:\s+|$)`)
+
+// ErrInvalidName is returned when registering a command with an invalid name
+var ErrInvalidName = errors.New("command names must be 1 to 32 lowercase letters, digits, dashes or underscores")
+
+// ErrDuplicate is returned when registering a command twice
+var ErrDuplicate = errors.New("command is already registered")
+
+// Call is a command run by a user in a conversation
+type Call struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	UserName       string
+	Name           string     // Without the slash
+	Text           string     // Arguments as typed
+	Args           []string   // Arguments split on spaces, quoted arguments are kept together
+	ReplyToID      *uuid.UUID // Thread the command was run in
+}
+
+// Response is the reply of a command
+type Response struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+	Markdown     bool   `json:"-"` // Text of in_channel responses is posted as Markdown
+}
+
+// Ephemeral creates a reply only shown to the caller
+func Ephemeral(format string, args ...interface{}) *Response {
+	return &Response{ResponseType: ResponseEphemeral, Text: fmt.Sprintf(format, args...)}
+}
+
+// InChannel creates a reply posted to the conversation
+func InChannel(text string) *Response {
+	return &Response{ResponseType: ResponseInChannel, Text: text}
+}
+
+// Handler runs a command, it returns a nil response when there is nothing to reply
+type Handler func(ctx context.Context, call *Call) (*Response, error)
+
+// Command is a slash command
+type Command struct {
+	Name        string
+	Usage       string // Arguments, like "@user [@user...]"
+	Description string
+	Permission  string
+	MinArgs     int
+	MaxArgs     int // Unlimited for no maximum
+	External    bool
+	Handler     Handler
+}
+
+// UsageText returns how to call the command
+func (c *Command) UsageText() string {
+	if c.Usage == "" {
+		return "/" + c.Name
+	}
+	return "/" + c.Name + " " + c.Usage
+}
+
+// CheckArgs checks the number of arguments of a call
+func (c *Command) CheckArgs(call *Call) error {
+	if len(call.Args) < c.MinArgs || (c.MaxArgs != Unlimited && len(call.Args) > c.MaxArgs) {
+		return fmt.Errorf("usage: %s", c.UsageText())
+	}
+	return nil
+}
+
+// Registry holds the commands by name
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]*Command
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]*Command)}
+}
+
+// Register adds a command to the registry
+func (r *Registry) Register(cmd *Command) error {
+	if !namePattern.MatchString(cmd.Name) {
+		return ErrInvalidName
+	}
+	if cmd.Permission == "" {
+		cmd.Permission = PermissionMember
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.commands[cmd.Name]; exists {
+		return fmt.Errorf("%w: /%s", ErrDuplicate, cmd.Name)
+	}
+	r.commands[cmd.Name] = cmd
+	return nil
+}
+
+// Lookup gets a command by name
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// Commands returns the registered commands ordered by name
+func (r *Registry) Commands() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commands := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// ValidName reports whether a command name can be registered
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Parse extracts the command name and arguments from message content
+// Returns false if the content is not a command. Content starting with // is a message starting with /
+func Parse(content string) (name, text string, ok bool) {
+	match := commandPattern.FindStringSubmatch(content)
+	if match == nil {
+		return "", "", false
+	}
+
+	return strings.ToLower(match[1]), strings.TrimSpace(content[len(match[0]):]), true
+}
+
+// IsCommand reports whether message content runs a command
+func IsCommand(content string) bool {
+	return commandPattern.MatchString(content)
+}
+
+// Unescape removes the slash escaping a message starting with //
+func Unescape(content string) string {
+	if strings.HasPrefix(content, "//") {
+		return content[1:]
+	}
+	return content
+}
+
+// SplitArgs splits command arguments on spaces
+// Double-quoted arguments can contain spaces, as in /poll "Lunch?" "Pizza" "Sushi"
+func SplitArgs(text string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuotes, inArg := false, false
+
+	for _, r := range text {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			inArg = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if inQuotes {
+		return nil, errors.New("unterminated quoted argument")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// Suggest returns the name closest to an unknown command, or an empty string if none is close
+func Suggest(name string, commands []*Command) string {
+	best, bestDistance := "", 3 // Names more than 2 edits away are not suggested
+	for _, cmd := range commands {
+		if distance := editDistance(name, cmd.Name); distance < bestDistance {
+			best, bestDistance = cmd.Name, distance
+		}
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between two names
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+
+	return prev[len(b)]
+}
+
+// Help describes the commands, one per line
+func Help(commands []*Command) string {
+	lines := make([]string, 0, len(commands)+1)
+	lines = append(lines, "Available commands:")
+	for _, cmd := range commands {
+		lines = append(lines, fmt.Sprintf("%s - %s", cmd.UsageText(), cmd.Description))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxHTTPResponseSize limits the responses read from command endpoints
+const maxHTTPResponseSize = 1 << 20
+
+// Headers of command requests, receivers verify the signature to authenticate them
+const (
+	TimestampHeader = "X-GoSwift-Timestamp"
+	SignatureHeader = "X-GoSwift-Signature" // "v1=" and the hex HMAC-SHA256 of "<timestamp>.<body>" with the command secret
+)
+
+// httpRequest is the body sent to command endpoints
+type httpRequest struct {
+	Command        string     `json:"command"`
+	Text           string     `json:"text"`
+	Args           []string   `json:"args"`
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	UserName       string     `json:"user_name"`
+	ReplyToID      *uuid.UUID `json:"reply_to_id,omitempty"`
+}
+
+// NewHTTPHandler creates a handler calling a third-party endpoint
+// The endpoint receives the call as JSON and answers with a Response, an empty body means no reply
+func NewHTTPHandler(url, secret string, timeout time.Duration) Handler {
+	client := &http.Client{Timeout: timeout}
+
+	return func(ctx context.Context, call *Call) (*Response, error) {
+		body, err := json.Marshal(&httpRequest{
+			Command:        "/" + call.Name,
+			Text:           call.Text,
+			Args:           call.Args,
+			ConversationID: call.ConversationID,
+			UserID:         call.UserID,
+			UserName:       call.UserName,
+			ReplyToID:      call.ReplyToID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode command: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call command endpoint: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("command endpoint returned status %d", resp.StatusCode)
+		}
+
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read command response: %w", err)
+		}
+		if len(bytes.TrimSpace(respBody)) == 0 {
+			return nil, nil
+		}
+
+		var response Response
+		if err := json.Unmarshal(respBody, &response); err != nil {
+			return nil, fmt.Errorf("failed to decode command response: %w", err)
+		}
+
+		switch response.ResponseType {
+		case "":
+			response.ResponseType = ResponseEphemeral
+		case ResponseEphemeral, ResponseInChannel:
+		default:
+			return nil, fmt.Errorf("command endpoint returned unknown response type %q", response.ResponseType)
+		}
+
+		return &response, nil
+	}
+}
+
+// Sign computes the signature of a command request
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
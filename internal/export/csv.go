@@ -0,0 +1,75 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvColumns is the header row of CSV exports
+// CSV exports hold one row per message, participants are listed in the JSON and HTML formats
+var csvColumns = []string{
+	"message_id", "created_at", "edited_at", "sender_id", "sender_name", "message_type", "content",
+	"reply_to_id", "thread_root_id", "forwarded_from", "attachments", "system_event",
+}
+
+// csvWriter writes one row per message
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin(_ *Header) error {
+	return c.w.Write(csvColumns)
+}
+
+func (c *csvWriter) WriteMessage(message *Message) error {
+	var editedAt, forwardedFrom, systemEvent string
+	if message.EditedAt != nil {
+		editedAt = message.EditedAt.UTC().Format(time.RFC3339)
+	}
+	if message.ForwardedFrom != nil {
+		forwardedFrom = message.ForwardedFrom.SenderName
+	}
+	if message.SystemEvent != nil {
+		systemEvent = message.SystemEvent.Event
+	}
+
+	attachments := make([]string, 0, len(message.Attachments))
+	for _, attachment := range message.Attachments {
+		attachments = append(attachments, fmt.Sprintf("%s (%s, %d bytes)", attachment.FileName, attachment.ContentType, attachment.SizeBytes))
+	}
+
+	return c.w.Write([]string{
+		message.ID.String(),
+		message.CreatedAt.UTC().Format(time.RFC3339),
+		editedAt,
+		message.SenderID.String(),
+		escapeFormula(message.SenderName),
+		message.MessageType,
+		escapeFormula(message.Content),
+		uuidString(message.ReplyToID),
+		uuidString(message.ThreadRootID),
+		escapeFormula(forwardedFrom),
+		escapeFormula(strings.Join(attachments, "; ")),
+		systemEvent,
+	})
+}
+
+func (c *csvWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula prefixes values that spreadsheets would evaluate as formulas
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
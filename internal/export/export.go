@@ -0,0 +1,133 @@
+// Package export writes conversation transcripts as JSON, self-contained HTML or CSV
+// Transcripts are streamed: the header is written first, then messages one at a time in chronological order
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Supported formats
+const (
+	FormatJSON = "json"
+	FormatHTML = "html"
+	FormatCSV  = "csv"
+)
+
+// Header describes the exported conversation
+type Header struct {
+	Conversation Conversation  `json:"conversation"`
+	Participants []Participant `json:"participants"`
+	ExportedAt   time.Time     `json:"exported_at"`
+	ExportedBy   uuid.UUID     `json:"exported_by"`
+}
+
+// Conversation is the exported conversation
+type Conversation struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Participant is a current member of the exported conversation
+type Participant struct {
+	UserID      uuid.UUID `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email"`
+	IsAdmin     bool      `json:"is_admin"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// Message is an exported message
+type Message struct {
+	ID            uuid.UUID           `json:"id"`
+	SenderID      uuid.UUID           `json:"sender_id"`
+	SenderName    string              `json:"sender_name"`
+	MessageType   string              `json:"message_type"`
+	Content       string              `json:"content"`
+	CreatedAt     time.Time           `json:"created_at"`
+	EditedAt      *time.Time          `json:"edited_at,omitempty"` // Last change after sending
+	ReplyToID     *uuid.UUID          `json:"reply_to_id,omitempty"`
+	ThreadRootID  *uuid.UUID          `json:"thread_root_id,omitempty"`
+	ForwardedFrom *ForwardedFrom      `json:"forwarded_from,omitempty"`
+	Attachments   []Attachment        `json:"attachments,omitempty"`
+	SystemEvent   *models.SystemEvent `json:"system_event,omitempty"`
+	Payload       json.RawMessage     `json:"payload,omitempty"` // Typed messages, content holds the plain-text fallback
+	Snippet       *Snippet            `json:"snippet,omitempty"` // Snippet messages, content holds a description of the code
+}
+
+// Snippet is the code of an exported snippet message
+type Snippet struct {
+	Language string `json:"language"`
+	Filename string `json:"filename,omitempty"`
+	Code     string `json:"code"`
+}
+
+// ForwardedFrom identifies the original of a forwarded message
+type ForwardedFrom struct {
+	SenderName string    `json:"sender_name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Attachment is the metadata of an exported attachment, the files themselves are not included
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	Width       *int      `json:"width,omitempty"`
+	Height      *int      `json:"height,omitempty"`
+	DurationMs  *int      `json:"duration_ms,omitempty"` // Voice recordings
+}
+
+// Writer writes a transcript
+// Begin is called once, then WriteMessage for each message, then End
+type Writer interface {
+	Begin(header *Header) error
+	WriteMessage(message *Message) error
+	End() error
+}
+
+// NewWriter creates a writer of the format writing to w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return newJSONWriter(w), nil
+	case FormatHTML:
+		return newHTMLWriter(w), nil
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType returns the content type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatJSON:
+		return "application/json"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// uuidString formats an optional ID, empty when unset
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// htmlHeader opens the document, styles are inlined so the file is self-contained
+var htmlHeader = template.Must(template.New("header").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Conversation.Name}} - GoSwift transcript</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 0 auto; max-width: 960px; padding: 24px; color: #1f2937; }
+h1 { font-size: 1.5rem; margin-bottom: 4px; }
+.meta, .time, .note { color: #6b7280; font-size: 0.85rem; }
+table { border-collapse: collapse; margin: 16px 0 24px; }
+th, td { border: 1px solid #e5e7eb; padding: 4px 10px; text-align: left; font-size: 0.9rem; }
+.message { border-bottom: 1px solid #f3f4f6; padding: 8px 0; }
+.message.reply { margin-left: 32px; }
+.message.system { color: #6b7280; font-style: italic; }
+.sender { font-weight: 600; }
+.content { white-space: pre-wrap; overflow-wrap: anywhere; margin-top: 2px; }
+.attachments { margin: 4px 0 0; padding-left: 20px; font-size: 0.85rem; }
+.snippet { background: #f9fafb; border: 1px solid #e5e7eb; margin: 4px 0 0; padding: 8px; overflow-x: auto; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>{{.Conversation.Name}}</h1>
+<p class="meta">{{.Conversation.Type}} conversation {{.Conversation.ID}}, created {{formatTime .Conversation.CreatedAt}}. Exported {{formatTime .ExportedAt}} by {{.ExportedBy}}.</p>
+<h2>Participants</h2>
+<table>
+<tr><th>Name</th><th>Email</th><th>Role</th><th>Joined</th></tr>
+{{range .Participants}}<tr><td>{{.DisplayName}}</td><td>{{.Email}}</td><td>{{if .IsAdmin}}Admin{{else}}Member{{end}}</td><td>{{formatTime .JoinedAt}}</td></tr>
+{{end}}</table>
+<h2>Messages</h2>
+`))
+
+// htmlMessage renders a message
+var htmlMessage = template.Must(template.New("message").Funcs(htmlFuncs).Parse(`<div class="message{{if .ThreadRootID}} reply{{end}}{{if .SystemEvent}} system{{end}}" id="m-{{.ID}}">
+<span class="sender">{{.SenderName}}</span> <span class="time">{{formatTime .CreatedAt}}{{if .EditedAt}} (edited {{formatTime .EditedAt}}){{end}}</span>
+{{if .ThreadRootID}}<div class="note">Reply in thread of <a href="#m-{{.ThreadRootID}}">a message</a></div>{{end}}
+{{if .ForwardedFrom}}<div class="note">Forwarded from {{.ForwardedFrom.SenderName}}, {{formatTime .ForwardedFrom.CreatedAt}}</div>{{end}}
+<div class="content">{{.Content}}</div>
+{{if .Snippet}}<pre class="snippet">{{.Snippet.Code}}</pre>{{end}}
+{{if .Attachments}}<ul class="attachments">{{range .Attachments}}<li>{{.FileName}} ({{.ContentType}}, {{formatSize .SizeBytes}}{{if .Width}}, {{.Width}}x{{.Height}}{{end}})</li>{{end}}</ul>{{end}}
+</div>
+`))
+
+var htmlFuncs = template.FuncMap{
+	"formatTime": func(value interface{}) string {
+		switch t := value.(type) {
+		case time.Time:
+			return t.UTC().Format("2006-01-02 15:04:05 UTC")
+		case *time.Time:
+			if t != nil {
+				return t.UTC().Format("2006-01-02 15:04:05 UTC")
+			}
+		}
+		return ""
+	},
+	"formatSize": formatSize,
+}
+
+// htmlWriter writes a standalone HTML page
+type htmlWriter struct {
+	w        *bufio.Writer
+	messages int
+}
+
+func newHTMLWriter(w io.Writer) *htmlWriter {
+	return &htmlWriter{w: bufio.NewWriter(w)}
+}
+
+func (h *htmlWriter) Begin(header *Header) error {
+	return htmlHeader.Execute(h.w, header)
+}
+
+func (h *htmlWriter) WriteMessage(message *Message) error {
+	h.messages++
+	return htmlMessage.Execute(h.w, message)
+}
+
+func (h *htmlWriter) End() error {
+	footer := "</body>\n</html>\n"
+	if h.messages == 0 {
+		footer = "<p class=\"note\">No messages.</p>\n" + footer
+	}
+	if _, err := h.w.WriteString(footer); err != nil {
+		return err
+	}
+	return h.w.Flush()
+}
+
+// formatSize formats a file size for people
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+}
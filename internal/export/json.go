@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// jsonWriter writes the header fields and a messages array, one message per line
+type jsonWriter struct {
+	w        *bufio.Writer
+	messages int
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonWriter) Begin(header *Header) error {
+	data, err := json.MarshalIndent(header, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The header object is left open so the messages array can be appended
+	opened := strings.TrimSuffix(strings.TrimSpace(string(data)), "}")
+	opened = strings.TrimRight(opened, "\n ")
+	if _, err := j.w.WriteString(opened + ",\n  \"messages\": ["); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (j *jsonWriter) WriteMessage(message *Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	separator := "\n    "
+	if j.messages > 0 {
+		separator = "," + separator
+	}
+	j.messages++
+
+	if _, err := j.w.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) End() error {
+	closing := "]\n}\n"
+	if j.messages > 0 {
+		closing = "\n  ]\n}\n"
+	}
+	if _, err := j.w.WriteString(closing); err != nil {
+		return err
+	}
+	return j.w.Flush()
+}
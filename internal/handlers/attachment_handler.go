@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"goswift/internal/service"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is the room left for multipart headers on top of the maximum file size
+const multipartOverhead = 1 << 20
+
+// AttachmentHandler handles file upload and download HTTP requests
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(attachmentService *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// UploadAttachment uploads a file to a conversation
+// @Summary Upload attachment
+// @Description Upload a file to a conversation, then send its ID in attachment_ids of an image, file or voice message.
+// @Description Images are stripped of their metadata, converted to JPEG or PNG (GIF is kept) and get thumbnails and a blurhash.
+// @Description Ogg/Opus and M4A/AAC recordings are checked and get duration_ms and a waveform, which lets them be sent as voice messages
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param file formData file true "File to upload"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Router /conversations/{id}/attachments [post]
+// @Security BearerAuth
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Reject oversized bodies before they are buffered
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachmentService.MaxUploadSize()+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": utils.ErrFileTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required", "details": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.Upload(c.Request.Context(), conversationID, userID, fileHeader.Filename, file, fileHeader.Size)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrFileTooLarge, utils.ErrImageTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case utils.ErrFileTypeNotAllowed:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case utils.ErrInvalidImage, utils.ErrInvalidAudio:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachment downloads an attachment
+// @Summary Download attachment
+// @Description Download an attachment of a conversation the user participates in
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /attachments/{id} [get]
+// @Security BearerAuth
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	attachment, reader, err := h.attachmentService.Open(c.Request.Context(), attachmentID, userID)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrAttachmentNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer reader.Close()
+
+	// Images are shown inline, everything else is downloaded
+	disposition := "attachment"
+	if attachment.IsImage() {
+		disposition = "inline"
+	}
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log.Printf("Error streaming attachment %s: %v", attachment.ID, err)
+	}
+}
+
+// DownloadThumbnail downloads a thumbnail of an image attachment
+// @Summary Download thumbnail
+// @Description Download a thumbnail of an image attachment. Sizes larger than the original image are not generated
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Attachment ID"
+// @Param size path string true "Thumbnail size" Enums(small, medium, large)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /attachments/{id}/thumbnails/{size} [get]
+// @Security BearerAuth
+func (h *AttachmentHandler) DownloadThumbnail(c *gin.Context) {
+	attachmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	thumbnail, reader, err := h.attachmentService.OpenThumbnail(c.Request.Context(), attachmentID, userID, c.Param("size"))
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrAttachmentNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		case utils.ErrThumbnailNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer reader.Close()
+
+	// Thumbnails never change once generated
+	c.Header("Content-Type", thumbnail.ContentType)
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log.Printf("Error streaming thumbnail %s of attachment %s: %v", thumbnail.Size, attachmentID, err)
+	}
+}
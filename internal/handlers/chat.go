@@ -1,32 +1,48 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"log"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"goswift/internal/commands"
 	"goswift/internal/models"
 	"goswift/internal/service"
 	"goswift/internal/websocket"
+	"goswift/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type ChatHandler struct {
-	chatService *service.ChatService
-	wsHandler   *websocket.Handler
+	chatService         *service.ChatService
+	notificationService *service.NotificationService
+	commandService      *service.CommandService
+	reminderService     *service.ReminderService
+	wsHandler           *websocket.Handler
 }
 
-func NewChatHandler(chatService *service.ChatService, wsHandler *websocket.Handler) *ChatHandler {
-	return &ChatHandler{
-		chatService: chatService,
-		wsHandler:   wsHandler,
+func NewChatHandler(chatService *service.ChatService, notificationService *service.NotificationService, commandService *service.CommandService, reminderService *service.ReminderService, wsHandler *websocket.Handler) *ChatHandler {
+	h := &ChatHandler{
+		chatService:         chatService,
+		notificationService: notificationService,
+		commandService:      commandService,
+		reminderService:     reminderService,
+		wsHandler:           wsHandler,
 	}
+	h.registerCommands()
+	return h
 }
 
 // CreateConversation creates a new conversation
 // @Summary Create a new conversation
-// @Description Create a new conversation (direct or group)
+// @Description Create a new conversation (direct or group). A system message records the creation
 // @Tags chat
 // @Accept json
 // @Produce json
@@ -59,12 +75,16 @@ func (h *ChatHandler) CreateConversation(c *gin.Context) {
 
 	req.CreatedBy = userID
 
-	conversation, err := h.chatService.CreateConversation(&req)
+	conversation, systemMessage, err := h.chatService.CreateConversation(&req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if h.wsHandler != nil && systemMessage != nil {
+		h.broadcastNewMessage(systemMessage)
+	}
+
 	c.JSON(http.StatusCreated, conversation)
 }
 
@@ -148,35 +168,85 @@ func (h *ChatHandler) GetConversation(c *gin.Context) {
 	c.JSON(http.StatusOK, conversation)
 }
 
-// SendMessage sends a message to a conversation
-// @Summary Send a message
-// @Description Send a message to a conversation
+// UpdateConversation renames a conversation
+// @Summary Rename conversation
+// @Description Rename a conversation. Only admins can rename a group. A system message records the change
 // @Tags chat
 // @Accept json
 // @Produce json
 // @Param id path string true "Conversation ID"
-// @Param message body models.SendMessageRequest true "Message details"
-// @Success 201 {object} models.MessageResponse
+// @Param request body models.UpdateConversationRequest true "New name"
+// @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
-// @Router /conversations/{id}/messages [post]
+// @Router /conversations/{id} [patch]
 // @Security BearerAuth
-func (h *ChatHandler) SendMessage(c *gin.Context) {
-	conversationIDStr := c.Param("id")
-	conversationID, err := uuid.Parse(conversationIDStr)
+func (h *ChatHandler) UpdateConversation(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
 		return
 	}
 
-	var req models.SendMessageRequest
+	var req models.UpdateConversationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	req.ConversationID = conversationID
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	systemMessage, err := h.chatService.RenameConversation(conversationID, userID, req.Name)
+	if err != nil {
+		h.respondMembershipError(c, err)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	h.broadcastConversationUpdated(conversationID, userID, map[string]interface{}{"name": name}, systemMessage)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation renamed", "name": name})
+}
+
+// AddParticipants adds members to a group
+// @Summary Add members
+// @Description Add users to a group conversation, users already in it are skipped. Only admins can add members. A system message records the change
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param request body models.AddParticipantsRequest true "Users to add"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/participants [post]
+// @Security BearerAuth
+func (h *ChatHandler) AddParticipants(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req models.AddParticipantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Get user ID from context
 	userIDStr, exists := c.Get("user_id")
@@ -191,74 +261,122 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := h.chatService.SendMessage(&req, userID)
+	added, systemMessage, err := h.chatService.AddParticipants(conversationID, userID, req.UserIDs)
 	if err != nil {
-		if err.Error() == "user is not a participant in this conversation" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.respondMembershipError(c, err)
 		return
 	}
 
-	// Broadcast message to all connected clients
-	if h.wsHandler != nil {
-		wsMessage := &websocket.Message{
-			Type:      "message",
-			Content:   message.Content,
-			UserID:    message.SenderID.String(),
-			Username:  message.SenderName,
-			Timestamp: message.CreatedAt.Unix(), // Use timestamp from database
-			Data: map[string]interface{}{
-				"id":               message.ID.String(),
-				"conversation_id":  message.ConversationID.String(),
-				"content":          message.Content,
-				"message_type":     message.MessageType,
-			},
+	if len(added) > 0 {
+		addedIDs := make([]string, 0, len(added))
+		for _, user := range added {
+			addedIDs = append(addedIDs, user.ID.String())
 		}
-		h.wsHandler.BroadcastMessage(wsMessage)
+		h.broadcastConversationUpdated(conversationID, userID, map[string]interface{}{"added_user_ids": addedIDs}, systemMessage)
 	}
 
-	c.JSON(http.StatusCreated, message)
+	c.JSON(http.StatusOK, gin.H{"message": "Members added", "added": added})
 }
 
-// GetMessages gets messages for a conversation
-// @Summary Get conversation messages
-// @Description Get messages for a conversation with pagination
+// RemoveParticipant removes a member from a group, or leaves it
+// @Summary Remove member or leave
+// @Description Remove a member from a group conversation, only admins can remove others. Removing yourself leaves the group, the last admin cannot leave while other members remain. A system message records the change
 // @Tags chat
 // @Produce json
 // @Param id path string true "Conversation ID"
-// @Param limit query int false "Number of messages to return (default: 50, max: 100)"
-// @Param offset query int false "Number of messages to skip (default: 0)"
-// @Success 200 {array} models.MessageResponse
+// @Param user_id path string true "User ID of the member"
+// @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
-// @Router /conversations/{id}/messages [get]
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /conversations/{id}/participants/{user_id} [delete]
 // @Security BearerAuth
-func (h *ChatHandler) GetMessages(c *gin.Context) {
-	conversationIDStr := c.Param("id")
-	conversationID, err := uuid.Parse(conversationIDStr)
+func (h *ChatHandler) RemoveParticipant(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
 		return
 	}
 
-	// Get pagination parameters
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
+	targetID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 50
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
-	if limit > 100 {
-		limit = 100
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	systemMessage, err := h.chatService.RemoveParticipant(conversationID, userID, targetID)
+	if err != nil {
+		h.respondMembershipError(c, err)
+		return
+	}
+
+	h.broadcastConversationUpdated(conversationID, userID, map[string]interface{}{"removed_user_id": targetID.String()}, systemMessage)
+
+	// The removed member no longer receives conversation broadcasts
+	if h.wsHandler != nil && systemMessage != nil {
+		h.wsHandler.SendToUser(targetID.String(), &websocket.Message{
+			Type:      "conversation_left",
+			UserID:    userID.String(),
+			Timestamp: time.Now().Unix(),
+			Data: map[string]interface{}{
+				"conversation_id": conversationID.String(),
+				"message":         systemMessage,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// SetParticipantAdmin grants or revokes the admin role of a group member
+// @Summary Change member role
+// @Description Make a member of a group conversation an admin or remove their admin role. Only admins can change roles, the last admin cannot step down while other members remain. A system message records the change
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param user_id path string true "User ID of the member"
+// @Param request body models.SetParticipantAdminRequest true "Admin role"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /conversations/{id}/participants/{user_id}/admin [put]
+// @Security BearerAuth
+func (h *ChatHandler) SetParticipantAdmin(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SetParticipantAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	// Get user ID from context
@@ -274,39 +392,113 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
-	messages, err := h.chatService.GetMessagesByConversationID(conversationID, userID, limit, offset)
+	systemMessage, err := h.chatService.SetParticipantAdmin(conversationID, userID, targetID, *req.IsAdmin)
 	if err != nil {
-		if err.Error() == "user is not a participant in this conversation" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
+		h.respondMembershipError(c, err)
+		return
+	}
+
+	if systemMessage != nil {
+		h.broadcastConversationUpdated(conversationID, userID, map[string]interface{}{
+			"user_id":  targetID.String(),
+			"is_admin": *req.IsAdmin,
+		}, systemMessage)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated", "is_admin": *req.IsAdmin})
+}
+
+// respondMembershipError writes the error response of a conversation management request
+func (h *ChatHandler) respondMembershipError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotParticipant, utils.ErrNotConversationAdmin:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case utils.ErrMemberNotFound, utils.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case utils.ErrConversationNameRequired, utils.ErrGroupOnly:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case utils.ErrLastAdmin:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// broadcastConversationUpdated broadcasts a change of a conversation and the system message recording it
+func (h *ChatHandler) broadcastConversationUpdated(conversationID, userID uuid.UUID, changes map[string]interface{}, systemMessage *models.MessageResponse) {
+	if h.wsHandler == nil {
 		return
 	}
 
-	c.JSON(http.StatusOK, messages)
+	changes["conversation_id"] = conversationID.String()
+	h.wsHandler.BroadcastMessage(&websocket.Message{
+		Type:      "conversation_updated",
+		UserID:    userID.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      changes,
+	})
+
+	if systemMessage != nil {
+		h.broadcastNewMessage(systemMessage)
+	}
 }
 
-// MarkMessageAsRead marks a message as read
-// @Summary Mark message as read
-// @Description Mark a specific message as read
+// SendMessage sends a message to a conversation
+// @Summary Send a message
+// @Description Send a message to a conversation. With format markdown, bold, italics, code, code blocks, links, lists and quotes are parsed into rich_content and content holds the plain text.
+// @Description Retries with the same Idempotency-Key header or client_msg_id return the original message
+// @Description Moderation rules and the classifier may reject the message, mask blocked words and links in content, or flag it for review.
+// @Description Text messages starting with / run a slash command instead and return its result, start the content with // to send a message starting with /
+// @Description Typed messages like locations set message_type to a kind listed by GET /messages/kinds and send a payload instead of content.
+// @Description Voice messages send a single Ogg/Opus or M4A/AAC recording without content, its duration and waveform are in the attachment
+// @Description Snippet messages send code in snippet without content, it is highlighted by the server and shown as a collapsed preview in message lists
 // @Tags chat
+// @Accept json
+// @Produce json
 // @Param id path string true "Conversation ID"
-// @Param message_id path string true "Message ID"
-// @Success 200 {object} map[string]interface{}
+// @Param Idempotency-Key header string false "Client message ID, alternative to client_msg_id"
+// @Param message body models.SendMessageRequest true "Message details"
+// @Success 200 {object} models.MessageResponse "Original message of a retried send"
+// @Success 201 {object} models.MessageResponse
+// @Success 202 {object} models.CommandResult "Slash command run"
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
-// @Router /conversations/{id}/messages/{message_id}/read [post]
+// @Failure 409 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "Snippet too large"
+// @Failure 422 {object} map[string]interface{} "Rejected by moderation"
+// @Failure 502 {object} map[string]interface{} "Third-party slash command failed"
+// @Router /conversations/{id}/messages [post]
 // @Security BearerAuth
-func (h *ChatHandler) MarkMessageAsRead(c *gin.Context) {
-	messageIDStr := c.Param("message_id")
-	messageID, err := uuid.Parse(messageIDStr)
+func (h *ChatHandler) SendMessage(c *gin.Context) {
+	conversationIDStr := c.Param("id")
+	conversationID, err := uuid.Parse(conversationIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req models.SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
 		return
 	}
 
+	req.ConversationID = conversationID
+
+	// The Idempotency-Key header is an alternative to client_msg_id in the body
+	if key := c.GetHeader("Idempotency-Key"); key != "" {
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+		if req.ClientMsgID != "" && req.ClientMsgID != key {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key does not match client_msg_id"})
+			return
+		}
+		req.ClientMsgID = key
+	}
+
 	// Get user ID from context
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
@@ -320,8 +512,48 @@ func (h *ChatHandler) MarkMessageAsRead(c *gin.Context) {
 		return
 	}
 
-	err = h.chatService.MarkMessageAsRead(messageID, userID)
+	if req.MessageType == "text" {
+		if commands.IsCommand(req.Content) {
+			result, err := h.RunCommand(c.Request.Context(), &req, userID)
+			if err != nil {
+				h.respondCommandError(c, err)
+				return
+			}
+			c.JSON(http.StatusAccepted, result)
+			return
+		}
+		req.Content = commands.Unescape(req.Content)
+	}
+
+	message, created, err := h.chatService.SendMessage(&req, userID)
 	if err != nil {
+		switch err {
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reply target not found"})
+			return
+		case utils.ErrReplyTargetMismatch, utils.ErrContentRequired, utils.ErrAttachmentRequired,
+			utils.ErrAttachmentInvalid, utils.ErrAttachmentNotImage, utils.ErrAttachmentNotAllowed,
+			utils.ErrPayloadNotAllowed, utils.ErrTypedMessageContent, utils.ErrAttachmentNotVoice,
+			utils.ErrSnippetRequired, utils.ErrSnippetNotAllowed, utils.ErrSnippetNotText:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case utils.ErrClientMsgIDConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, utils.ErrUnknownMessageType) || errors.Is(err, utils.ErrInvalidPayload) ||
+			errors.Is(err, utils.ErrVoiceTooLong) || errors.Is(err, utils.ErrUnsupportedLanguage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, utils.ErrSnippetTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, utils.ErrMessageRejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		if err.Error() == "user is not a participant in this conversation" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
@@ -330,5 +562,1988 @@ func (h *ChatHandler) MarkMessageAsRead(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Message marked as read"})
+	// Retries were already broadcast when the original was sent
+	if !created {
+		c.JSON(http.StatusOK, message)
+		return
+	}
+
+	// Broadcast message to all connected clients
+	h.PublishMessage(message)
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// PublishMessage broadcasts a new message to its conversation and sends the notifications it causes
+func (h *ChatHandler) PublishMessage(message *models.MessageResponse) {
+	if h.wsHandler == nil {
+		return
+	}
+
+	h.broadcastNewMessage(message)
+
+	mentionedIDs := h.notifyMentions(message)
+
+	if message.ThreadRootID != nil {
+		h.broadcastThreadUpdate(message, message.SenderID, mentionedIDs)
+	}
+
+	if message.DraftCleared {
+		h.sendDraftUpdated(&models.MessageDraft{
+			UserID:         message.SenderID,
+			ConversationID: message.ConversationID,
+			Format:         "plain",
+			UpdatedAt:      message.CreatedAt,
+		}, "")
+	}
+}
+
+// broadcastNewMessage broadcasts a saved message to the clients in its conversation
+func (h *ChatHandler) broadcastNewMessage(message *models.MessageResponse) {
+	data := map[string]interface{}{
+		"id":              message.ID.String(),
+		"conversation_id": message.ConversationID.String(),
+		"content":         message.Content,
+		"message_type":    message.MessageType,
+	}
+	if message.ThreadRootID != nil {
+		data["reply_to_id"] = message.ReplyToID.String()
+		data["thread_root_id"] = message.ThreadRootID.String()
+	}
+	if len(message.Attachments) > 0 {
+		data["attachments"] = message.Attachments
+	}
+	if len(message.Mentions) > 0 {
+		data["mentions"] = message.Mentions
+	}
+	if message.ForwardedFrom != nil {
+		data["forwarded_from"] = message.ForwardedFrom
+	}
+	if message.ExpiresAt != nil {
+		data["expires_at"] = message.ExpiresAt
+	}
+	if message.ClientMsgID != nil {
+		data["client_msg_id"] = *message.ClientMsgID
+	}
+	if message.RichContent != nil {
+		data["rich_content"] = message.RichContent
+	}
+	if message.SystemEvent != nil {
+		data["system_event"] = message.SystemEvent
+	}
+	if message.Poll != nil {
+		data["poll"] = message.Poll
+	}
+	if message.Payload != nil {
+		data["payload"] = message.Payload
+	}
+	if message.Snippet != nil {
+		data["snippet"] = message.Snippet
+	}
+
+	wsMessage := &websocket.Message{
+		Type:      "message",
+		Content:   message.Content,
+		UserID:    message.SenderID.String(),
+		Username:  message.SenderName,
+		Timestamp: message.CreatedAt.Unix(), // Use timestamp from database
+		Data:      data,
+	}
+	h.wsHandler.BroadcastMessage(wsMessage)
+}
+
+// notifyMentions notifies the users mentioned in a message, even if they muted the conversation
+// Returns the IDs of the notified users
+func (h *ChatHandler) notifyMentions(message *models.MessageResponse) []uuid.UUID {
+	userIDs, err := h.chatService.GetMentionedUserIDs(message)
+	if err != nil {
+		log.Printf("Error resolving mentioned users of message %s: %v", message.ID, err)
+		return nil
+	}
+
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	notifications, err := h.notificationService.NotifyMention(message, userIDs)
+	if err != nil {
+		log.Printf("Error creating mention notifications: %v", err)
+	}
+
+	h.sendNotifications(message, notifications)
+
+	return userIDs
+}
+
+// sendNotifications delivers notifications caused by a message to their users
+func (h *ChatHandler) sendNotifications(message *models.MessageResponse, notifications []*models.Notification) {
+	for _, notification := range notifications {
+		h.wsHandler.SendToUser(notification.UserID.String(), &websocket.Message{
+			Type:      "notification",
+			Content:   notification.Content,
+			UserID:    message.SenderID.String(),
+			Username:  message.SenderName,
+			Timestamp: notification.CreatedAt.Unix(),
+			Data:      notification,
+		})
+	}
+}
+
+// broadcastThreadUpdate sends the updated thread summary to the conversation
+// and notifies the thread followers about a new reply
+// Users already notified of a mention in the reply are not notified again
+func (h *ChatHandler) broadcastThreadUpdate(reply *models.MessageResponse, userID uuid.UUID, notifiedIDs []uuid.UUID) {
+	root, err := h.chatService.GetMessage(reply.ConversationID, *reply.ThreadRootID, userID)
+	if err != nil {
+		log.Printf("Error getting thread root %s: %v", *reply.ThreadRootID, err)
+		return
+	}
+
+	h.wsHandler.BroadcastMessage(&websocket.Message{
+		Type:      "thread_updated",
+		UserID:    reply.SenderID.String(),
+		Username:  reply.SenderName,
+		Timestamp: reply.CreatedAt.Unix(),
+		Data: map[string]interface{}{
+			"conversation_id": root.ConversationID.String(),
+			"thread_root_id":  root.ID.String(),
+			"reply_id":        reply.ID.String(),
+			"reply_count":     root.ReplyCount,
+			"last_reply_at":   root.LastReplyAt,
+			"last_reply_by":   root.LastReplyBy,
+		},
+	})
+
+	followerIDs, err := h.chatService.GetThreadFollowerIDs(root.ID, append(notifiedIDs, reply.SenderID)...)
+	if err != nil {
+		log.Printf("Error getting followers of thread %s: %v", root.ID, err)
+		return
+	}
+
+	notifications, err := h.notificationService.NotifyThreadReply(reply, followerIDs)
+	if err != nil {
+		log.Printf("Error creating thread reply notifications: %v", err)
+	}
+
+	h.sendNotifications(reply, notifications)
+}
+
+// GetMessages gets messages for a conversation
+// @Summary Get conversation messages
+// @Description Get messages for a conversation with pagination
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param limit query int false "Number of messages to return (default: 50, max: 100)"
+// @Param offset query int false "Number of messages to skip (default: 0)"
+// @Success 200 {array} models.MessageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/messages [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetMessages(c *gin.Context) {
+	conversationIDStr := c.Param("id")
+	conversationID, err := uuid.Parse(conversationIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	// Get pagination parameters
+	limitStr := c.DefaultQuery("limit", "50")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	messages, err := h.chatService.GetMessagesByConversationID(conversationID, userID, limit, offset)
+	if err != nil {
+		if err.Error() == "user is not a participant in this conversation" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// MarkMessageAsRead marks a message as read
+// @Summary Mark message as read
+// @Description Mark a specific message as read. The read cursor of the user moves to it, and the senders of the messages it passes receive delivery_update events
+// @Tags chat
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/read [post]
+// @Security BearerAuth
+func (h *ChatHandler) MarkMessageAsRead(c *gin.Context) {
+	messageIDStr := c.Param("message_id")
+	messageID, err := uuid.Parse(messageIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	updates, err := h.chatService.MarkMessageAsRead(messageID, userID)
+	if err != nil {
+		if err.Error() == "user is not a participant in this conversation" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.PublishDeliveryUpdates(updates)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message marked as read"})
+}
+
+// GetMessageReceipts gets the delivery state of a message for each recipient
+// @Summary Get message receipts
+// @Description Get whether each recipient of a message has received or read it. Only the sender of the message can see its receipts.
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 200 {array} models.MessageReceipt
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/receipts [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetMessageReceipts(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	receipts, err := h.chatService.GetMessageReceipts(conversationID, messageID, userID)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant, utils.ErrNotMessageSender:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, receipts)
+}
+
+// PublishDeliveryUpdates pushes the new delivery states of messages to their senders
+func (h *ChatHandler) PublishDeliveryUpdates(updates []*models.DeliveryUpdate) {
+	if h.wsHandler == nil {
+		return
+	}
+
+	for _, update := range updates {
+		h.wsHandler.SendToUser(update.SenderID.String(), &websocket.Message{
+			Type:      "delivery_update",
+			UserID:    update.RecipientID.String(),
+			Timestamp: time.Now().Unix(),
+			Data:      update,
+		})
+	}
+}
+
+// GetThread gets a thread with its replies
+// @Summary Get message thread
+// @Description Get a thread root message and its replies with pagination
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Thread root message ID"
+// @Param limit query int false "Number of replies to return (default: 50, max: 100)"
+// @Param offset query int false "Number of replies to skip (default: 0)"
+// @Success 200 {object} models.ThreadResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/thread [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetThread(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	thread, err := h.chatService.GetThread(conversationID, messageID, userID, limit, offset)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
+}
+
+// FollowThread follows the thread of a message
+// @Summary Follow thread
+// @Description Receive notifications for new replies in a thread
+// @Tags chat
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/follow [post]
+// @Security BearerAuth
+func (h *ChatHandler) FollowThread(c *gin.Context) {
+	h.setThreadFollowing(c, true)
+}
+
+// UnfollowThread unfollows the thread of a message
+// @Summary Unfollow thread
+// @Description Stop receiving notifications for new replies in a thread
+// @Tags chat
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/follow [delete]
+// @Security BearerAuth
+func (h *ChatHandler) UnfollowThread(c *gin.Context) {
+	h.setThreadFollowing(c, false)
+}
+
+// setThreadFollowing updates the thread follow state of the authenticated user
+func (h *ChatHandler) setThreadFollowing(c *gin.Context, isFollowing bool) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = h.chatService.SetThreadFollowing(conversationID, messageID, userID, isFollowing)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Thread follow state updated", "is_following": isFollowing})
+}
+
+// AddReaction adds an emoji reaction to a message
+// @Summary Add reaction
+// @Description React to a message with a unicode emoji or a custom :shortcode:
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Param reaction body models.AddReactionRequest true "Reaction details"
+// @Success 200 {object} models.ReactionSummary
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/reactions [post]
+// @Security BearerAuth
+func (h *ChatHandler) AddReaction(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	var req models.AddReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	summary, added, err := h.chatService.AddReaction(conversationID, messageID, userID, req.Emoji)
+	if err != nil {
+		switch err {
+		case utils.ErrInvalidReaction:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if added {
+		h.broadcastReaction("reaction_added", conversationID, messageID, userID, summary)
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// RemoveReaction removes an emoji reaction from a message
+// @Summary Remove reaction
+// @Description Remove the authenticated user's reaction from a message
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Param emoji path string true "Unicode emoji or custom :shortcode: (URL encoded)"
+// @Success 200 {object} models.ReactionSummary
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/reactions/{emoji} [delete]
+// @Security BearerAuth
+func (h *ChatHandler) RemoveReaction(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	summary, err := h.chatService.RemoveReaction(conversationID, messageID, userID, c.Param("emoji"))
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		case utils.ErrReactionNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reaction not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	h.broadcastReaction("reaction_removed", conversationID, messageID, userID, summary)
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// broadcastReaction sends a reaction event to the participants of a conversation
+func (h *ChatHandler) broadcastReaction(eventType string, conversationID, messageID, userID uuid.UUID, summary *models.ReactionSummary) {
+	if h.wsHandler == nil {
+		return
+	}
+
+	h.wsHandler.BroadcastMessage(&websocket.Message{
+		Type:      eventType,
+		UserID:    userID.String(),
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"conversation_id": conversationID.String(),
+			"message_id":      messageID.String(),
+			"user_id":         userID.String(),
+			"emoji":           summary.Emoji,
+			"count":           summary.Count,
+		},
+	})
+}
+
+// SearchMessages searches the message history of the authenticated user
+// @Summary Search messages
+// @Description Full-text search over messages of conversations the user participates in, newest first
+// @Tags chat
+// @Produce json
+// @Param q query string true "Search query (supports quoted phrases, OR and -exclusions)"
+// @Param conversation_id query string false "Only search this conversation"
+// @Param sender_id query string false "Only messages from this sender"
+// @Param message_type query string false "Only messages of this type: text, image, file, voice, snippet, poll, system or a kind from GET /messages/kinds"
+// @Param from query string false "Only messages created at or after this time (RFC3339)"
+// @Param to query string false "Only messages created before this time (RFC3339)"
+// @Param limit query int false "Number of results to return (default: 20, max: 100)"
+// @Param cursor query string false "Cursor from the next_cursor field of the previous page"
+// @Success 200 {object} models.MessageSearchResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /messages/search [get]
+// @Security BearerAuth
+func (h *ChatHandler) SearchMessages(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	filter := &models.MessageSearchFilter{
+		Query:  c.Query("q"),
+		UserID: userID,
+	}
+
+	if conversationIDStr := c.Query("conversation_id"); conversationIDStr != "" {
+		conversationID, err := uuid.Parse(conversationIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+			return
+		}
+		filter.ConversationID = &conversationID
+	}
+
+	if senderIDStr := c.Query("sender_id"); senderIDStr != "" {
+		senderID, err := uuid.Parse(senderIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender ID"})
+			return
+		}
+		filter.SenderID = &senderID
+	}
+
+	filter.MessageType = c.Query("message_type")
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use RFC3339 format"})
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use RFC3339 format"})
+			return
+		}
+		filter.To = &to
+	}
+
+	filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	results, err := h.chatService.SearchMessages(filter, c.Query("cursor"))
+	if err != nil {
+		switch err {
+		case utils.ErrSearchQueryRequired, utils.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			if errors.Is(err, utils.ErrUnknownMessageType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetMentions gets the messages mentioning the authenticated user
+// @Summary Get mentions
+// @Description Get the messages mentioning the authenticated user across their conversations, newest first. Includes @everyone and @here mentions
+// @Tags chat
+// @Produce json
+// @Param limit query int false "Number of messages to return (default: 50, max: 100)"
+// @Param offset query int false "Number of messages to skip (default: 0)"
+// @Success 200 {array} models.MessageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /messages/mentions [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetMentions(c *gin.Context) {
+	// Get pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	messages, err := h.chatService.GetMentions(userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// MuteConversation mutes a conversation
+// @Summary Mute conversation
+// @Description Stop thread reply notifications from a conversation. Mentions still notify
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/mute [post]
+// @Security BearerAuth
+func (h *ChatHandler) MuteConversation(c *gin.Context) {
+	h.setConversationMuted(c, true)
+}
+
+// UnmuteConversation unmutes a conversation
+// @Summary Unmute conversation
+// @Description Resume notifications from a muted conversation
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/mute [delete]
+// @Security BearerAuth
+func (h *ChatHandler) UnmuteConversation(c *gin.Context) {
+	h.setConversationMuted(c, false)
+}
+
+// setConversationMuted updates the mute state of a conversation for the authenticated user
+func (h *ChatHandler) setConversationMuted(c *gin.Context, isMuted bool) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = h.chatService.SetConversationMuted(conversationID, userID, isMuted)
+	if err != nil {
+		if err == utils.ErrNotParticipant {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation mute state updated", "is_muted": isMuted})
+}
+
+// UpdateMessageTTL changes the disappearing messages setting of a conversation
+// @Summary Update disappearing messages
+// @Description Set how long new messages of a conversation are kept, 0 turns disappearing messages off. Only admins can change it in group conversations
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param request body models.UpdateMessageTTLRequest true "Message TTL"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/message-ttl [put]
+// @Security BearerAuth
+func (h *ChatHandler) UpdateMessageTTL(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req models.UpdateMessageTTLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	systemMessage, err := h.chatService.SetMessageTTL(conversationID, userID, req.TTLSeconds)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant, utils.ErrNotConversationAdmin:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	var ttlSeconds *int
+	if req.TTLSeconds > 0 {
+		ttlSeconds = &req.TTLSeconds
+	}
+
+	if h.wsHandler != nil {
+		h.wsHandler.BroadcastMessage(&websocket.Message{
+			Type:      "conversation_updated",
+			UserID:    userID.String(),
+			Timestamp: time.Now().Unix(),
+			Data: map[string]interface{}{
+				"conversation_id":     conversationID.String(),
+				"message_ttl_seconds": ttlSeconds,
+			},
+		})
+		if systemMessage != nil {
+			h.broadcastNewMessage(systemMessage)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Disappearing messages updated", "message_ttl_seconds": ttlSeconds})
+}
+
+// PinMessage pins a message in a conversation
+// @Summary Pin message
+// @Description Pin a message in a conversation. Only admins can pin in group conversations
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 201 {object} models.PinnedMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/pin [post]
+// @Security BearerAuth
+func (h *ChatHandler) PinMessage(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	pin, systemMessage, err := h.chatService.PinMessage(conversationID, messageID, userID)
+	if err != nil {
+		h.respondPinError(c, err)
+		return
+	}
+
+	if h.wsHandler != nil {
+		h.broadcastPin("message_pinned", conversationID, messageID, userID, pin)
+		if systemMessage != nil {
+			h.broadcastNewMessage(systemMessage)
+		}
+	}
+
+	c.JSON(http.StatusCreated, pin)
+}
+
+// UnpinMessage unpins a message in a conversation
+// @Summary Unpin message
+// @Description Unpin a message in a conversation. Only admins can unpin in group conversations
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/pin [delete]
+// @Security BearerAuth
+func (h *ChatHandler) UnpinMessage(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	systemMessage, err := h.chatService.UnpinMessage(conversationID, messageID, userID)
+	if err != nil {
+		h.respondPinError(c, err)
+		return
+	}
+
+	if h.wsHandler != nil {
+		h.broadcastPin("message_unpinned", conversationID, messageID, userID, nil)
+		if systemMessage != nil {
+			h.broadcastNewMessage(systemMessage)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message unpinned"})
+}
+
+// respondPinError writes the error response of a pin or unpin request
+func (h *ChatHandler) respondPinError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotParticipant, utils.ErrNotConversationAdmin:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case utils.ErrMessageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	case utils.ErrNotPinned:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case utils.ErrAlreadyPinned, utils.ErrPinLimitReached:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// broadcastPin notifies the conversation that a message was pinned or unpinned
+func (h *ChatHandler) broadcastPin(eventType string, conversationID, messageID, userID uuid.UUID, pin *models.PinnedMessage) {
+	data := map[string]interface{}{
+		"conversation_id": conversationID.String(),
+		"message_id":      messageID.String(),
+		"user_id":         userID.String(),
+	}
+	if pin != nil {
+		data["pinned_by"] = pin.PinnedBy.String()
+		data["pinned_at"] = pin.PinnedAt
+	}
+
+	h.wsHandler.BroadcastMessage(&websocket.Message{
+		Type:      eventType,
+		UserID:    userID.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+}
+
+// GetPinnedMessages gets the pinned messages of a conversation
+// @Summary Get pinned messages
+// @Description Get the pinned messages of a conversation, most recently pinned first
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {array} models.MessageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/pins [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetPinnedMessages(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	messages, err := h.chatService.GetPinnedMessages(conversationID, userID)
+	if err != nil {
+		if err == utils.ErrNotParticipant {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// CreatePoll posts a poll in a group conversation
+// @Summary Create poll
+// @Description Post a poll message in a group conversation, with 2 to 10 options, single or multiple choice, anonymous or public votes and an optional closing time
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param request body models.CreatePollRequest true "Poll"
+// @Success 201 {object} models.MessageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/polls [post]
+// @Security BearerAuth
+func (h *ChatHandler) CreatePoll(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req models.CreatePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	message, err := h.chatService.CreatePoll(conversationID, userID, &req)
+	if err != nil {
+		h.respondPollError(c, err)
+		return
+	}
+
+	h.PublishMessage(message)
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// GetPoll gets a poll with its current results
+// @Summary Get poll
+// @Description Get a poll with its vote counts, the votes of the user and the voters of public polls
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param poll_id path string true "Poll ID"
+// @Success 200 {object} models.Poll
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/polls/{poll_id} [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetPoll(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	pollID, err := uuid.Parse(c.Param("poll_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid poll ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	poll, err := h.chatService.GetPoll(conversationID, pollID, userID)
+	if err != nil {
+		h.respondPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// VotePoll votes in a poll
+// @Summary Vote in poll
+// @Description Replace the votes of the user in a poll. Single choice polls accept one option, an empty list retracts the vote
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param poll_id path string true "Poll ID"
+// @Param request body models.VotePollRequest true "Chosen options"
+// @Success 200 {object} models.Poll
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /conversations/{id}/polls/{poll_id}/votes [put]
+// @Security BearerAuth
+func (h *ChatHandler) VotePoll(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	pollID, err := uuid.Parse(c.Param("poll_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid poll ID"})
+		return
+	}
+
+	var req models.VotePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	poll, err := h.chatService.VotePoll(conversationID, pollID, userID, req.OptionIDs)
+	if err != nil {
+		h.respondPollError(c, err)
+		return
+	}
+
+	if h.wsHandler != nil {
+		h.broadcastPoll("poll_updated", poll, userID)
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// ClosePoll closes a poll early
+// @Summary Close poll
+// @Description Close a poll before its closing time. Only the creator of the poll and conversation admins can close it
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param poll_id path string true "Poll ID"
+// @Success 200 {object} models.Poll
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /conversations/{id}/polls/{poll_id}/close [post]
+// @Security BearerAuth
+func (h *ChatHandler) ClosePoll(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	pollID, err := uuid.Parse(c.Param("poll_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid poll ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	poll, err := h.chatService.ClosePoll(conversationID, pollID, userID)
+	if err != nil {
+		h.respondPollError(c, err)
+		return
+	}
+
+	if h.wsHandler != nil {
+		h.broadcastPoll("poll_closed", poll, userID)
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// respondPollError writes the error response of a poll request
+func (h *ChatHandler) respondPollError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotParticipant, utils.ErrNotConversationAdmin:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case utils.ErrPollNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case utils.ErrPollRequiresGroup, utils.ErrPollOptionsNotUnique, utils.ErrPollClosesInPast,
+		utils.ErrPollSingleChoice, utils.ErrPollOptionInvalid:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case utils.ErrPollClosed:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// broadcastPoll sends the current results of a poll to its conversation
+// The votes of the acting user are left out, they are private in anonymous polls
+func (h *ChatHandler) broadcastPoll(eventType string, poll *models.Poll, userID uuid.UUID) {
+	results := *poll
+	results.MyVotes = nil
+
+	h.wsHandler.BroadcastMessage(&websocket.Message{
+		Type:      eventType,
+		UserID:    userID.String(),
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"conversation_id": poll.ConversationID.String(),
+			"message_id":      poll.MessageID.String(),
+			"poll":            &results,
+		},
+	})
+}
+
+// SaveDraft saves the draft of the user in a conversation
+// @Summary Save draft
+// @Description Save the unsent message the user is writing, synced to their other devices with a draft_updated event.
+// @Description The most recent updated_at wins: an older save is rejected with 409 and the stored draft. Empty content clears the draft.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param request body models.SaveDraftRequest true "Draft"
+// @Success 200 {object} models.MessageDraft
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /conversations/{id}/draft [put]
+// @Security BearerAuth
+func (h *ChatHandler) SaveDraft(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req models.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	draft, saved, err := h.chatService.SaveDraft(conversationID, userID, &req)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if !saved {
+		c.JSON(http.StatusConflict, gin.H{"error": utils.ErrDraftOutdated.Error(), "draft": draft})
+		return
+	}
+
+	h.sendDraftUpdated(draft, req.DeviceID)
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// GetDraft gets the draft of the user in a conversation
+// @Summary Get draft
+// @Description Get the unsent message the user is writing in a conversation, saved from any of their devices
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} models.MessageDraft
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/draft [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetDraft(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	draft, err := h.chatService.GetDraft(conversationID, userID)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrDraftNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// ClearDraft clears the draft of the user in a conversation
+// @Summary Clear draft
+// @Description Clear the unsent message the user is writing in a conversation on all their devices. Sending a message clears it too.
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param device_id query string false "Device clearing the draft, echoed in the draft_updated event"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/draft [delete]
+// @Security BearerAuth
+func (h *ChatHandler) ClearDraft(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	draft, err := h.chatService.ClearDraft(conversationID, userID)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if draft != nil {
+		h.sendDraftUpdated(draft, c.Query("device_id"))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Draft cleared"})
+}
+
+// sendDraftUpdated sends a saved or cleared draft to all connections of its user
+// The device that changed the draft is included, so it can skip its own update
+func (h *ChatHandler) sendDraftUpdated(draft *models.MessageDraft, deviceID string) {
+	if h.wsHandler == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"conversation_id": draft.ConversationID.String(),
+		"content":         draft.Content,
+		"format":          draft.Format,
+		"updated_at":      draft.UpdatedAt,
+		"cleared":         draft.Content == "",
+	}
+	if deviceID != "" {
+		data["device_id"] = deviceID
+	}
+
+	h.wsHandler.SendToUser(draft.UserID.String(), &websocket.Message{
+		Type:      "draft_updated",
+		UserID:    draft.UserID.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+}
+
+// ForwardMessages forwards messages to other conversations
+// @Summary Forward messages
+// @Description Forward messages to other conversations. The user must participate in the conversations of the messages and in every target. Forwarded messages keep the original sender and timestamp, attachments are carried over without re-uploading. Copies are moderated like new messages
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param request body models.ForwardMessagesRequest true "Messages and target conversations"
+// @Success 201 {array} models.MessageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{} "Rejected by moderation"
+// @Router /messages/forward [post]
+// @Security BearerAuth
+func (h *ChatHandler) ForwardMessages(c *gin.Context) {
+	var req models.ForwardMessagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	messages, err := h.chatService.ForwardMessages(&req, userID)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		case utils.ErrMessageNotForwardable:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			if errors.Is(err, utils.ErrMessageRejected) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if h.wsHandler != nil {
+		for _, message := range messages {
+			h.broadcastNewMessage(message)
+		}
+	}
+
+	c.JSON(http.StatusCreated, messages)
+}
+
+// BookmarkMessage bookmarks a message for the authenticated user
+// @Summary Bookmark message
+// @Description Save a message to find it later from the bookmarks list, with an optional personal note. Bookmarking a message again replaces its note
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Param request body models.BookmarkRequest false "Personal note"
+// @Success 200 {object} models.Bookmark "Note of an existing bookmark updated"
+// @Success 201 {object} models.Bookmark
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/bookmark [post]
+// @Security BearerAuth
+func (h *ChatHandler) BookmarkMessage(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// The body is optional, bookmarks without a note need none
+	var req models.BookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	bookmark, created, err := h.chatService.BookmarkMessage(conversationID, messageID, userID, req.Note)
+	if err != nil {
+		switch err {
+		case utils.ErrNotParticipant:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrMessageNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	h.sendBookmarkUpdated(userID, conversationID, messageID, bookmark)
+
+	if !created {
+		c.JSON(http.StatusOK, bookmark)
+		return
+	}
+	c.JSON(http.StatusCreated, bookmark)
+}
+
+// RemoveBookmark removes the bookmark of the authenticated user on a message
+// @Summary Remove bookmark
+// @Description Remove a message from the bookmarks of the user
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/bookmark [delete]
+// @Security BearerAuth
+func (h *ChatHandler) RemoveBookmark(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.chatService.RemoveBookmark(conversationID, messageID, userID); err != nil {
+		if err == utils.ErrBookmarkNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.sendBookmarkUpdated(userID, conversationID, messageID, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed"})
+}
+
+// sendBookmarkUpdated sends a bookmark change to all connections of its user, a nil bookmark was removed
+func (h *ChatHandler) sendBookmarkUpdated(userID, conversationID, messageID uuid.UUID, bookmark *models.Bookmark) {
+	if h.wsHandler == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"conversation_id": conversationID.String(),
+		"message_id":      messageID.String(),
+		"is_bookmarked":   bookmark != nil,
+	}
+	if bookmark != nil {
+		data["note"] = bookmark.Note
+	}
+
+	h.wsHandler.SendToUser(userID.String(), &websocket.Message{
+		Type:      "bookmark_updated",
+		UserID:    userID.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+}
+
+// GetBookmarks gets the bookmarked messages of the authenticated user
+// @Summary Get bookmarks
+// @Description Get the messages bookmarked by the user across their conversations, most recently bookmarked first.
+// @Description Bookmarks of deleted messages are removed, bookmarks in conversations the user left are not listed
+// @Tags chat
+// @Produce json
+// @Param limit query int false "Number of bookmarks to return (default: 20, max: 100)"
+// @Param cursor query string false "Cursor from the next_cursor field of the previous page"
+// @Success 200 {object} models.BookmarkPage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /messages/bookmarks [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetBookmarks(c *gin.Context) {
+	// Get pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	page, err := h.chatService.GetBookmarks(userID, limit, c.Query("cursor"))
+	if err != nil {
+		if err == utils.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetMessageKinds lists the message types with a structured payload
+// @Summary List message kinds
+// @Description List the message types sent with a structured payload, like locations and contact cards, with the JSON Schema of their payload.
+// @Description Typed messages are sent with their type as message_type, the payload and no content: the content is generated as a plain-text fallback
+// @Tags chat
+// @Produce json
+// @Success 200 {array} models.MessageKind
+// @Failure 401 {object} map[string]interface{}
+// @Router /messages/kinds [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetMessageKinds(c *gin.Context) {
+	c.JSON(http.StatusOK, h.chatService.MessageKinds())
+}
+
+// GetSnippetLanguages lists the languages code snippets are highlighted in
+// @Summary List snippet languages
+// @Description List the languages code snippets are highlighted in, with the aliases accepted as snippet.language
+// @Tags chat
+// @Produce json
+// @Success 200 {array} models.SnippetLanguage
+// @Failure 401 {object} map[string]interface{}
+// @Router /messages/snippets/languages [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetSnippetLanguages(c *gin.Context) {
+	c.JSON(http.StatusOK, h.chatService.SnippetLanguages())
+}
+
+// GetSnippet gets the full highlighted code of a snippet message
+// @Summary Get snippet
+// @Description Get the full code of a snippet message, highlighted as HTML whose only markup is spans with hl-keyword, hl-builtin, hl-string, hl-number and hl-comment classes.
+// @Description Message lists only include a collapsed preview of the first lines
+// @Tags chat
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Param tokens query bool false "Include the highlighted tokens, for clients rendering the code natively"
+// @Success 200 {object} models.SnippetResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/snippet [get]
+// @Security BearerAuth
+func (h *ChatHandler) GetSnippet(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	withTokens, err := strconv.ParseBool(c.DefaultQuery("tokens", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tokens parameter"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	snippet, err := h.chatService.GetSnippet(conversationID, messageID, userID, withTokens)
+	if err != nil {
+		h.respondSnippetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snippet)
+}
+
+// DownloadSnippet downloads the code of a snippet message
+// @Summary Download snippet
+// @Description Download the code of a snippet message as a UTF-8 text file, named after the file name of the snippet
+// @Tags chat
+// @Produce plain
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/snippet/raw [get]
+// @Security BearerAuth
+func (h *ChatHandler) DownloadSnippet(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	snippet, err := h.chatService.GetSnippetCode(conversationID, messageID, userID)
+	if err != nil {
+		h.respondSnippetError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": snippet.DownloadName()}))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(snippet.Code))
+}
+
+// respondSnippetError writes the error response of a snippet request
+func (h *ChatHandler) respondSnippetError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotParticipant:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case utils.ErrMessageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	case utils.ErrSnippetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CommandHandler handles slash command HTTP requests
+type CommandHandler struct {
+	commandService *service.CommandService
+}
+
+// NewCommandHandler creates a new command handler
+func NewCommandHandler(commandService *service.CommandService) *CommandHandler {
+	return &CommandHandler{
+		commandService: commandService,
+	}
+}
+
+// respondCommandAdminError writes the error response of a slash command management request
+func respondCommandAdminError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotWorkspaceAdmin:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case utils.ErrCommandNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case utils.ErrCommandExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case utils.ErrInvalidCommandName:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetCommands lists the slash commands
+// @Summary List slash commands
+// @Description List the built-in and third-party slash commands with their usage and the permission needed to run them
+// @Tags commands
+// @Produce json
+// @Success 200 {array} models.CommandInfo
+// @Failure 401 {object} map[string]interface{}
+// @Router /commands [get]
+// @Security BearerAuth
+func (h *CommandHandler) GetCommands(c *gin.Context) {
+	c.JSON(http.StatusOK, h.commandService.ListCommands())
+}
+
+// CreateCommand registers a third-party slash command
+// @Summary Register slash command
+// @Description Register a command answered by a third-party endpoint (workspace admins only). Running the command POSTs the call as JSON to the URL,
+// @Description signed in the X-GoSwift-Signature header with "v1=" and the hex HMAC-SHA256 of "<X-GoSwift-Timestamp>.<body>" keyed by the returned secret.
+// @Description The endpoint answers with {"response_type": "ephemeral" or "in_channel", "text": "..."} or an empty body
+// @Tags commands
+// @Accept json
+// @Produce json
+// @Param command body models.CreateSlashCommandRequest true "Command details"
+// @Success 201 {object} models.SlashCommand
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /commands [post]
+// @Security BearerAuth
+func (h *CommandHandler) CreateCommand(c *gin.Context) {
+	var req models.CreateSlashCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	command, err := h.commandService.CreateCommand(userID, &req)
+	if err != nil {
+		respondCommandAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, command)
+}
+
+// DeleteCommand deletes a third-party slash command
+// @Summary Delete slash command
+// @Description Delete a third-party slash command (workspace admins only)
+// @Tags commands
+// @Produce json
+// @Param name path string true "Command name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /commands/{name} [delete]
+// @Security BearerAuth
+func (h *CommandHandler) DeleteCommand(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.commandService.DeleteCommand(userID, c.Param("name")); err != nil {
+		respondCommandAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Command deleted"})
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"goswift/internal/commands"
+	"goswift/internal/models"
+	"goswift/internal/websocket"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// shrug is appended by /shrug
+const shrug = `¯\_(ツ)_/¯`
+
+// registerCommands registers the built-in slash commands
+// They live in the chat handler since most of them broadcast the changes they make
+func (h *ChatHandler) registerCommands() {
+	h.commandService.Register(&commands.Command{
+		Name:        "me",
+		Usage:       "<action>",
+		Description: "Describe what you are doing",
+		MinArgs:     1,
+		MaxArgs:     commands.Unlimited,
+		Handler: func(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+			return &commands.Response{ResponseType: commands.ResponseInChannel, Text: "_" + call.Text + "_", Markdown: true}, nil
+		},
+	})
+
+	h.commandService.Register(&commands.Command{
+		Name:        "shrug",
+		Usage:       "[message]",
+		Description: "Append " + shrug + " to your message",
+		MaxArgs:     commands.Unlimited,
+		Handler: func(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+			return commands.InChannel(strings.TrimSpace(call.Text + " " + shrug)), nil
+		},
+	})
+
+	h.commandService.Register(&commands.Command{
+		Name:        "topic",
+		Usage:       "[topic|none]",
+		Description: "Show or set the topic of the conversation, none clears it",
+		MaxArgs:     commands.Unlimited,
+		Handler:     h.runTopicCommand,
+	})
+
+	h.commandService.Register(&commands.Command{
+		Name:        "invite",
+		Usage:       "@user [@user...]",
+		Description: "Add members to the group",
+		Permission:  commands.PermissionConversationAdmin,
+		MinArgs:     1,
+		MaxArgs:     commands.Unlimited,
+		Handler:     h.runInviteCommand,
+	})
+
+	h.commandService.Register(&commands.Command{
+		Name:        "leave",
+		Description: "Leave the group",
+		Handler:     h.runLeaveCommand,
+	})
+
+	h.commandService.Register(&commands.Command{
+		Name:        "mute",
+		Usage:       "[off]",
+		Description: "Mute notifications from the conversation, off unmutes it",
+		MaxArgs:     1,
+		Handler: func(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+			isMuted := len(call.Args) == 0 || !strings.EqualFold(call.Args[0], "off")
+			if err := h.chatService.SetConversationMuted(call.ConversationID, call.UserID, isMuted); err != nil {
+				return nil, err
+			}
+			if isMuted {
+				return commands.Ephemeral("Conversation muted, send /mute off to get notifications again"), nil
+			}
+			return commands.Ephemeral("Conversation unmuted"), nil
+		},
+	})
+
+	h.commandService.Register(&commands.Command{
+		Name:        "poll",
+		Usage:       `"question" "option" "option" [...] [--multiple] [--anonymous]`,
+		Description: "Create a poll",
+		MinArgs:     3,
+		MaxArgs:     commands.Unlimited,
+		Handler:     h.runPollCommand,
+	})
+
+	h.commandService.Register(&commands.Command{
+		Name:        "remind",
+		Usage:       "[me] <when> [about <note>]",
+		Description: `Get a private reminder about the thread or conversation, like "/remind me tomorrow 9am about the release"`,
+		MinArgs:     1,
+		MaxArgs:     commands.Unlimited,
+		Handler:     h.runRemindCommand,
+	})
+}
+
+// runTopicCommand shows the topic without arguments and sets it otherwise
+func (h *ChatHandler) runTopicCommand(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+	if call.Text == "" {
+		conversation, err := h.chatService.GetConversationByID(call.ConversationID, call.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if conversation.Topic == "" {
+			return commands.Ephemeral("This conversation has no topic, set one with /topic <topic>"), nil
+		}
+		return commands.Ephemeral("Topic: %s", conversation.Topic), nil
+	}
+
+	topic := call.Text
+	if strings.EqualFold(topic, "none") {
+		topic = ""
+	}
+
+	systemMessage, err := h.chatService.SetConversationTopic(call.ConversationID, call.UserID, topic)
+	if err != nil {
+		return nil, err
+	}
+	if systemMessage == nil {
+		return commands.Ephemeral("The topic is unchanged"), nil
+	}
+
+	h.broadcastConversationUpdated(call.ConversationID, call.UserID, map[string]interface{}{"topic": topic}, systemMessage)
+	return nil, nil
+}
+
+// runInviteCommand adds the mentioned users to the group
+func (h *ChatHandler) runInviteCommand(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+	userIDs := make([]uuid.UUID, 0, len(call.Args))
+	for _, handle := range call.Args {
+		user, err := h.chatService.FindUserByHandle(handle)
+		if err != nil {
+			if errors.Is(err, utils.ErrUserNotFound) || errors.Is(err, utils.ErrHandleAmbiguous) {
+				return nil, fmt.Errorf("%w: %s", err, handle)
+			}
+			return nil, err
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	added, systemMessage, err := h.chatService.AddParticipants(call.ConversationID, call.UserID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(added) == 0 {
+		return commands.Ephemeral("Everyone is already a member"), nil
+	}
+
+	addedIDs := make([]string, 0, len(added))
+	for _, user := range added {
+		addedIDs = append(addedIDs, user.ID.String())
+	}
+	h.broadcastConversationUpdated(call.ConversationID, call.UserID, map[string]interface{}{"added_user_ids": addedIDs}, systemMessage)
+	return nil, nil
+}
+
+// runLeaveCommand removes the caller from the group
+func (h *ChatHandler) runLeaveCommand(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+	systemMessage, err := h.chatService.RemoveParticipant(call.ConversationID, call.UserID, call.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	h.broadcastConversationUpdated(call.ConversationID, call.UserID, map[string]interface{}{"removed_user_id": call.UserID.String()}, systemMessage)
+
+	// The caller no longer receives conversation broadcasts
+	if h.wsHandler != nil && systemMessage != nil {
+		h.wsHandler.SendToUser(call.UserID.String(), &websocket.Message{
+			Type:      "conversation_left",
+			UserID:    call.UserID.String(),
+			Timestamp: time.Now().Unix(),
+			Data: map[string]interface{}{
+				"conversation_id": call.ConversationID.String(),
+				"message":         systemMessage,
+			},
+		})
+	}
+
+	return commands.Ephemeral("You left the conversation"), nil
+}
+
+// runPollCommand creates a poll from a quoted question and options
+func (h *ChatHandler) runPollCommand(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+	req := &models.CreatePollRequest{}
+	for _, arg := range call.Args {
+		switch strings.ToLower(arg) {
+		case "--multiple":
+			req.AllowsMultiple = true
+		case "--anonymous":
+			req.IsAnonymous = true
+		default:
+			if req.Question == "" {
+				req.Question = arg
+			} else {
+				req.Options = append(req.Options, arg)
+			}
+		}
+	}
+
+	// The same limits as polls created through the API
+	if len(req.Options) < 2 || len(req.Options) > 10 {
+		return nil, fmt.Errorf("%w: polls need 2 to 10 options", utils.ErrInvalidCommandUsage)
+	}
+	if len([]rune(req.Question)) > 300 {
+		return nil, fmt.Errorf("%w: the question must be at most 300 characters", utils.ErrInvalidCommandUsage)
+	}
+	for _, option := range req.Options {
+		if len([]rune(option)) > 100 {
+			return nil, fmt.Errorf("%w: options must be at most 100 characters", utils.ErrInvalidCommandUsage)
+		}
+	}
+
+	message, err := h.chatService.CreatePoll(call.ConversationID, call.UserID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	h.PublishMessage(message)
+	return nil, nil
+}
+
+// runRemindCommand sets a reminder about the thread the command was run in, or else about the conversation
+// The time is read in the timezone of the caller and the text after it becomes the note
+func (h *ChatHandler) runRemindCommand(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+	reminder, err := h.reminderService.CreateReminder(call.ConversationID, call.ReplyToID, call.UserID, &models.CreateReminderRequest{When: call.Text})
+	if err != nil {
+		return nil, err
+	}
+
+	about := "this conversation"
+	if reminder.MessageID != nil {
+		about = "this thread"
+	}
+	if reminder.Note != "" {
+		about = reminder.Note
+	}
+
+	return commands.Ephemeral("I will remind you about %s on %s (%s)", about, reminder.RemindAt.Format("Mon 2 Jan 2006 at 15:04"), reminder.Timezone), nil
+}
+
+// RunCommand runs the slash command of a message instead of sending it
+// Ephemeral replies are sent to the caller's clients, in_channel replies are posted as a message of the caller
+func (h *ChatHandler) RunCommand(ctx context.Context, req *models.SendMessageRequest, userID uuid.UUID) (*models.CommandResult, error) {
+	name, response, err := h.commandService.Execute(ctx, req.ConversationID, userID, req.Content, req.ReplyToID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CommandResult{Command: name}
+	if response == nil || strings.TrimSpace(response.Text) == "" {
+		return result, nil
+	}
+	result.ResponseType = response.ResponseType
+	result.Text = response.Text
+
+	if response.ResponseType == commands.ResponseInChannel {
+		content := response.Text
+		// Replies are posted with the limits of messages
+		if runes := []rune(content); len(runes) > 1000 {
+			content = string(runes[:999]) + "…"
+		}
+
+		format := "plain"
+		if response.Markdown {
+			format = "markdown"
+		}
+
+		message, created, err := h.chatService.SendMessage(&models.SendMessageRequest{
+			ConversationID: req.ConversationID,
+			Content:        content,
+			Format:         format,
+			MessageType:    "text",
+			ReplyToID:      req.ReplyToID,
+			ClientMsgID:    req.ClientMsgID,
+		}, userID)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			h.PublishMessage(message)
+		}
+		result.Message = message
+		return result, nil
+	}
+
+	if h.wsHandler != nil {
+		h.wsHandler.SendToUser(userID.String(), &websocket.Message{
+			Type:      "command_response",
+			UserID:    userID.String(),
+			Timestamp: time.Now().Unix(),
+			Data: map[string]interface{}{
+				"conversation_id": req.ConversationID.String(),
+				"command":         name,
+				"text":            response.Text,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// respondCommandError writes the error response of a slash command
+func (h *ChatHandler) respondCommandError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrNotParticipant), errors.Is(err, utils.ErrNotConversationAdmin),
+		errors.Is(err, utils.ErrNotWorkspaceAdmin):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrUserNotFound), errors.Is(err, utils.ErrMemberNotFound), errors.Is(err, utils.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrUnknownCommand), errors.Is(err, utils.ErrInvalidCommandUsage),
+		errors.Is(err, utils.ErrHandleAmbiguous), errors.Is(err, utils.ErrTopicTooLong),
+		errors.Is(err, utils.ErrGroupOnly), errors.Is(err, utils.ErrPollRequiresGroup),
+		errors.Is(err, utils.ErrPollOptionsNotUnique), errors.Is(err, utils.ErrInvalidReminderTime),
+		errors.Is(err, utils.ErrReminderTimeInPast), errors.Is(err, utils.ErrReminderTimeTooFar):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrCommandFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrMessageRejected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
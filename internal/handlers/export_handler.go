@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"goswift/internal/export"
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ExportHandler handles conversation export HTTP requests
+type ExportHandler struct {
+	exportService *service.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService *service.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// respondExportError writes the error response of an export request
+func respondExportError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotParticipant:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case utils.ErrConversationNotFound, utils.ErrExportNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case utils.ErrExportNotReady:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case utils.ErrExportExpired:
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateExport exports a conversation
+// @Summary Export conversation
+// @Description Export the full history of a conversation with its participants, attachment metadata, edits and system events as JSON, self-contained HTML or CSV (messages only).
+// @Description Participants and workspace admins can export. Small conversations are exported right away (201, completed with download_url),
+// @Description larger ones are exported in the background (202, pending): poll GET /exports/{id} or wait for the export_completed WebSocket event
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param export body models.CreateExportRequest true "Export format"
+// @Success 201 {object} models.ConversationExport
+// @Success 202 {object} models.ConversationExport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/exports [post]
+// @Security BearerAuth
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req models.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	exp, err := h.exportService.RequestExport(c.Request.Context(), conversationID, userID, req.Format)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	if exp.Status == "pending" {
+		c.JSON(http.StatusAccepted, exp)
+		return
+	}
+
+	c.JSON(http.StatusCreated, exp)
+}
+
+// GetExport gets the status of an export
+// @Summary Get export
+// @Description Get an export requested by the authenticated user. download_url is set once it is completed, until the file expires
+// @Tags exports
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} models.ConversationExport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /exports/{id} [get]
+// @Security BearerAuth
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	exp, err := h.exportService.GetExport(exportID, userID)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exp)
+}
+
+// DownloadExport downloads the file of a completed export
+// @Summary Download export
+// @Description Download the file of a completed export. The requester must still have access to the conversation
+// @Tags exports
+// @Produce octet-stream
+// @Param id path string true "Export ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 410 {object} map[string]interface{}
+// @Router /exports/{id}/download [get]
+// @Security BearerAuth
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	exp, reader, err := h.exportService.OpenExport(c.Request.Context(), exportID, userID)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", export.ContentType(exp.Format))
+	if exp.SizeBytes != nil {
+		c.Header("Content-Length", strconv.FormatInt(*exp.SizeBytes, 10))
+	}
+	if exp.FileName != nil {
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": *exp.FileName}))
+	}
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log.Printf("Error streaming export %s: %v", exp.ID, err)
+	}
+}
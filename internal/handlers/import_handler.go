@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"goswift/internal/service"
+	"goswift/internal/slackimport"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ImportHandler handles chat history import HTTP requests
+type ImportHandler struct {
+	importService *service.ImportService
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// ImportSlack imports a Slack export archive
+// @Summary Import Slack export
+// @Description Import the channels, members, messages, threads and reactions of a Slack export ZIP. Slack users are mapped to users by email,
+// @Description messages of users without an account are skipped and listed in unmapped_users. Re-running an import only adds what was not imported yet.
+// @Description Only workspace admins can import, large archives can be imported with the import-slack command of the server
+// @Tags imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param archive formData file true "Slack export ZIP"
+// @Success 200 {object} models.SlackImportReport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Router /imports/slack [post]
+// @Security BearerAuth
+func (h *ImportHandler) ImportSlack(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Reject oversized bodies before they are buffered
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.importService.MaxArchiveSize()+multipartOverhead)
+
+	fileHeader, err := c.FormFile("archive")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": utils.ErrFileTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive is required", "details": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read archive"})
+		return
+	}
+	defer file.Close()
+
+	report, err := h.importService.ImportSlackArchive(userID, file, fileHeader.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrNotWorkspaceAdmin):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, utils.ErrFileTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, slackimport.ErrInvalidArchive):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
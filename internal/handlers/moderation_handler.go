@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/internal/websocket"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ModerationHandler handles moderation rule and review queue HTTP requests
+type ModerationHandler struct {
+	moderationService *service.ModerationService
+	attachmentService *service.AttachmentService
+	wsHandler         *websocket.Handler
+}
+
+// NewModerationHandler creates a new moderation handler
+func NewModerationHandler(moderationService *service.ModerationService, attachmentService *service.AttachmentService, wsHandler *websocket.Handler) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+		attachmentService: attachmentService,
+		wsHandler:         wsHandler,
+	}
+}
+
+// respondModerationError writes the error response of a moderation request
+func respondModerationError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotModerator:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case utils.ErrModerationRuleNotFound, utils.ErrModerationFlagNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case utils.ErrModerationRuleExists, utils.ErrModerationFlagReviewed:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, utils.ErrInvalidModerationRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// CreateRule adds a moderation rule
+// @Summary Create moderation rule
+// @Description Add a rule checked on every message before it is saved (moderators only).
+// @Description word rules match whole words or phrases case-insensitively, domain rules match links to the domain and its subdomains, regex rules use RE2 syntax.
+// @Description reject refuses the message, redact masks the matches (links are replaced) and flag adds the message to the review queue
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param rule body models.CreateModerationRuleRequest true "Rule details"
+// @Success 201 {object} models.ModerationRule
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /moderation/rules [post]
+// @Security BearerAuth
+func (h *ModerationHandler) CreateRule(c *gin.Context) {
+	var req models.CreateModerationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	rule, err := h.moderationService.CreateRule(userID, &req)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetRules gets the moderation rules
+// @Summary Get moderation rules
+// @Description Get all moderation rules, oldest first (moderators only)
+// @Tags moderation
+// @Produce json
+// @Success 200 {array} models.ModerationRule
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /moderation/rules [get]
+// @Security BearerAuth
+func (h *ModerationHandler) GetRules(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	rules, err := h.moderationService.GetRules(userID)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// DeleteRule deletes a moderation rule
+// @Summary Delete moderation rule
+// @Description Delete a moderation rule (moderators only). Messages already sent are not affected
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /moderation/rules/{id} [delete]
+// @Security BearerAuth
+func (h *ModerationHandler) DeleteRule(c *gin.Context) {
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.moderationService.DeleteRule(userID, ruleID); err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule deleted"})
+}
+
+// GetFlags gets the review queue
+// @Summary Get flagged messages
+// @Description Get the messages flagged by rules or the classifier (moderators only). Pending flags are listed oldest first, reviewed flags newest first
+// @Tags moderation
+// @Produce json
+// @Param status query string false "Status filter (default: pending)" Enums(pending, dismissed, removed)
+// @Param limit query int false "Number of flags to return (default: 50, max: 100)"
+// @Param offset query int false "Number of flags to skip (default: 0)"
+// @Success 200 {array} models.ModerationFlag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /moderation/flags [get]
+// @Security BearerAuth
+func (h *ModerationHandler) GetFlags(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	if status != "pending" && status != "dismissed" && status != "removed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	// Get pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	flags, err := h.moderationService.GetFlags(userID, status, limit, offset)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flags)
+}
+
+// ReviewFlag dismisses a flagged message or removes it
+// @Summary Review flagged message
+// @Description Dismiss a pending flag, or remove the flagged message (moderators only).
+// @Description Removing deletes the message with its thread replies and attachments, resolves every pending flag of the message and sends message_removed to the conversation
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Flag ID"
+// @Param review body models.ReviewModerationFlagRequest true "Decision"
+// @Success 200 {object} models.ModerationFlag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /moderation/flags/{id}/review [post]
+// @Security BearerAuth
+func (h *ModerationHandler) ReviewFlag(c *gin.Context) {
+	flagID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flag ID"})
+		return
+	}
+
+	var req models.ReviewModerationFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	flag, messages, attachments, err := h.moderationService.ReviewFlag(userID, flagID, req.Decision)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	h.attachmentService.DeleteUnreferencedFiles(context.Background(), attachments)
+
+	if h.wsHandler != nil {
+		for _, message := range messages {
+			data := map[string]interface{}{
+				"conversation_id": message.ConversationID.String(),
+				"message_id":      message.ID.String(),
+			}
+			if message.ThreadRootID != nil {
+				data["thread_root_id"] = message.ThreadRootID.String()
+			}
+
+			h.wsHandler.BroadcastMessage(&websocket.Message{
+				Type:      "message_removed",
+				UserID:    userID.String(),
+				Timestamp: time.Now().Unix(),
+				Data:      data,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
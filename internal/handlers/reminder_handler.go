@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReminderHandler handles message reminder HTTP requests
+type ReminderHandler struct {
+	reminderService *service.ReminderService
+}
+
+// NewReminderHandler creates a new reminder handler
+func NewReminderHandler(reminderService *service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{
+		reminderService: reminderService,
+	}
+}
+
+// respondReminderError writes the error response of a reminder request
+func respondReminderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrNotParticipant):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrReminderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+	case errors.Is(err, utils.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	case errors.Is(err, utils.ErrReminderTimeRequired), errors.Is(err, utils.ErrInvalidReminderTime),
+		errors.Is(err, utils.ErrReminderTimeInPast), errors.Is(err, utils.ErrReminderTimeTooFar),
+		errors.Is(err, utils.ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateReminder sets a reminder about a message
+// @Summary Remind me about a message
+// @Description Set a private reminder about a message. The time is either typed, like "in 2 hours", "tomorrow 9am" or "friday at 14:30 to reply", and read in the timezone of the user, or an exact remind_at. Text typed after the time becomes the note. A timezone given here is saved for later reminders. When due, the reminder is added to the notification feed and pushed as "notification" and "reminder" WebSocket events
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message_id path string true "Message ID"
+// @Param reminder body models.CreateReminderRequest true "Reminder time and note"
+// @Success 201 {object} models.Reminder
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /conversations/{id}/messages/{message_id}/reminders [post]
+// @Security BearerAuth
+func (h *ReminderHandler) CreateReminder(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	messageID, err := uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	var req models.CreateReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	reminder, err := h.reminderService.CreateReminder(conversationID, &messageID, userID, &req)
+	if err != nil {
+		respondReminderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reminder)
+}
+
+// GetReminders gets the reminders of the authenticated user
+// @Summary Get reminders
+// @Description Get the reminders of the authenticated user, upcoming ones soonest first or delivered ones latest first
+// @Tags reminders
+// @Produce json
+// @Param status query string false "Status filter (default: pending)" Enums(pending, delivered)
+// @Param limit query int false "Number of reminders to return (default: 50, max: 100)"
+// @Param offset query int false "Number of reminders to skip (default: 0)"
+// @Success 200 {array} models.Reminder
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /reminders [get]
+// @Security BearerAuth
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	if status != "pending" && status != "delivered" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	// Get pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	reminders, err := h.reminderService.GetReminders(userID, status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reminders)
+}
+
+// GetReminder gets a reminder
+// @Summary Get reminder
+// @Description Get a reminder of the authenticated user
+// @Tags reminders
+// @Produce json
+// @Param id path string true "Reminder ID"
+// @Success 200 {object} models.Reminder
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /reminders/{id} [get]
+// @Security BearerAuth
+func (h *ReminderHandler) GetReminder(c *gin.Context) {
+	reminderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	reminder, err := h.reminderService.GetReminder(reminderID, userID)
+	if err != nil {
+		respondReminderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reminder)
+}
+
+// SnoozeReminder reminds the user again later
+// @Summary Snooze reminder
+// @Description Set a later time for a reminder, typed like "in 1 hour" or exact, in 15 minutes without a body. Delivered reminders become pending again
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param id path string true "Reminder ID"
+// @Param snooze body models.SnoozeReminderRequest false "New time"
+// @Success 200 {object} models.Reminder
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /reminders/{id}/snooze [post]
+// @Security BearerAuth
+func (h *ReminderHandler) SnoozeReminder(c *gin.Context) {
+	reminderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	// The body is optional
+	var req models.SnoozeReminderRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	reminder, err := h.reminderService.SnoozeReminder(reminderID, userID, &req)
+	if err != nil {
+		respondReminderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reminder)
+}
+
+// CancelReminder cancels a reminder
+// @Summary Cancel reminder
+// @Description Delete a reminder of the authenticated user, pending or delivered
+// @Tags reminders
+// @Produce json
+// @Param id path string true "Reminder ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /reminders/{id} [delete]
+// @Security BearerAuth
+func (h *ReminderHandler) CancelReminder(c *gin.Context) {
+	reminderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.reminderService.CancelReminder(reminderID, userID); err != nil {
+		respondReminderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder cancelled"})
+}
+
+// GetReminderSettings gets the reminder settings of the authenticated user
+// @Summary Get reminder settings
+// @Description Get the timezone reminder times are read in, the server default until the user sets one
+// @Tags reminders
+// @Produce json
+// @Success 200 {object} models.ReminderSettings
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /reminders/settings [get]
+// @Security BearerAuth
+func (h *ReminderHandler) GetReminderSettings(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	settings, err := h.reminderService.GetSettings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateReminderSettings changes the reminder settings of the authenticated user
+// @Summary Update reminder settings
+// @Description Set the timezone reminder times are read in, as an IANA name like Asia/Ho_Chi_Minh. Existing reminders keep their time
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param settings body models.UpdateReminderSettingsRequest true "Timezone"
+// @Success 200 {object} models.ReminderSettings
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /reminders/settings [put]
+// @Security BearerAuth
+func (h *ReminderHandler) UpdateReminderSettings(c *gin.Context) {
+	var req models.UpdateReminderSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	settings, err := h.reminderService.UpdateSettings(userID, &req)
+	if err != nil {
+		respondReminderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
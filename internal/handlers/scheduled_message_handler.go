@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ScheduledMessageHandler handles send-later HTTP requests
+type ScheduledMessageHandler struct {
+	scheduledMessageService *service.ScheduledMessageService
+}
+
+// NewScheduledMessageHandler creates a new scheduled message handler
+func NewScheduledMessageHandler(scheduledMessageService *service.ScheduledMessageService) *ScheduledMessageHandler {
+	return &ScheduledMessageHandler{
+		scheduledMessageService: scheduledMessageService,
+	}
+}
+
+// respondScheduledMessageError writes the error response of a scheduled message request
+func respondScheduledMessageError(c *gin.Context, err error) {
+	switch err {
+	case utils.ErrNotParticipant:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case utils.ErrScheduledMessageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled message not found"})
+	case utils.ErrScheduledMessageNotPending:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case utils.ErrScheduledAtInPast, utils.ErrScheduledAtTooFar, utils.ErrContentRequired, utils.ErrAttachmentRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ScheduleMessage schedules a message to be sent later
+// @Summary Schedule message
+// @Description Schedule a message to be posted in a conversation at a future time. Attachments and reply targets are checked when the message is posted
+// @Tags scheduled-messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message body models.ScheduleMessageRequest true "Message details and time"
+// @Success 201 {object} models.ScheduledMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /conversations/{id}/scheduled-messages [post]
+// @Security BearerAuth
+func (h *ScheduledMessageHandler) ScheduleMessage(c *gin.Context) {
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req models.ScheduleMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	scheduled, err := h.scheduledMessageService.ScheduleMessage(conversationID, userID, &req)
+	if err != nil {
+		respondScheduledMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, scheduled)
+}
+
+// GetScheduledMessages gets the scheduled messages of the authenticated user
+// @Summary Get scheduled messages
+// @Description Get the scheduled messages of the authenticated user, soonest first
+// @Tags scheduled-messages
+// @Produce json
+// @Param status query string false "Status filter (default: pending)" Enums(pending, sent, failed)
+// @Param limit query int false "Number of messages to return (default: 50, max: 100)"
+// @Param offset query int false "Number of messages to skip (default: 0)"
+// @Success 200 {array} models.ScheduledMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /scheduled-messages [get]
+// @Security BearerAuth
+func (h *ScheduledMessageHandler) GetScheduledMessages(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	if status != "pending" && status != "sent" && status != "failed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	// Get pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	scheduledMessages, err := h.scheduledMessageService.GetScheduledMessages(userID, status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, scheduledMessages)
+}
+
+// GetScheduledMessage gets a scheduled message
+// @Summary Get scheduled message
+// @Description Get a scheduled message of the authenticated user
+// @Tags scheduled-messages
+// @Produce json
+// @Param id path string true "Scheduled message ID"
+// @Success 200 {object} models.ScheduledMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /scheduled-messages/{id} [get]
+// @Security BearerAuth
+func (h *ScheduledMessageHandler) GetScheduledMessage(c *gin.Context) {
+	scheduledID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	scheduled, err := h.scheduledMessageService.GetScheduledMessage(scheduledID, userID)
+	if err != nil {
+		respondScheduledMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, scheduled)
+}
+
+// UpdateScheduledMessage changes a pending scheduled message
+// @Summary Update scheduled message
+// @Description Replace the content and time of a scheduled message that was not sent yet
+// @Tags scheduled-messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Scheduled message ID"
+// @Param message body models.ScheduleMessageRequest true "Message details and time"
+// @Success 200 {object} models.ScheduledMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /scheduled-messages/{id} [put]
+// @Security BearerAuth
+func (h *ScheduledMessageHandler) UpdateScheduledMessage(c *gin.Context) {
+	scheduledID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled message ID"})
+		return
+	}
+
+	var req models.ScheduleMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	scheduled, err := h.scheduledMessageService.UpdateScheduledMessage(scheduledID, userID, &req)
+	if err != nil {
+		respondScheduledMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, scheduled)
+}
+
+// CancelScheduledMessage cancels a scheduled message
+// @Summary Cancel scheduled message
+// @Description Delete a scheduled message that was not sent yet, or dismiss a failed one
+// @Tags scheduled-messages
+// @Produce json
+// @Param id path string true "Scheduled message ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /scheduled-messages/{id} [delete]
+// @Security BearerAuth
+func (h *ScheduledMessageHandler) CancelScheduledMessage(c *gin.Context) {
+	scheduledID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled message ID"})
+		return
+	}
+
+	// Get user ID from context
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.scheduledMessageService.CancelScheduledMessage(scheduledID, userID); err != nil {
+		respondScheduledMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduled message cancelled"})
+}
@@ -0,0 +1,249 @@
+// Package highlight highlights the syntax of code snippets on the server, so every client shows them the same way
+// Code is split into typed tokens by a lexer driven by the comment, string and keyword rules of each language.
+// Tokens can be rendered as HTML whose only markup is spans with fixed class names, all code is escaped
+package highlight
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token types
+const (
+	TokenPlain   = "plain"
+	TokenKeyword = "keyword"
+	TokenBuiltin = "builtin" // Built-in types, functions and constants
+	TokenString  = "string"
+	TokenNumber  = "number"
+	TokenComment = "comment"
+)
+
+// Token is a piece of code with its syntax type
+type Token struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// lexer splits code into tokens
+type lexer struct {
+	lang       *Language
+	code       string
+	pos        int
+	plainStart int // Start of the plain text not emitted yet, -1 if there is none
+	tokens     []Token
+}
+
+// Tokenize splits code into tokens, adjacent plain text is merged into a single token
+func Tokenize(lang *Language, code string) []Token {
+	if lang == nil || lang == PlainText {
+		if code == "" {
+			return nil
+		}
+		return []Token{{Type: TokenPlain, Text: code}}
+	}
+
+	l := &lexer{lang: lang, code: code, plainStart: -1}
+	for l.pos < len(l.code) {
+		l.next()
+	}
+	l.flushPlain(l.pos)
+	return l.tokens
+}
+
+// emit adds the code from start to the current position as a token
+// Plain text is contiguous, so a run of it is sliced once when it ends instead of being concatenated
+func (l *lexer) emit(tokenType string, start int) {
+	if start == l.pos {
+		return
+	}
+	if tokenType == TokenPlain {
+		if l.plainStart < 0 {
+			l.plainStart = start
+		}
+		return
+	}
+	l.flushPlain(start)
+	l.tokens = append(l.tokens, Token{Type: tokenType, Text: l.code[start:l.pos]})
+}
+
+// flushPlain adds the plain text not emitted yet, which ends at end, as a token
+func (l *lexer) flushPlain(end int) {
+	if l.plainStart < 0 {
+		return
+	}
+	l.tokens = append(l.tokens, Token{Type: TokenPlain, Text: l.code[l.plainStart:end]})
+	l.plainStart = -1
+}
+
+// next reads the token at the current position
+func (l *lexer) next() {
+	start := l.pos
+	rest := l.code[l.pos:]
+
+	for _, prefix := range l.lang.LineComments {
+		if strings.HasPrefix(rest, prefix) {
+			l.pos += lineEnd(rest)
+			l.emit(TokenComment, start)
+			return
+		}
+	}
+
+	if open, close := l.lang.BlockComment[0], l.lang.BlockComment[1]; open != "" && strings.HasPrefix(rest, open) {
+		l.pos += skipTo(rest, len(open), close)
+		l.emit(TokenComment, start)
+		return
+	}
+
+	r, size := utf8.DecodeRuneInString(rest)
+	switch {
+	case l.lang.TripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`)):
+		l.pos += skipTo(rest, 3, rest[:3])
+		l.emit(TokenString, start)
+	case strings.ContainsRune(l.lang.RawQuotes, r):
+		l.pos += skipTo(rest, size, string(r))
+		l.emit(TokenString, start)
+	case strings.ContainsRune(l.lang.Quotes, r):
+		l.pos += quotedEnd(rest, r)
+		l.emit(TokenString, start)
+	case isDigit(r) || (r == '.' && len(rest) > 1 && isDigit(rune(rest[1]))):
+		l.pos += numberEnd(rest)
+		l.emit(TokenNumber, start)
+	case l.isIdentStart(r):
+		l.pos += size
+		for l.pos < len(l.code) {
+			r, size := utf8.DecodeRuneInString(l.code[l.pos:])
+			if !l.isIdentStart(r) && !isDigit(r) {
+				break
+			}
+			l.pos += size
+		}
+		l.emit(l.wordType(l.code[start:l.pos]), start)
+	default:
+		l.pos += size
+		l.emit(TokenPlain, start)
+	}
+}
+
+// isIdentStart reports whether a rune can start an identifier
+func (l *lexer) isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || strings.ContainsRune(l.lang.IdentExtra, r)
+}
+
+// wordType returns the token type of an identifier
+func (l *lexer) wordType(word string) string {
+	if l.lang.IgnoreCase {
+		word = strings.ToLower(word)
+	}
+	switch {
+	case l.lang.keywords[word]:
+		return TokenKeyword
+	case l.lang.builtins[word]:
+		return TokenBuiltin
+	default:
+		return TokenPlain
+	}
+}
+
+// lineEnd returns the length of the line at the start of s, without its line break
+func lineEnd(s string) int {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return i
+	}
+	return len(s)
+}
+
+// skipTo returns the length of s up to and including the first end after offset, or of all of s if there is none
+func skipTo(s string, offset int, end string) int {
+	if i := strings.Index(s[offset:], end); i >= 0 {
+		return offset + i + len(end)
+	}
+	return len(s)
+}
+
+// quotedEnd returns the length of the quoted string at the start of s
+// Backslashes escape the next character and unterminated strings end with the line
+func quotedEnd(s string, quote rune) int {
+	for i := 1; i < len(s); i++ {
+		switch rune(s[i]) {
+		case '\\':
+			i++
+		case '\n':
+			return i
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// numberEnd returns the length of the number at the start of s, including hex digits, exponents and suffixes
+func numberEnd(s string) int {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case isDigit(rune(c)) || c == '.' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			i++
+		case (c == '+' || c == '-') && i > 0 && (s[i-1] == 'e' || s[i-1] == 'E') && !strings.HasPrefix(s, "0x"):
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// isDigit reports whether a rune is an ASCII digit
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// HTML renders tokens as HTML, tokens other than plain text are wrapped in <span class="hl-TYPE">
+func HTML(tokens []Token) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		if token.Type == TokenPlain {
+			b.WriteString(html.EscapeString(token.Text))
+			continue
+		}
+		b.WriteString(`<span class="hl-`)
+		b.WriteString(token.Type)
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(token.Text))
+		b.WriteString(`</span>`)
+	}
+	return b.String()
+}
+
+// Head returns the tokens of the first lines of code, and whether lines were left out
+// Tokens spanning the cut, like block comments, are cut too
+func Head(tokens []Token, lines int) ([]Token, bool) {
+	if lines <= 0 {
+		return nil, len(tokens) > 0
+	}
+
+	var head []Token
+	for i, token := range tokens {
+		newlines := strings.Count(token.Text, "\n")
+		if newlines < lines {
+			head = append(head, token)
+			lines -= newlines
+			continue
+		}
+
+		// The last line ends inside this token
+		cut := 0
+		for ; lines > 0; lines-- {
+			cut += strings.IndexByte(token.Text[cut:], '\n') + 1
+		}
+		if text := token.Text[:cut-1]; text != "" {
+			head = append(head, Token{Type: token.Type, Text: text})
+		}
+
+		truncated := cut < len(token.Text) || i < len(tokens)-1
+		return head, truncated
+	}
+	return head, false
+}
@@ -0,0 +1,232 @@
+package highlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lookup(t *testing.T, name string) *Language {
+	t.Helper()
+	lang, ok := Lookup(name)
+	if !ok {
+		t.Fatalf("language %q is not registered", name)
+	}
+	return lang
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		code string
+		want []Token
+	}{
+		{
+			name: "only plain text",
+			lang: "go",
+			code: "a + b",
+			want: []Token{{TokenPlain, "a + b"}},
+		},
+		{
+			name: "plain text between tokens",
+			lang: "go",
+			code: "x := 1 // one",
+			want: []Token{{TokenPlain, "x := "}, {TokenNumber, "1"}, {TokenPlain, " "}, {TokenComment, "// one"}},
+		},
+		{
+			name: "plain text at the end",
+			lang: "go",
+			code: "return x, y",
+			want: []Token{{TokenKeyword, "return"}, {TokenPlain, " x, y"}},
+		},
+		{
+			name: "adjacent tokens without plain text",
+			lang: "go",
+			code: "len(\"a\")",
+			want: []Token{{TokenBuiltin, "len"}, {TokenPlain, "("}, {TokenString, `"a"`}, {TokenPlain, ")"}},
+		},
+		{
+			name: "multibyte plain text",
+			lang: "go",
+			code: "héllo := \"wörld\" // ✓",
+			want: []Token{{TokenPlain, "héllo := "}, {TokenString, `"wörld"`}, {TokenPlain, " "}, {TokenComment, "// ✓"}},
+		},
+		{
+			name: "plain text across lines",
+			lang: "go",
+			code: "a\n\tb\nnil",
+			want: []Token{{TokenPlain, "a\n\tb\n"}, {TokenBuiltin, "nil"}},
+		},
+		{
+			name: "unterminated string ends with the line",
+			lang: "go",
+			code: "\"abc\nx",
+			want: []Token{{TokenString, `"abc`}, {TokenPlain, "\nx"}},
+		},
+		{
+			name: "escaped quote",
+			lang: "go",
+			code: `"a\"b" c`,
+			want: []Token{{TokenString, `"a\"b"`}, {TokenPlain, " c"}},
+		},
+		{
+			name: "raw string spans lines",
+			lang: "go",
+			code: "`a\nb` c",
+			want: []Token{{TokenString, "`a\nb`"}, {TokenPlain, " c"}},
+		},
+		{
+			name: "unterminated block comment",
+			lang: "go",
+			code: "x /* open",
+			want: []Token{{TokenPlain, "x "}, {TokenComment, "/* open"}},
+		},
+		{
+			name: "numbers",
+			lang: "go",
+			code: "0x1F + 1e-3 + .5",
+			want: []Token{{TokenNumber, "0x1F"}, {TokenPlain, " + "}, {TokenNumber, "1e-3"}, {TokenPlain, " + "}, {TokenNumber, ".5"}},
+		},
+		{
+			name: "identifier containing a keyword",
+			lang: "go",
+			code: "format",
+			want: []Token{{TokenPlain, "format"}},
+		},
+		{
+			name: "python triple quotes",
+			lang: "python",
+			code: "s = \"\"\"a\nb\"\"\"  # doc",
+			want: []Token{{TokenPlain, "s = "}, {TokenString, "\"\"\"a\nb\"\"\""}, {TokenPlain, "  "}, {TokenComment, "# doc"}},
+		},
+		{
+			name: "identifier characters of the language",
+			lang: "javascript",
+			code: "$el = document",
+			want: []Token{{TokenPlain, "$el = "}, {TokenBuiltin, "document"}},
+		},
+		{
+			name: "yaml builtins",
+			lang: "yaml",
+			code: "on: yes",
+			want: []Token{{TokenBuiltin, "on"}, {TokenPlain, ": "}, {TokenBuiltin, "yes"}},
+		},
+		{
+			name: "empty code",
+			lang: "go",
+			code: "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(lookup(t, tt.lang), tt.code); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) =\n%q\nwant\n%q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeKeepsAllCode(t *testing.T) {
+	codes := []string{
+		"package main\n\nfunc main() {\n\tfmt.Println(\"héllo\", 42) // done\n}\n",
+		"def f(x):\n    '''doc'''\n    return x * 2  # twice\n",
+		"const a = `line\n${b}`; /* c */ let d = 'e'",
+		"\x00\xff invalid utf-8 \"\xfe",
+		strings.Repeat("a + ", 1000),
+	}
+
+	for _, lang := range Languages() {
+		for _, code := range codes {
+			tokens := Tokenize(lang, code)
+
+			var b strings.Builder
+			for i, token := range tokens {
+				if token.Text == "" {
+					t.Errorf("%s: empty token %d in %q", lang.Name, i, code)
+				}
+				if i > 0 && token.Type == TokenPlain && tokens[i-1].Type == TokenPlain {
+					t.Errorf("%s: adjacent plain tokens %d and %d in %q", lang.Name, i-1, i, code)
+				}
+				b.WriteString(token.Text)
+			}
+			if b.String() != code {
+				t.Errorf("%s: tokens of %q join to %q", lang.Name, code, b.String())
+			}
+		}
+	}
+}
+
+func TestUnknownLanguages(t *testing.T) {
+	for _, name := range []string{"brainfuck", "", "go ", "GOLANG", "Py"} {
+		lang, ok := Lookup(name)
+		known := strings.TrimSpace(name) != "" && name != "brainfuck"
+		if ok != known {
+			t.Errorf("Lookup(%q) = %v, %v, want found = %v", name, lang, ok, known)
+		}
+	}
+
+	detected := []struct {
+		fileName string
+		want     *Language
+	}{
+		{"main.go", lookup(t, "go")},
+		{"Script.PY", lookup(t, "python")},
+		{"notes.txt", PlainText},
+		{"program.cob", PlainText},
+		{"Makefile", PlainText},
+		{"archive.tar.gz", PlainText},
+	}
+	for _, tt := range detected {
+		if got := Detect(tt.fileName); got != tt.want {
+			t.Errorf("Detect(%q) = %s, want %s", tt.fileName, got.Name, tt.want.Name)
+		}
+	}
+
+	// Code of unknown or plain text languages is a single plain token
+	for _, lang := range []*Language{nil, PlainText} {
+		code := "if x { return \"y\" } // z"
+		want := []Token{{TokenPlain, code}}
+		if got := Tokenize(lang, code); !reflect.DeepEqual(got, want) {
+			t.Errorf("Tokenize(%v) = %q, want %q", lang, got, want)
+		}
+		if got := Tokenize(lang, ""); got != nil {
+			t.Errorf("Tokenize(%v) of no code = %q, want nil", lang, got)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	tokens := []Token{{TokenPlain, "a < b && "}, {TokenString, `"<script>"`}, {TokenComment, "// it's"}}
+	want := `a &lt; b &amp;&amp; <span class="hl-string">&#34;&lt;script&gt;&#34;</span><span class="hl-comment">// it&#39;s</span>`
+
+	if got := HTML(tokens); got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+}
+
+func TestHead(t *testing.T) {
+	tokens := Tokenize(lookup(t, "go"), "a := 1\n/* one\ntwo\nthree */\nb")
+
+	tests := []struct {
+		lines     int
+		want      []Token
+		truncated bool
+	}{
+		{0, nil, true},
+		{1, []Token{{TokenPlain, "a := "}, {TokenNumber, "1"}}, true},
+		{2, []Token{{TokenPlain, "a := "}, {TokenNumber, "1"}, {TokenPlain, "\n"}, {TokenComment, "/* one"}}, true},
+		{4, []Token{{TokenPlain, "a := "}, {TokenNumber, "1"}, {TokenPlain, "\n"}, {TokenComment, "/* one\ntwo\nthree */"}}, true},
+		{5, tokens, false},
+		{10, tokens, false},
+	}
+
+	for _, tt := range tests {
+		got, truncated := Head(tokens, tt.lines)
+		if !reflect.DeepEqual(got, tt.want) || truncated != tt.truncated {
+			t.Errorf("Head(%d) = %q, %v, want %q, %v", tt.lines, got, truncated, tt.want, tt.truncated)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package highlight
+
+import (
+	"path"
+	"strings"
+)
+
+// Language describes the syntax of a programming language for the lexer
+type Language struct {
+	Name         string // Identifier sent by clients, like "go"
+	Title        string // Display name, like "Go"
+	Aliases      []string
+	Extensions   []string
+	LineComments []string
+	BlockComment [2]string // Start and end, empty if the language has none
+	Quotes       string    // Characters opening a string
+	RawQuotes    string    // Quotes whose strings span lines and have no escapes
+	TripleQuotes bool      // Python-style """ strings
+	IdentExtra   string    // Characters allowed in identifiers besides letters, digits and _
+	IgnoreCase   bool      // Keywords match in any case
+	keywords     map[string]bool
+	builtins     map[string]bool
+}
+
+// PlainText is the language of snippets without highlighting
+var PlainText = &Language{Name: "text", Title: "Plain text", Extensions: []string{".txt", ".log"}}
+
+// languages are the highlighted languages, PlainText included
+var languages = []*Language{
+	PlainText,
+	newLanguage(&Language{
+		Name: "go", Title: "Go", Aliases: []string{"golang"}, Extensions: []string{".go"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, RawQuotes: "`",
+	},
+		"break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var",
+		"append bool byte cap close complex copy delete error false float32 float64 int int8 int16 int32 int64 iota len make new nil panic print println real recover rune string true uint uint8 uint16 uint32 uint64 uintptr any comparable min max clear"),
+	newLanguage(&Language{
+		Name: "python", Title: "Python", Aliases: []string{"py"}, Extensions: []string{".py"},
+		LineComments: []string{"#"}, Quotes: `"'`, TripleQuotes: true,
+	},
+		"and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield match case",
+		"False None True bool bytes dict float int len list object print range set str super tuple type self isinstance enumerate zip open"),
+	newLanguage(&Language{
+		Name: "javascript", Title: "JavaScript", Aliases: []string{"js", "jsx"}, Extensions: []string{".js", ".mjs", ".cjs", ".jsx"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, RawQuotes: "`", IdentExtra: "$",
+	},
+		"async await break case catch class const continue debugger default delete do else export extends finally for function if import in instanceof let new of return static super switch this throw try typeof var void while with yield",
+		"Array Boolean Date Error JSON Map Math Number Object Promise RegExp Set String Symbol console document false null true undefined window NaN Infinity"),
+	newLanguage(&Language{
+		Name: "typescript", Title: "TypeScript", Aliases: []string{"ts", "tsx"}, Extensions: []string{".ts", ".tsx"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, RawQuotes: "`", IdentExtra: "$",
+	},
+		"abstract as async await break case catch class const continue declare default delete do else enum export extends finally for from function if implements import in instanceof interface keyof let namespace new of private protected public readonly return static super switch this throw try type typeof var void while yield",
+		"Array Boolean Date Error JSON Map Math Number Object Promise Record Set String any boolean console false never null number string true undefined unknown void"),
+	newLanguage(&Language{
+		Name: "java", Title: "Java", Extensions: []string{".java"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`,
+	},
+		"abstract assert break case catch class continue default do else enum extends final finally for if implements import instanceof interface native new package private protected public record return static super switch synchronized this throw throws transient try var void volatile while",
+		"Boolean Integer List Map Object String System boolean byte char double false float int long null short true"),
+	newLanguage(&Language{
+		Name: "kotlin", Title: "Kotlin", Aliases: []string{"kt"}, Extensions: []string{".kt", ".kts"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`,
+	},
+		"as break class continue data do else enum for fun if import in interface is object override package private protected public return sealed super this throw try typealias val var when while",
+		"Any Boolean Int List Long Map String Unit false listOf mapOf null println true"),
+	newLanguage(&Language{
+		Name: "c", Title: "C", Extensions: []string{".c", ".h"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, IdentExtra: "#",
+	},
+		"auto break case const continue default do else enum extern for goto if inline register restrict return sizeof static struct switch typedef union volatile while #include #define #ifdef #ifndef #endif #if #else #pragma",
+		"NULL bool char double float int long short signed size_t unsigned void printf malloc free true false"),
+	newLanguage(&Language{
+		Name: "cpp", Title: "C++", Aliases: []string{"c++", "cc"}, Extensions: []string{".cpp", ".cc", ".cxx", ".hpp", ".hh"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, IdentExtra: "#",
+	},
+		"auto break case catch class const constexpr continue default delete do else enum explicit extern for friend if inline namespace new noexcept operator private protected public return sizeof static struct switch template this throw try typedef typename union using virtual volatile while #include #define #ifdef #ifndef #endif #if #else #pragma",
+		"bool char double false float int long nullptr short signed size_t std string true unsigned vector void"),
+	newLanguage(&Language{
+		Name: "csharp", Title: "C#", Aliases: []string{"cs", "c#"}, Extensions: []string{".cs"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`,
+	},
+		"abstract as async await base break case catch class const continue default delegate do else enum event explicit extern finally for foreach if implicit in interface internal is lock namespace new operator out override params private protected public readonly record ref return sealed static struct switch this throw try typeof using var virtual void while",
+		"Console List String Task bool byte char decimal double false float int long null object string true"),
+	newLanguage(&Language{
+		Name: "rust", Title: "Rust", Aliases: []string{"rs"}, Extensions: []string{".rs"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"`,
+	},
+		"as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while",
+		"Box Err None Ok Option Result Some String Vec bool char f32 f64 false i8 i16 i32 i64 i128 isize str true u8 u16 u32 u64 u128 usize println"),
+	newLanguage(&Language{
+		Name: "ruby", Title: "Ruby", Aliases: []string{"rb"}, Extensions: []string{".rb"},
+		LineComments: []string{"#"}, Quotes: `"'`,
+	},
+		"alias and begin break case class def defined do else elsif end ensure for if in module next not or redo rescue retry return self super then unless until when while yield",
+		"false nil true puts require attr_accessor attr_reader"),
+	newLanguage(&Language{
+		Name: "php", Title: "PHP", Extensions: []string{".php"},
+		LineComments: []string{"//", "#"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, IdentExtra: "$",
+	},
+		"abstract as break case catch class const continue declare default do echo else elseif extends final finally fn for foreach function if implements interface namespace new private protected public return static switch throw trait try use while",
+		"array bool false float int null string true $this"),
+	newLanguage(&Language{
+		Name: "swift", Title: "Swift", Extensions: []string{".swift"},
+		LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"`,
+	},
+		"as break case catch class continue default defer do else enum extension fileprivate for func guard if import in init internal is let private protocol public return self static struct switch throw throws try var where while",
+		"Any Array Bool Dictionary Double Int String false nil print true"),
+	newLanguage(&Language{
+		Name: "sql", Title: "SQL", Aliases: []string{"postgresql", "mysql"}, Extensions: []string{".sql"},
+		LineComments: []string{"--"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `'`, IgnoreCase: true,
+	},
+		"add alter and as asc between by case check column constraint create cross default delete desc distinct drop else end exists foreign from full group having if in index inner insert into is join key left like limit not null offset on or order outer primary references returning right select set table then union unique update using values view when where with",
+		"avg bigint boolean char count date false integer jsonb max min now serial sum text timestamp true uuid varchar"),
+	newLanguage(&Language{
+		Name: "shell", Title: "Shell", Aliases: []string{"bash", "sh", "zsh"}, Extensions: []string{".sh", ".bash", ".zsh"},
+		LineComments: []string{"#"}, Quotes: `"`, RawQuotes: "'", IdentExtra: "$",
+	},
+		"case do done elif else esac export fi for function if in local return then until while",
+		"cd echo exit grep printf read set source test"),
+	newLanguage(&Language{
+		Name: "json", Title: "JSON", Extensions: []string{".json"},
+		Quotes: `"`,
+	}, "", "false null true"),
+	newLanguage(&Language{
+		Name: "yaml", Title: "YAML", Aliases: []string{"yml"}, Extensions: []string{".yaml", ".yml"},
+		LineComments: []string{"#"}, Quotes: `"'`,
+	}, "", "false no null off on true yes"),
+}
+
+// newLanguage sets the keywords and builtins of a language from space-separated lists
+func newLanguage(lang *Language, keywords, builtins string) *Language {
+	lang.keywords = wordSet(keywords, lang.IgnoreCase)
+	lang.builtins = wordSet(builtins, lang.IgnoreCase)
+	return lang
+}
+
+// wordSet builds a set from a space-separated list
+func wordSet(words string, ignoreCase bool) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		if ignoreCase {
+			word = strings.ToLower(word)
+		}
+		set[word] = true
+	}
+	return set
+}
+
+// Lookup gets a language by name or alias, ignoring case
+func Lookup(name string) (*Language, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, lang := range languages {
+		if lang.Name == name {
+			return lang, true
+		}
+		for _, alias := range lang.Aliases {
+			if alias == name {
+				return lang, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Detect guesses the language of a file from its extension, PlainText if unknown
+func Detect(fileName string) *Language {
+	ext := strings.ToLower(path.Ext(fileName))
+	if ext == "" {
+		return PlainText
+	}
+	for _, lang := range languages {
+		for _, extension := range lang.Extensions {
+			if extension == ext {
+				return lang
+			}
+		}
+	}
+	return PlainText
+}
+
+// Languages returns the supported languages
+func Languages() []*Language {
+	return append([]*Language(nil), languages...)
+}
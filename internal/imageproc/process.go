@@ -0,0 +1,171 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	// Register decoders for the supported upload formats
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxPixels is the largest image accepted, to protect against decompression bombs
+	MaxPixels = 50_000_000
+
+	// jpegQuality is the quality used when re-encoding images and thumbnails
+	jpegQuality = 85
+
+	// blurHashComponentsX and blurHashComponentsY control the detail of the placeholder
+	blurHashComponentsX = 4
+	blurHashComponentsY = 3
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported or corrupt image")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// ThumbnailSize is a named bounding box for generated thumbnails
+type ThumbnailSize struct {
+	Name         string
+	MaxDimension int
+}
+
+// ThumbnailSizes are generated for every image larger than the box
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "small", MaxDimension: 160},
+	{Name: "medium", MaxDimension: 480},
+	{Name: "large", MaxDimension: 1280},
+}
+
+// Thumbnail is an encoded, downscaled copy of an image
+type Thumbnail struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Result is a processed image ready to be stored
+type Result struct {
+	Data        []byte // Re-encoded image without metadata
+	ContentType string
+	Width       int
+	Height      int
+	BlurHash    string
+	Thumbnails  []Thumbnail
+}
+
+// Process prepares an uploaded image for web clients
+// The image is rotated according to its EXIF orientation and re-encoded, which drops
+// all metadata such as GPS coordinates. JPEG stays JPEG, PNG stays PNG, WebP becomes
+// JPEG (or PNG when it has transparency) and GIF is kept as is to preserve animation.
+func Process(data []byte) (*Result, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrUnsupportedImage
+	}
+
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	hasAlpha := !isOpaque(img)
+	bounds := img.Bounds()
+
+	result := &Result{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}
+
+	switch {
+	case format == "gif":
+		// GIF has no EXIF data and re-encoding would drop the animation
+		result.Data = data
+		result.ContentType = "image/gif"
+	case format == "png" || hasAlpha:
+		result.Data, err = encodePNG(img)
+		result.ContentType = "image/png"
+	default:
+		result.Data, err = encodeJPEG(img)
+		result.ContentType = "image/jpeg"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, size := range ThumbnailSizes {
+		if result.Width <= size.MaxDimension && result.Height <= size.MaxDimension {
+			continue
+		}
+
+		thumb := resize(img, size.MaxDimension)
+		thumbnail := Thumbnail{
+			Name:   size.Name,
+			Width:  thumb.Bounds().Dx(),
+			Height: thumb.Bounds().Dy(),
+		}
+
+		if hasAlpha {
+			thumbnail.Data, err = encodePNG(thumb)
+			thumbnail.ContentType = "image/png"
+		} else {
+			thumbnail.Data, err = encodeJPEG(thumb)
+			thumbnail.ContentType = "image/jpeg"
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.Thumbnails = append(result.Thumbnails, thumbnail)
+	}
+
+	// A tiny copy is enough for the blurred placeholder
+	result.BlurHash = encodeBlurHash(resize(img, 32), blurHashComponentsX, blurHashComponentsY)
+
+	return result, nil
+}
+
+// isOpaque reports whether an image has no transparent pixels
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,464 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// gradient returns an opaque test image with some detail
+func gradient(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 255})
+		}
+	}
+	return img
+}
+
+func encodeTestJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("encoding JPEG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeTestPNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encoding PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// exifTIFF builds a TIFF header whose first IFD holds only the orientation tag
+func exifTIFF(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+	return tiff
+}
+
+// withSegment inserts an APP1 segment right after the start of image marker of a JPEG
+func withSegment(data, payload []byte) []byte {
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// withEXIF adds an EXIF segment with the given orientation and a fake GPS marker to a JPEG
+func withEXIF(data []byte, order binary.ByteOrder, orientation uint16) []byte {
+	payload := append([]byte("Exif\x00\x00"), exifTIFF(order, orientation)...)
+	payload = append(payload, "GPS 10.7769N 106.7009E"...)
+	return withSegment(data, payload)
+}
+
+// pngHeader returns a PNG whose header claims the given size, without the pixel data to back it
+func pngHeader(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	data := encodeTestPNG(t, gradient(1, 1))
+
+	// Signature (8) + chunk length (4), then "IHDR" and its 13 data bytes followed by the CRC
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestProcess(t *testing.T) {
+	transparent := gradient(600, 300)
+	transparent.SetNRGBA(0, 0, color.NRGBA{})
+
+	var gifData bytes.Buffer
+	paletted := image.NewPaletted(image.Rect(0, 0, 500, 200), color.Palette{color.Black, color.White})
+	if err := gif.Encode(&gifData, paletted, nil); err != nil {
+		t.Fatalf("encoding GIF: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		width       int
+		height      int
+		thumbnails  []Thumbnail // Data is not compared
+	}{
+		{
+			name:        "large JPEG gets every thumbnail",
+			data:        encodeTestJPEG(t, gradient(2000, 1000)),
+			contentType: "image/jpeg",
+			width:       2000,
+			height:      1000,
+			thumbnails: []Thumbnail{
+				{Name: "small", Width: 160, Height: 80, ContentType: "image/jpeg"},
+				{Name: "medium", Width: 480, Height: 240, ContentType: "image/jpeg"},
+				{Name: "large", Width: 1280, Height: 640, ContentType: "image/jpeg"},
+			},
+		},
+		{
+			name:        "portrait JPEG fits its height",
+			data:        encodeTestJPEG(t, gradient(300, 600)),
+			contentType: "image/jpeg",
+			width:       300,
+			height:      600,
+			thumbnails: []Thumbnail{
+				{Name: "small", Width: 80, Height: 160, ContentType: "image/jpeg"},
+				{Name: "medium", Width: 240, Height: 480, ContentType: "image/jpeg"},
+			},
+		},
+		{
+			name:        "small PNG has no thumbnails",
+			data:        encodeTestPNG(t, gradient(100, 100)),
+			contentType: "image/png",
+			width:       100,
+			height:      100,
+		},
+		{
+			name:        "image exactly at a thumbnail size is not scaled to it",
+			data:        encodeTestPNG(t, gradient(160, 90)),
+			contentType: "image/png",
+			width:       160,
+			height:      90,
+		},
+		{
+			name:        "transparent PNG keeps PNG thumbnails",
+			data:        encodeTestPNG(t, transparent),
+			contentType: "image/png",
+			width:       600,
+			height:      300,
+			thumbnails: []Thumbnail{
+				{Name: "small", Width: 160, Height: 80, ContentType: "image/png"},
+				{Name: "medium", Width: 480, Height: 240, ContentType: "image/png"},
+			},
+		},
+		{
+			name:        "extreme aspect ratio keeps at least one pixel",
+			data:        encodeTestPNG(t, gradient(2000, 1)),
+			contentType: "image/png",
+			width:       2000,
+			height:      1,
+			thumbnails: []Thumbnail{
+				{Name: "small", Width: 160, Height: 1, ContentType: "image/jpeg"},
+				{Name: "medium", Width: 480, Height: 1, ContentType: "image/jpeg"},
+				{Name: "large", Width: 1280, Height: 1, ContentType: "image/jpeg"},
+			},
+		},
+		{
+			name:        "GIF is kept as is",
+			data:        gifData.Bytes(),
+			contentType: "image/gif",
+			width:       500,
+			height:      200,
+			thumbnails: []Thumbnail{
+				{Name: "small", Width: 160, Height: 64, ContentType: "image/jpeg"},
+				{Name: "medium", Width: 480, Height: 192, ContentType: "image/jpeg"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if result.ContentType != tt.contentType {
+				t.Errorf("ContentType = %q, want %q", result.ContentType, tt.contentType)
+			}
+			if result.Width != tt.width || result.Height != tt.height {
+				t.Errorf("size = %dx%d, want %dx%d", result.Width, result.Height, tt.width, tt.height)
+			}
+			if tt.contentType == "image/gif" && !bytes.Equal(result.Data, tt.data) {
+				t.Error("GIF data was re-encoded")
+			}
+
+			config, format, err := image.DecodeConfig(bytes.NewReader(result.Data))
+			if err != nil {
+				t.Fatalf("decoding result: %v", err)
+			}
+			if "image/"+format != tt.contentType || config.Width != tt.width || config.Height != tt.height {
+				t.Errorf("result decodes as %s %dx%d", format, config.Width, config.Height)
+			}
+
+			if len(result.Thumbnails) != len(tt.thumbnails) {
+				t.Fatalf("got %d thumbnails, want %d", len(result.Thumbnails), len(tt.thumbnails))
+			}
+			for i, want := range tt.thumbnails {
+				got := result.Thumbnails[i]
+				if got.Name != want.Name || got.Width != want.Width || got.Height != want.Height || got.ContentType != want.ContentType {
+					t.Errorf("thumbnail %d = %s %dx%d %s, want %s %dx%d %s", i,
+						got.Name, got.Width, got.Height, got.ContentType,
+						want.Name, want.Width, want.Height, want.ContentType)
+				}
+
+				config, _, err := image.DecodeConfig(bytes.NewReader(got.Data))
+				if err != nil {
+					t.Fatalf("decoding thumbnail %s: %v", got.Name, err)
+				}
+				if config.Width != want.Width || config.Height != want.Height {
+					t.Errorf("thumbnail %s decodes as %dx%d", got.Name, config.Width, config.Height)
+				}
+			}
+
+			if len(result.BlurHash) != 28 {
+				t.Errorf("BlurHash = %q, want 28 characters", result.BlurHash)
+			}
+		})
+	}
+}
+
+func TestProcessRejectsInvalidImages(t *testing.T) {
+	jpegData := encodeTestJPEG(t, gradient(64, 64))
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrUnsupportedImage},
+		{"not an image", []byte("%PDF-1.7 definitely not an image"), ErrUnsupportedImage},
+		{"truncated JPEG", jpegData[:len(jpegData)/2], ErrUnsupportedImage},
+		{"decompression bomb", pngHeader(t, 10000, 10000), ErrImageTooLarge},
+		{"just over the pixel limit", pngHeader(t, MaxPixels/1000+1, 1000), ErrImageTooLarge},
+		{"zero width", pngHeader(t, 0, 10), ErrUnsupportedImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Process() = %v, %v, want error %v", result, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestProcessStripsEXIF(t *testing.T) {
+	plain := encodeTestJPEG(t, gradient(40, 20))
+
+	tests := []struct {
+		name   string
+		data   []byte
+		width  int
+		height int
+	}{
+		{"no EXIF", plain, 40, 20},
+		{"little endian, normal", withEXIF(plain, binary.LittleEndian, 1), 40, 20},
+		{"little endian, rotated 90", withEXIF(plain, binary.LittleEndian, 6), 20, 40},
+		{"big endian, rotated 270", withEXIF(plain, binary.BigEndian, 8), 20, 40},
+		{"big endian, rotated 180", withEXIF(plain, binary.BigEndian, 3), 40, 20},
+		{"invalid orientation", withEXIF(plain, binary.LittleEndian, 9), 40, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if result.Width != tt.width || result.Height != tt.height {
+				t.Errorf("size = %dx%d, want %dx%d", result.Width, result.Height, tt.width, tt.height)
+			}
+			if bytes.Contains(result.Data, []byte("Exif")) || bytes.Contains(result.Data, []byte("GPS")) {
+				t.Error("result still contains EXIF metadata")
+			}
+			if got := jpegOrientation(result.Data); got != 1 {
+				t.Errorf("result orientation = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeTestJPEG(t, gradient(8, 8))
+	exif := withEXIF(plain, binary.BigEndian, 6)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"empty", nil, 1},
+		{"not a JPEG", []byte("GIF89a......"), 1},
+		{"no EXIF", plain, 1},
+		{"little endian", withEXIF(plain, binary.LittleEndian, 6), 6},
+		{"big endian", exif, 6},
+		{"mirrored", withEXIF(plain, binary.LittleEndian, 2), 2},
+		{"out of range", withEXIF(plain, binary.LittleEndian, 0), 1},
+		{"other APP1 segment", withSegment(plain, []byte("http://ns.adobe.com/xap/1.0/\x00")), 1},
+		{"EXIF after another segment", withSegment(exif, []byte("http://ns.adobe.com/xap/1.0/\x00")), 6},
+		{"truncated segment", exif[:20], 1},
+		{"segment length past the end", append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF}, "Exif\x00\x00"...), 1},
+		{"segment length too small", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0x00, 0x00}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTIFFOrientation(t *testing.T) {
+	valid := exifTIFF(binary.LittleEndian, 6)
+
+	badOrder := append([]byte{}, valid...)
+	copy(badOrder, "XX")
+
+	offsetPastEnd := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(offsetPastEnd[4:], 1000)
+
+	offsetInHeader := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(offsetInHeader[4:], 2)
+
+	tooManyEntries := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint16(tooManyEntries[8:], 100)
+	binary.LittleEndian.PutUint16(tooManyEntries[10:], 0x010F) // Make, so the orientation is never found
+
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{"valid", valid, 6},
+		{"too short", valid[:6], 1},
+		{"unknown byte order", badOrder, 1},
+		{"IFD offset past the end", offsetPastEnd, 1},
+		{"IFD offset inside the header", offsetInHeader, 1},
+		{"entry count past the end", tooManyEntries, 1},
+		{"truncated entry", valid[:16], 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tiffOrientation(tt.tiff); got != tt.want {
+				t.Errorf("tiffOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// A 3x2 image with a distinct color per pixel:
+	//   A B C
+	//   D E F
+	const pixels = "ABCDEF"
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := range pixels {
+		src.SetNRGBA(i%3, i/3, color.NRGBA{R: pixels[i], A: 255})
+	}
+
+	tests := []struct {
+		orientation int
+		want        []string // Rows of the result
+	}{
+		{0, []string{"ABC", "DEF"}},
+		{1, []string{"ABC", "DEF"}},
+		{2, []string{"CBA", "FED"}},
+		{3, []string{"FED", "CBA"}},
+		{4, []string{"DEF", "ABC"}},
+		{5, []string{"AD", "BE", "CF"}},
+		{6, []string{"DA", "EB", "FC"}},
+		{7, []string{"FC", "EB", "DA"}},
+		{8, []string{"CF", "BE", "AD"}},
+		{9, []string{"ABC", "DEF"}},
+	}
+
+	for _, tt := range tests {
+		img := applyOrientation(src, tt.orientation)
+		bounds := img.Bounds()
+
+		var rows []string
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			var row []byte
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, _, _, _ := img.At(x, y).RGBA()
+				row = append(row, byte(r>>8))
+			}
+			rows = append(rows, string(row))
+		}
+
+		if len(rows) != len(tt.want) {
+			t.Errorf("orientation %d: got rows %q, want %q", tt.orientation, rows, tt.want)
+			continue
+		}
+		for i := range rows {
+			if rows[i] != tt.want[i] {
+				t.Errorf("orientation %d: got rows %q, want %q", tt.orientation, rows, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEncodeBlurHash(t *testing.T) {
+	solid := func(c color.NRGBA) image.Image {
+		img := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		dc   string // Characters 2 to 6 encode the average sRGB color
+	}{
+		{"black", solid(color.NRGBA{A: 255}), encodeBase83(0x000000, 4)},
+		{"white", solid(color.NRGBA{R: 255, G: 255, B: 255, A: 255}), encodeBase83(0xFFFFFF, 4)},
+		{"red", solid(color.NRGBA{R: 255, A: 255}), encodeBase83(0xFF0000, 4)},
+		{"1x1", solid(color.NRGBA{G: 255, A: 255}).(*image.NRGBA).SubImage(image.Rect(0, 0, 1, 1)), encodeBase83(0x00FF00, 4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeBlurHash(tt.img, blurHashComponentsX, blurHashComponentsY)
+			if len(got) != 28 || got[2:6] != tt.dc {
+				t.Errorf("encodeBlurHash() = %q, want 28 characters with average color %q", got, tt.dc)
+			}
+		})
+	}
+
+	// Black has no energy in any component
+	if got := encodeBlurHash(solid(color.NRGBA{A: 255}), 4, 3); got != "L00000fQfQfQfQfQfQfQfQfQfQfQ" {
+		t.Errorf("encodeBlurHash() of black = %q", got)
+	}
+
+	hash := encodeBlurHash(gradient(32, 32), 4, 3)
+	if len(hash) != 28 || hash[0] != 'L' {
+		t.Errorf("encodeBlurHash() = %q, want 28 characters starting with the 4x3 size flag", hash)
+	}
+	if again := encodeBlurHash(gradient(32, 32), 4, 3); again != hash {
+		t.Errorf("encodeBlurHash() is not deterministic: %q then %q", hash, again)
+	}
+}
@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/internal/websocket"
+)
+
+const (
+	// exportBatchSize is the number of pending exports claimed per poll
+	exportBatchSize = 5
+
+	// exportClaimTimeout is how long an export may stay running before it is
+	// considered lost by a stopped instance and claimed again
+	exportClaimTimeout = 30 * time.Minute
+
+	// expiredExportBatchSize is the number of expired export files deleted per poll
+	expiredExportBatchSize = 100
+)
+
+// ExportWorker produces the conversation exports too large to be produced during the request
+// and deletes export files past their retention period
+// Exports are claimed atomically, so several server instances can run a worker
+type ExportWorker struct {
+	exportService *service.ExportService
+	wsHandler     *websocket.Handler
+	interval      time.Duration
+}
+
+// NewExportWorker creates a new export worker polling at the given interval
+func NewExportWorker(exportService *service.ExportService, wsHandler *websocket.Handler, interval time.Duration) *ExportWorker {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	return &ExportWorker{
+		exportService: exportService,
+		wsHandler:     wsHandler,
+		interval:      interval,
+	}
+}
+
+// Start polls for pending exports until the process exits
+func (w *ExportWorker) Start() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.run()
+		w.expire()
+		<-ticker.C
+	}
+}
+
+// run produces all pending exports
+func (w *ExportWorker) run() {
+	for {
+		claimed, err := w.exportService.ClaimExports(exportBatchSize, exportClaimTimeout)
+		if err != nil {
+			log.Printf("Error claiming exports: %v", err)
+			return
+		}
+
+		for _, export := range claimed {
+			w.exportService.RunExport(context.Background(), export)
+			w.notifyRequester(export)
+		}
+
+		if len(claimed) < exportBatchSize {
+			return
+		}
+	}
+}
+
+// expire deletes the files of expired exports
+func (w *ExportWorker) expire() {
+	for {
+		deleted, err := w.exportService.DeleteExpiredExports(context.Background(), expiredExportBatchSize)
+		if err != nil {
+			log.Printf("Error deleting expired exports: %v", err)
+			return
+		}
+		if deleted < expiredExportBatchSize {
+			return
+		}
+	}
+}
+
+// notifyRequester tells the requester that their export is ready or failed
+func (w *ExportWorker) notifyRequester(export *models.ConversationExport) {
+	if w.wsHandler == nil {
+		return
+	}
+
+	eventType := "export_completed"
+	if export.Status != "completed" {
+		eventType = "export_failed"
+	}
+
+	w.wsHandler.SendToUser(export.RequestedBy.String(), &websocket.Message{
+		Type:      eventType,
+		UserID:    export.RequestedBy.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      export,
+	})
+}
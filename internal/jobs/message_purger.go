@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"goswift/internal/service"
+	"goswift/internal/websocket"
+)
+
+const (
+	// messagePurgeBatchSize is the number of expired messages deleted per transaction
+	messagePurgeBatchSize = 200
+
+	// defaultPurgeInterval is used when no valid purge interval is configured
+	defaultPurgeInterval = time.Minute
+)
+
+// MessagePurger hard-deletes disappearing messages once they expire
+// Expired messages are already hidden from reads, so the interval only bounds how long they stay stored
+type MessagePurger struct {
+	chatService       *service.ChatService
+	attachmentService *service.AttachmentService
+	wsHandler         *websocket.Handler
+	interval          time.Duration
+}
+
+// NewMessagePurger creates a new message purger running at the given interval
+func NewMessagePurger(
+	chatService *service.ChatService,
+	attachmentService *service.AttachmentService,
+	wsHandler *websocket.Handler,
+	interval time.Duration,
+) *MessagePurger {
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+
+	return &MessagePurger{
+		chatService:       chatService,
+		attachmentService: attachmentService,
+		wsHandler:         wsHandler,
+		interval:          interval,
+	}
+}
+
+// Start purges expired messages until the process exits
+func (p *MessagePurger) Start() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge()
+		<-ticker.C
+	}
+}
+
+// purge deletes all expired messages, their stored files and notifies the conversations
+func (p *MessagePurger) purge() {
+	for {
+		messages, attachments, err := p.chatService.PurgeExpiredMessages(messagePurgeBatchSize)
+		if err != nil {
+			log.Printf("Error purging expired messages: %v", err)
+			return
+		}
+
+		p.attachmentService.DeleteUnreferencedFiles(context.Background(), attachments)
+
+		if p.wsHandler != nil {
+			for _, message := range messages {
+				data := map[string]interface{}{
+					"conversation_id": message.ConversationID.String(),
+					"message_id":      message.ID.String(),
+				}
+				if message.ThreadRootID != nil {
+					data["thread_root_id"] = message.ThreadRootID.String()
+				}
+
+				p.wsHandler.BroadcastMessage(&websocket.Message{
+					Type:      "message_expired",
+					Timestamp: time.Now().Unix(),
+					Data:      data,
+				})
+			}
+		}
+
+		// Replies are deleted along with their expired roots, so a short batch means nothing expired is left
+		if len(messages) < messagePurgeBatchSize {
+			return
+		}
+	}
+}
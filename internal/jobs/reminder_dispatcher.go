@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/internal/websocket"
+)
+
+const (
+	// reminderBatchSize is the number of due reminders claimed per poll
+	reminderBatchSize = 100
+
+	// reminderClaimTimeout is how long a claimed reminder may stay undelivered
+	// before it is considered lost by a stopped instance
+	reminderClaimTimeout = 5 * time.Minute
+)
+
+// ReminderDispatcher delivers reminders when they are due
+// A due reminder becomes a notification in the feed of its user and is pushed to their open clients
+type ReminderDispatcher struct {
+	reminderService *service.ReminderService
+	wsHandler       *websocket.Handler
+	interval        time.Duration
+}
+
+// NewReminderDispatcher creates a new reminder dispatcher polling at the given interval
+func NewReminderDispatcher(reminderService *service.ReminderService, wsHandler *websocket.Handler, interval time.Duration) *ReminderDispatcher {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	return &ReminderDispatcher{
+		reminderService: reminderService,
+		wsHandler:       wsHandler,
+		interval:        interval,
+	}
+}
+
+// Start polls for due reminders until the process exits
+func (d *ReminderDispatcher) Start() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch()
+		<-ticker.C
+	}
+}
+
+// dispatch delivers all due reminders
+func (d *ReminderDispatcher) dispatch() {
+	if released, err := d.reminderService.ReleaseStaleClaims(reminderClaimTimeout); err != nil {
+		log.Printf("Error releasing stale reminders: %v", err)
+	} else if released > 0 {
+		log.Printf("Released %d stale reminders", released)
+	}
+
+	for {
+		claimed, err := d.reminderService.ClaimDueReminders(reminderBatchSize)
+		if err != nil {
+			log.Printf("Error claiming reminders: %v", err)
+			return
+		}
+
+		for _, reminder := range claimed {
+			d.deliver(reminder)
+		}
+
+		if len(claimed) < reminderBatchSize {
+			return
+		}
+	}
+}
+
+// deliver notifies the user of a claimed reminder
+func (d *ReminderDispatcher) deliver(reminder *models.Reminder) {
+	notification, err := d.reminderService.Deliver(reminder)
+	if err != nil {
+		log.Printf("Error delivering reminder %s: %v", reminder.ID, err)
+	}
+	if notification == nil || d.wsHandler == nil {
+		return
+	}
+
+	d.wsHandler.SendToUser(reminder.UserID.String(), &websocket.Message{
+		Type:      "notification",
+		UserID:    reminder.UserID.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      notification,
+	})
+	d.wsHandler.SendToUser(reminder.UserID.String(), &websocket.Message{
+		Type:      "reminder",
+		UserID:    reminder.UserID.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      reminder,
+	})
+}
@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/service"
+	"goswift/internal/websocket"
+)
+
+const (
+	// scheduledMessageBatchSize is the number of due messages claimed per poll
+	scheduledMessageBatchSize = 50
+
+	// scheduledMessageClaimTimeout is how long a claimed message may stay unsent
+	// before it is considered lost by a stopped instance
+	scheduledMessageClaimTimeout = 5 * time.Minute
+
+	// defaultPollInterval is used when no valid poll interval is configured
+	defaultPollInterval = 5 * time.Second
+)
+
+// MessagePublisher broadcasts posted messages and sends the notifications they cause
+type MessagePublisher interface {
+	PublishMessage(message *models.MessageResponse)
+}
+
+// ScheduledMessageDispatcher posts scheduled messages when they are due
+// Schedules are stored in the database, so they survive restarts, and claimed atomically,
+// so several server instances can run a dispatcher without double sends
+type ScheduledMessageDispatcher struct {
+	scheduledMessageService *service.ScheduledMessageService
+	chatService             *service.ChatService
+	publisher               MessagePublisher
+	wsHandler               *websocket.Handler
+	interval                time.Duration
+}
+
+// NewScheduledMessageDispatcher creates a new scheduled message dispatcher polling at the given interval
+func NewScheduledMessageDispatcher(
+	scheduledMessageService *service.ScheduledMessageService,
+	chatService *service.ChatService,
+	publisher MessagePublisher,
+	wsHandler *websocket.Handler,
+	interval time.Duration,
+) *ScheduledMessageDispatcher {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	return &ScheduledMessageDispatcher{
+		scheduledMessageService: scheduledMessageService,
+		chatService:             chatService,
+		publisher:               publisher,
+		wsHandler:               wsHandler,
+		interval:                interval,
+	}
+}
+
+// Start polls for due messages until the process exits
+func (d *ScheduledMessageDispatcher) Start() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch()
+		<-ticker.C
+	}
+}
+
+// dispatch posts all due messages
+func (d *ScheduledMessageDispatcher) dispatch() {
+	stale, err := d.scheduledMessageService.FailStaleClaims(scheduledMessageClaimTimeout)
+	if err != nil {
+		log.Printf("Error failing stale scheduled messages: %v", err)
+	}
+	for _, scheduled := range stale {
+		d.notifySender("scheduled_message_failed", scheduled)
+	}
+
+	for {
+		claimed, err := d.scheduledMessageService.ClaimDueMessages(scheduledMessageBatchSize)
+		if err != nil {
+			log.Printf("Error claiming scheduled messages: %v", err)
+			return
+		}
+
+		for _, scheduled := range claimed {
+			d.send(scheduled)
+		}
+
+		if len(claimed) < scheduledMessageBatchSize {
+			return
+		}
+	}
+}
+
+// send posts a claimed scheduled message as its sender
+func (d *ScheduledMessageDispatcher) send(scheduled *models.ScheduledMessage) {
+	message, created, err := d.chatService.SendMessage(scheduled.SendRequest(), scheduled.SenderID)
+	if err != nil {
+		log.Printf("Error sending scheduled message %s: %v", scheduled.ID, err)
+
+		reason := err.Error()
+		scheduled.Status = "failed"
+		scheduled.Error = &reason
+		if err := d.scheduledMessageService.MarkFailed(scheduled.ID, reason); err != nil {
+			log.Printf("Error marking scheduled message %s as failed: %v", scheduled.ID, err)
+		}
+
+		d.notifySender("scheduled_message_failed", scheduled)
+		return
+	}
+
+	scheduled.Status = "sent"
+	scheduled.MessageID = &message.ID
+	if err := d.scheduledMessageService.MarkSent(scheduled.ID, message.ID); err != nil {
+		log.Printf("Error marking scheduled message %s as sent: %v", scheduled.ID, err)
+	}
+
+	if created {
+		d.publisher.PublishMessage(message)
+	}
+	d.notifySender("scheduled_message_sent", scheduled)
+}
+
+// notifySender tells the sender that one of their scheduled messages was sent or failed
+func (d *ScheduledMessageDispatcher) notifySender(eventType string, scheduled *models.ScheduledMessage) {
+	if d.wsHandler == nil {
+		return
+	}
+
+	d.wsHandler.SendToUser(scheduled.SenderID.String(), &websocket.Message{
+		Type:      eventType,
+		UserID:    scheduled.SenderID.String(),
+		Timestamp: time.Now().Unix(),
+		Data:      scheduled,
+	})
+}
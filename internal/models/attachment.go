@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment represents an uploaded file that can be linked to a message
+type Attachment struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	ConversationID uuid.UUID  `json:"conversation_id" db:"conversation_id"`
+	MessageID      *uuid.UUID `json:"message_id,omitempty" db:"message_id"` // Nil until the attachment is sent in a message
+	UploaderID     uuid.UUID  `json:"uploader_id" db:"uploader_id"`
+	FileName       string     `json:"file_name" db:"file_name"`
+	ContentType    string     `json:"content_type" db:"content_type"`
+	SizeBytes      int64      `json:"size_bytes" db:"size_bytes"`
+	StorageKey     string     `json:"-" db:"storage_key"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+
+	// Image metadata, only set for image attachments
+	Width      *int                  `json:"width,omitempty" db:"width"`
+	Height     *int                  `json:"height,omitempty" db:"height"`
+	BlurHash   *string               `json:"blurhash,omitempty" db:"blurhash"` // Placeholder shown while the image loads
+	Thumbnails []AttachmentThumbnail `json:"thumbnails,omitempty" db:"thumbnails"`
+
+	// Audio metadata, only set for Ogg/Opus and M4A/AAC recordings
+	DurationMs *int  `json:"duration_ms,omitempty" db:"duration_ms"`
+	Waveform   []int `json:"waveform,omitempty" db:"waveform"` // 64 loudness values from 0 to 100 for the player
+
+	// Virtual fields
+	URL string `json:"url" db:"-"` // Authorized download URL
+}
+
+// AttachmentThumbnail represents a downscaled copy of an image attachment
+type AttachmentThumbnail struct {
+	Size        string `json:"size"` // small, medium or large
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+
+	// Virtual fields
+	URL string `json:"url,omitempty"` // Authorized download URL
+}
+
+// IsImage reports whether the attachment is an image
+func (a *Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+// IsVoice reports whether the attachment is a recording that can be sent as a voice message
+func (a *Attachment) IsVoice() bool {
+	return a.DurationMs != nil
+}
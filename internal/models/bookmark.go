@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bookmark represents a message saved by a user to find it later
+type Bookmark struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	UserID         uuid.UUID        `json:"-" db:"user_id"`
+	MessageID      uuid.UUID        `json:"message_id" db:"message_id"`
+	ConversationID uuid.UUID        `json:"conversation_id" db:"conversation_id"`
+	Note           string           `json:"note" db:"note"` // Personal note, only visible to the user
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	Message        *MessageResponse `json:"message,omitempty"`
+}
+
+// BookmarkRequest represents the request to bookmark a message
+type BookmarkRequest struct {
+	Note string `json:"note" binding:"max=500"` // Replaces the note of an existing bookmark
+}
+
+// BookmarkPage represents a page of bookmarks
+type BookmarkPage struct {
+	Bookmarks  []*Bookmark `json:"bookmarks"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
+	"goswift/internal/richtext"
+
 	"github.com/google/uuid"
 )
 
@@ -11,10 +14,14 @@ type Conversation struct {
 	ID          uuid.UUID `json:"id" db:"id"`
 	Name        string    `json:"name" db:"name"` // For group chats
 	Type        string    `json:"type" db:"type"` // "direct" or "group"
+	Topic       string    `json:"topic,omitempty" db:"topic"`
 	CreatedBy   uuid.UUID `json:"created_by" db:"created_by"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 	LastMessage *Message  `json:"last_message,omitempty" db:"-"` // Virtual field
+
+	// Disappearing messages, nil when messages never expire
+	MessageTTLSeconds *int `json:"message_ttl_seconds,omitempty" db:"message_ttl_seconds"`
 }
 
 // Message represents a chat message
@@ -22,12 +29,43 @@ type Message struct {
 	ID             uuid.UUID `json:"id" db:"id"`
 	ConversationID uuid.UUID `json:"conversation_id" db:"conversation_id"`
 	SenderID       uuid.UUID `json:"sender_id" db:"sender_id"`
-	Content        string    `json:"content" db:"content"`
-	MessageType    string    `json:"message_type" db:"message_type"` // "text", "image", "file"
+	Content        string    `json:"content" db:"content"`           // Plain text, rendered from the rich content if any
+	MessageType    string    `json:"message_type" db:"message_type"` // "text", "image", "file", "voice", "snippet", "system", "poll" or a payload kind
 	IsRead         bool      `json:"is_read" db:"is_read"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 
+	// Thread fields
+	ReplyToID    *uuid.UUID `json:"reply_to_id,omitempty" db:"reply_to_id"`
+	ThreadRootID *uuid.UUID `json:"thread_root_id,omitempty" db:"thread_root_id"` // Root message of the thread this reply belongs to
+	ReplyCount   int        `json:"reply_count" db:"reply_count"`
+	LastReplyAt  *time.Time `json:"last_reply_at,omitempty" db:"last_reply_at"`
+	LastReplyBy  *uuid.UUID `json:"last_reply_by,omitempty" db:"last_reply_by"`
+
+	// Forwarding fields, copied from the original message
+	ForwardedFromMessageID  *uuid.UUID `json:"forwarded_from_message_id,omitempty" db:"forwarded_from_message_id"`
+	ForwardedFromSenderID   *uuid.UUID `json:"forwarded_from_sender_id,omitempty" db:"forwarded_from_sender_id"`
+	ForwardedFromSenderName *string    `json:"forwarded_from_sender_name,omitempty" db:"forwarded_from_sender_name"`
+	ForwardedFromCreatedAt  *time.Time `json:"forwarded_from_created_at,omitempty" db:"forwarded_from_created_at"`
+
+	// Set from the conversation TTL at send time
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+
+	// Client-supplied ID, unique per sender, used to dedupe retried sends
+	ClientMsgID *string `json:"client_msg_id,omitempty" db:"client_msg_id"`
+
+	// Parsed document of Markdown messages
+	RichContent *richtext.Node `json:"rich_content,omitempty" db:"rich_content"`
+
+	// Structured payload of system messages
+	SystemEvent *SystemEvent `json:"system_event,omitempty" db:"system_event"`
+
+	// Last edit of the content after sending, nil if never edited
+	EditedAt *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+
+	// Payload of typed messages like locations, content holds its plain-text fallback
+	Payload json.RawMessage `json:"payload,omitempty" db:"payload" swaggertype:"object"`
+
 	// Virtual fields for joins
 	SenderName string `json:"sender_name,omitempty" db:"-"`
 	Sender     *User  `json:"sender,omitempty" db:"-"`
@@ -40,6 +78,7 @@ type ConversationParticipant struct {
 	UserID         uuid.UUID `json:"user_id" db:"user_id"`
 	JoinedAt       time.Time `json:"joined_at" db:"joined_at"`
 	IsAdmin        bool      `json:"is_admin" db:"is_admin"` // For group chats
+	IsMuted        bool      `json:"is_muted" db:"is_muted"` // Muted conversations only notify about mentions
 
 	// Virtual fields for joins
 	User *User `json:"user,omitempty" db:"-"`
@@ -53,11 +92,33 @@ type CreateConversationRequest struct {
 	CreatedBy uuid.UUID   `json:"created_by"`
 }
 
+// UpdateConversationRequest represents the request to rename a conversation
+type UpdateConversationRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+// AddParticipantsRequest represents the request to add members to a group
+type AddParticipantsRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1,max=50"`
+}
+
+// SetParticipantAdminRequest represents the request to grant or revoke the admin role of a member
+type SetParticipantAdminRequest struct {
+	IsAdmin *bool `json:"is_admin" binding:"required"`
+}
+
 // SendMessageRequest represents the request to send a message
 type SendMessageRequest struct {
-	ConversationID uuid.UUID `json:"conversation_id" binding:"required"`
-	Content        string    `json:"content" binding:"required,min=1,max=1000"`
-	MessageType    string    `json:"message_type" binding:"required,oneof=text image file"`
+	ConversationID uuid.UUID       `json:"conversation_id" binding:"required"`
+	Content        string          `json:"content" binding:"max=1000"`                                // Required for text messages, optional caption otherwise
+	Format         string          `json:"format,omitempty" binding:"omitempty,oneof=plain markdown"` // "plain" by default
+	MessageType    string          `json:"message_type" binding:"required,max=20"`                    // "text", "image", "file", "voice", "snippet" or a payload kind listed by GET /messages/kinds
+	Payload        json.RawMessage `json:"payload,omitempty" swaggertype:"object"`                    // Required for payload kinds, validated against their schema
+	Snippet        *SnippetRequest `json:"snippet,omitempty"`                                         // Required for snippet messages
+	ReplyToID      *uuid.UUID      `json:"reply_to_id,omitempty"`                                     // Reply in the thread of this message
+	AttachmentIDs  []uuid.UUID     `json:"attachment_ids,omitempty" binding:"omitempty,max=10"`       // Required for image, file and voice messages
+	ClientMsgID    string          `json:"client_msg_id,omitempty" binding:"max=255"`                 // Retries with the same ID return the original message
+	KeepDraft      bool            `json:"-"`                                                         // Set for messages not typed in the composer, like scheduled ones
 }
 
 // ConversationResponse represents the conversation response
@@ -65,11 +126,14 @@ type ConversationResponse struct {
 	ID           uuid.UUID `json:"id"`
 	Name         string    `json:"name"`
 	Type         string    `json:"type"`
+	Topic        string    `json:"topic,omitempty"`
 	CreatedBy    uuid.UUID `json:"created_by"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	LastMessage  *Message  `json:"last_message,omitempty"`
 	Participants []User    `json:"participants,omitempty"`
+
+	MessageTTLSeconds *int `json:"message_ttl_seconds,omitempty"`
 }
 
 // MessageResponse represents the message response
@@ -83,4 +147,162 @@ type MessageResponse struct {
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 	SenderName     string    `json:"sender_name"`
+
+	// Thread info
+	ReplyToID    *uuid.UUID `json:"reply_to_id,omitempty"`
+	ThreadRootID *uuid.UUID `json:"thread_root_id,omitempty"`
+	ReplyCount   int        `json:"reply_count"`
+	LastReplyAt  *time.Time `json:"last_reply_at,omitempty"`
+	LastReplyBy  *uuid.UUID `json:"last_reply_by,omitempty"`
+
+	// Reactions aggregated per emoji
+	Reactions []ReactionSummary `json:"reactions,omitempty"`
+
+	Attachments []*Attachment `json:"attachments,omitempty"`
+
+	// Mentions found in the content, for rendering
+	Mentions []MessageMention `json:"mentions,omitempty"`
+
+	// Pin state
+	IsPinned bool       `json:"is_pinned"`
+	PinnedBy *uuid.UUID `json:"pinned_by,omitempty"`
+	PinnedAt *time.Time `json:"pinned_at,omitempty"`
+
+	// Bookmark state of the user the message is returned to
+	IsBookmarked bool   `json:"is_bookmarked"`
+	BookmarkNote string `json:"bookmark_note,omitempty"`
+
+	// Set when the message was forwarded from another conversation
+	ForwardedFrom *ForwardedFrom `json:"forwarded_from,omitempty"`
+
+	// Disappearing messages are deleted after this time
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	ClientMsgID *string `json:"client_msg_id,omitempty"`
+
+	// Parsed document of Markdown messages, content holds its plain-text rendering
+	RichContent *richtext.Node `json:"rich_content,omitempty"`
+
+	// Structured payload of system messages, content holds an English description
+	SystemEvent *SystemEvent `json:"system_event,omitempty"`
+
+	// Payload of typed messages like locations, content holds its plain-text fallback
+	Payload json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+
+	// Last edit of the content after sending, nil if never edited
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+
+	// Set on poll messages
+	Poll *Poll `json:"poll,omitempty"`
+
+	// Set on snippet messages, the full code is loaded from the snippet endpoints
+	Snippet *SnippetPreview `json:"snippet,omitempty"`
+
+	// Aggregated delivery state over the recipients, only set for the sender
+	Delivery *DeliveryState `json:"delivery,omitempty"`
+
+	// Set when sending the message cleared the sender's draft in the conversation
+	DraftCleared bool `json:"-"`
+}
+
+// UpdateMessageTTLRequest represents the request to change the disappearing messages setting
+type UpdateMessageTTLRequest struct {
+	TTLSeconds int `json:"ttl_seconds" binding:"omitempty,min=60,max=31536000"` // 0 turns disappearing messages off
+}
+
+// ForwardedFrom attributes a forwarded message to its original sender
+type ForwardedFrom struct {
+	MessageID  *uuid.UUID `json:"message_id,omitempty"` // Nil once the original message is deleted
+	SenderID   *uuid.UUID `json:"sender_id,omitempty"`  // Nil once the original sender is deleted
+	SenderName string     `json:"sender_name"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ForwardMessagesRequest represents the request to forward messages to other conversations
+type ForwardMessagesRequest struct {
+	MessageIDs      []uuid.UUID `json:"message_ids" binding:"required,min=1,max=20"`
+	ConversationIDs []uuid.UUID `json:"conversation_ids" binding:"required,min=1,max=10"` // Target conversations
+}
+
+// MessageMention represents an @mention in the content of a message
+// Offset and length are counted in characters (Unicode code points) and include the @
+type MessageMention struct {
+	ID             uuid.UUID  `json:"-" db:"id"`
+	MessageID      uuid.UUID  `json:"-" db:"message_id"`
+	ConversationID uuid.UUID  `json:"-" db:"conversation_id"`
+	Type           string     `json:"type" db:"mention_type"`         // user, everyone or here
+	UserID         *uuid.UUID `json:"user_id,omitempty" db:"user_id"` // Mentioned user, only for user mentions
+	Offset         int        `json:"offset" db:"start_offset"`
+	Length         int        `json:"length" db:"length"`
+}
+
+// PinnedMessage represents a message pinned in a conversation
+type PinnedMessage struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	ConversationID uuid.UUID `json:"conversation_id" db:"conversation_id"`
+	MessageID      uuid.UUID `json:"message_id" db:"message_id"`
+	PinnedBy       uuid.UUID `json:"pinned_by" db:"pinned_by"`
+	PinnedAt       time.Time `json:"pinned_at" db:"pinned_at"`
+}
+
+// ThreadResponse represents a thread root message with a page of its replies
+type ThreadResponse struct {
+	Root    *MessageResponse   `json:"root"`
+	Replies []*MessageResponse `json:"replies"`
+}
+
+// Reaction represents a user's emoji reaction on a message
+type Reaction struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	MessageID uuid.UUID `json:"message_id" db:"message_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Emoji     string    `json:"emoji" db:"emoji"` // Unicode emoji or custom shortcode like :party_parrot:
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReactionSummary represents the aggregated reactions with one emoji on a message
+type ReactionSummary struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
+
+// AddReactionRequest represents the request to react to a message
+type AddReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required,max=64"`
+}
+
+// MessageSearchFilter represents the filters of a message search
+type MessageSearchFilter struct {
+	Query          string
+	UserID         uuid.UUID // Only conversations this user participates in are searched
+	ConversationID *uuid.UUID
+	SenderID       *uuid.UUID
+	MessageType    string
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+
+	// Cursor position, results are older than this message
+	BeforeCreatedAt *time.Time
+	BeforeID        *uuid.UUID
+}
+
+// MessageSearchResult represents a message matching a search with a highlighted snippet
+type MessageSearchResult struct {
+	Message *MessageResponse `json:"message"`
+	Snippet string           `json:"snippet"` // HTML escaped content with matches wrapped in <mark> tags
+}
+
+// MessageSearchResponse represents a page of search results
+type MessageSearchResponse struct {
+	Results    []*MessageSearchResult `json:"results"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// MessageKind describes a message type with a structured payload
+type MessageKind struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema" swaggertype:"object"` // JSON Schema of the payload
 }
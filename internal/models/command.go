@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SlashCommand is a third-party slash command, run by calling its URL
+type SlashCommand struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"` // Without the slash
+	URL         string     `json:"url" db:"url"`
+	Usage       string     `json:"usage" db:"usage"`
+	Description string     `json:"description" db:"description"`
+	Secret      string     `json:"secret,omitempty" db:"secret"` // Signs the requests, only returned when the command is registered
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateSlashCommandRequest represents the request to register a third-party slash command
+type CreateSlashCommandRequest struct {
+	Name        string `json:"name" binding:"required,max=32"`
+	URL         string `json:"url" binding:"required,url,max=2048"`
+	Usage       string `json:"usage,omitempty" binding:"max=100"`
+	Description string `json:"description" binding:"required,max=255"`
+}
+
+// CommandInfo describes a slash command available to users
+type CommandInfo struct {
+	Name        string `json:"name"`
+	Usage       string `json:"usage,omitempty"`
+	Description string `json:"description"`
+	Permission  string `json:"permission"` // "member", "conversation_admin" or "workspace_admin"
+	BuiltIn     bool   `json:"built_in"`
+}
+
+// CommandResult is the outcome of a slash command sent as a message
+type CommandResult struct {
+	Command      string           `json:"command"`
+	ResponseType string           `json:"response_type,omitempty"` // "ephemeral" or "in_channel", empty without reply
+	Text         string           `json:"text,omitempty"`
+	Message      *MessageResponse `json:"message,omitempty"` // Message posted by an in_channel reply
+}
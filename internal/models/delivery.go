@@ -0,0 +1,46 @@
+package models
+
+import "github.com/google/uuid"
+
+// Delivery states of a message, from the sender's point of view
+const (
+	DeliverySent      = "sent"      // Saved, not yet on every recipient's device
+	DeliveryDelivered = "delivered" // Acknowledged by a socket of every recipient
+	DeliveryRead      = "read"      // Read by every recipient
+)
+
+// DeliveryState represents the aggregated delivery state of a message over its recipients
+type DeliveryState struct {
+	Status         string `json:"status"` // "sent", "delivered" or "read"
+	RecipientCount int    `json:"recipient_count"`
+	DeliveredCount int    `json:"delivered_count"` // Includes recipients who read the message
+	ReadCount      int    `json:"read_count"`
+}
+
+// SetStatus derives the status of the state from its counts
+func (d *DeliveryState) SetStatus() {
+	switch {
+	case d.RecipientCount > 0 && d.ReadCount == d.RecipientCount:
+		d.Status = DeliveryRead
+	case d.RecipientCount > 0 && d.DeliveredCount == d.RecipientCount:
+		d.Status = DeliveryDelivered
+	default:
+		d.Status = DeliverySent
+	}
+}
+
+// MessageReceipt represents the delivery state of a message for one recipient
+type MessageReceipt struct {
+	UserID      uuid.UUID `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Status      string    `json:"status"` // "sent", "delivered" or "read"
+}
+
+// DeliveryUpdate represents the new delivery states of the messages of a sender after a
+// recipient acknowledged or read them, pushed to the sender as a delivery_update event
+type DeliveryUpdate struct {
+	ConversationID uuid.UUID                    `json:"conversation_id"`
+	SenderID       uuid.UUID                    `json:"-"`
+	RecipientID    uuid.UUID                    `json:"recipient_id"`
+	Messages       map[uuid.UUID]*DeliveryState `json:"messages"` // Keyed by message ID
+}
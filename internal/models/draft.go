@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageDraft represents the unsent message a user is writing in a conversation
+// Drafts are synced across the user's devices, the latest updated_at wins
+type MessageDraft struct {
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`
+	ConversationID uuid.UUID `json:"conversation_id" db:"conversation_id"`
+	Content        string    `json:"content" db:"content"` // Empty once cleared
+	Format         string    `json:"format" db:"format"`   // "plain" or "markdown"
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SaveDraftRequest represents the request to save a draft
+type SaveDraftRequest struct {
+	Content   string     `json:"content" binding:"max=1000"`                                // Empty content clears the draft
+	Format    string     `json:"format,omitempty" binding:"omitempty,oneof=plain markdown"` // "plain" by default
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`                                      // When the user edited the draft, now by default
+	DeviceID  string     `json:"device_id,omitempty" binding:"max=100"`                     // Echoed in the draft_updated event so the device can skip it
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConversationExport is a transcript of a conversation requested for download
+type ConversationExport struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	ConversationID uuid.UUID  `json:"conversation_id" db:"conversation_id"`
+	RequestedBy    uuid.UUID  `json:"requested_by" db:"requested_by"`
+	Format         string     `json:"format" db:"format"` // "json", "html" or "csv"
+	Status         string     `json:"status" db:"status"` // "pending", "running", "completed" or "failed"
+	ClaimedAt      *time.Time `json:"-" db:"claimed_at"`
+	StorageKey     *string    `json:"-" db:"storage_key"`
+	FileName       *string    `json:"file_name,omitempty" db:"file_name"`
+	SizeBytes      *int64     `json:"size_bytes,omitempty" db:"size_bytes"`
+	MessageCount   *int       `json:"message_count,omitempty" db:"message_count"`
+	Error          *string    `json:"error,omitempty" db:"error"` // Reason of the failure
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" db:"expires_at"` // When the file is deleted
+
+	// Virtual fields
+	DownloadURL string `json:"download_url,omitempty" db:"-"` // Authorized download URL once completed
+}
+
+// CreateExportRequest represents the request to export a conversation
+type CreateExportRequest struct {
+	Format string `json:"format" binding:"required,oneof=json html csv"`
+}
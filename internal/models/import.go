@@ -0,0 +1,14 @@
+package models
+
+// SlackImportReport summarizes an import of a Slack export archive
+// Re-running an import only adds what was not imported before, so the counts are those of this run
+type SlackImportReport struct {
+	ConversationsCreated int      `json:"conversations_created"`
+	ConversationsSkipped int      `json:"conversations_skipped"` // Channels without any member found by email
+	ParticipantsAdded    int      `json:"participants_added"`
+	MessagesImported     int      `json:"messages_imported"`
+	MessagesExisting     int      `json:"messages_existing"` // Already imported by a previous run
+	MessagesUnmapped     int      `json:"messages_unmapped"` // Skipped because the sender was not found by email
+	ReactionsImported    int      `json:"reactions_imported"`
+	UnmappedUsers        []string `json:"unmapped_users"` // Slack users with messages and no GoSwift account, by email or name
+}
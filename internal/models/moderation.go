@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModerationRule is a workspace-wide rule checked before messages are saved
+type ModerationRule struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Kind      string     `json:"kind" db:"kind"`       // "word", "domain" or "regex"
+	Pattern   string     `json:"pattern" db:"pattern"` // Word or phrase, domain or regular expression
+	Action    string     `json:"action" db:"action"`   // "reject", "redact" or "flag"
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateModerationRuleRequest represents the request to add a moderation rule
+type CreateModerationRuleRequest struct {
+	Kind    string `json:"kind" binding:"required,oneof=word domain regex"`
+	Pattern string `json:"pattern" binding:"required,max=500"`
+	Action  string `json:"action" binding:"required,oneof=reject redact flag"`
+	Reason  string `json:"reason,omitempty" binding:"max=255"`
+}
+
+// ModerationFlag is a message waiting in or reviewed from the moderation queue
+type ModerationFlag struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	MessageID      *uuid.UUID `json:"message_id,omitempty" db:"message_id"` // Unset once the message is deleted
+	ConversationID uuid.UUID  `json:"conversation_id" db:"conversation_id"`
+	SenderID       uuid.UUID  `json:"sender_id" db:"sender_id"`
+	SenderName     string     `json:"sender_name,omitempty"`
+	Hook           string     `json:"hook" db:"hook"` // Rule or classifier that flagged the message
+	Reason         string     `json:"reason" db:"reason"`
+	Content        string     `json:"content" db:"content"` // Content as sent, before redactions
+	Status         string     `json:"status" db:"status"`   // "pending", "dismissed" or "removed"
+	ReviewedBy     *uuid.UUID `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ReviewModerationFlagRequest represents the decision of a moderator on a flagged message
+type ReviewModerationFlagRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=dismiss remove"` // "remove" deletes the message
+}
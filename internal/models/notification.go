@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification represents an entry in a user's notification feed
+type Notification struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	Type           string     `json:"type" db:"type"` // "thread_reply", "mention" or "reminder"
+	ConversationID *uuid.UUID `json:"conversation_id,omitempty" db:"conversation_id"`
+	MessageID      *uuid.UUID `json:"message_id,omitempty" db:"message_id"`
+	ActorID        *uuid.UUID `json:"actor_id,omitempty" db:"actor_id"`
+	Content        string     `json:"content" db:"content"`
+	IsRead         bool       `json:"is_read" db:"is_read"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Poll represents a poll posted as a message in a group conversation
+type Poll struct {
+	ID             uuid.UUID     `json:"id" db:"id"`
+	MessageID      uuid.UUID     `json:"message_id" db:"message_id"`
+	ConversationID uuid.UUID     `json:"conversation_id" db:"conversation_id"`
+	Question       string        `json:"question" db:"question"`
+	AllowsMultiple bool          `json:"allows_multiple" db:"allows_multiple"`
+	IsAnonymous    bool          `json:"is_anonymous" db:"is_anonymous"` // Voters are only listed for public polls
+	VoterCount     int           `json:"voter_count" db:"voter_count"`
+	ClosesAt       *time.Time    `json:"closes_at,omitempty" db:"closes_at"`
+	ClosedAt       *time.Time    `json:"closed_at,omitempty" db:"closed_at"` // Set when closed early
+	ClosedBy       *uuid.UUID    `json:"closed_by,omitempty" db:"closed_by"`
+	CreatedBy      uuid.UUID     `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
+	Options        []*PollOption `json:"options" db:"-"`
+
+	// Virtual fields
+	IsClosed bool        `json:"is_closed" db:"-"`
+	MyVotes  []uuid.UUID `json:"my_votes,omitempty" db:"-"` // Options the requesting user voted for
+}
+
+// PollOption represents an option of a poll with its vote count
+type PollOption struct {
+	ID        uuid.UUID   `json:"id" db:"id"`
+	PollID    uuid.UUID   `json:"-" db:"poll_id"`
+	Position  int         `json:"position" db:"position"`
+	Text      string      `json:"text" db:"text"`
+	VoteCount int         `json:"vote_count" db:"vote_count"`
+	Voters    []uuid.UUID `json:"voters,omitempty" db:"-"` // Only for public polls
+}
+
+// CreatePollRequest represents the request to post a poll
+type CreatePollRequest struct {
+	Question       string     `json:"question" binding:"required,min=1,max=300"`
+	Options        []string   `json:"options" binding:"required,min=2,max=10,dive,required,max=100"`
+	AllowsMultiple bool       `json:"allows_multiple"`
+	IsAnonymous    bool       `json:"is_anonymous"`
+	ClosesAt       *time.Time `json:"closes_at,omitempty"` // RFC 3339 time in the future
+}
+
+// VotePollRequest represents the request to vote in a poll
+// The vote replaces any previous vote of the user, an empty list retracts it
+type VotePollRequest struct {
+	OptionIDs []uuid.UUID `json:"option_ids" binding:"max=10"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reminder represents a private reminder of a user about a message or a conversation
+type Reminder struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	ConversationID uuid.UUID  `json:"conversation_id" db:"conversation_id"`
+	MessageID      *uuid.UUID `json:"message_id,omitempty" db:"message_id"` // Empty for reminders about the conversation
+	MessagePreview string     `json:"message_preview,omitempty" db:"-"`
+	Note           string     `json:"note" db:"note"`
+	RemindAt       time.Time  `json:"remind_at" db:"remind_at"` // In the timezone of the reminder
+	Timezone       string     `json:"timezone" db:"timezone"`
+	Status         string     `json:"status" db:"status"` // "pending", "delivering" or "delivered"
+	ClaimedAt      *time.Time `json:"-" db:"claimed_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	NotificationID *uuid.UUID `json:"notification_id,omitempty" db:"notification_id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateReminderRequest represents the request to be reminded about a message
+type CreateReminderRequest struct {
+	When     string     `json:"when,omitempty" binding:"max=100"`    // Like "in 2 hours", "tomorrow 9am" or "friday at 14:30", may end with the note
+	RemindAt *time.Time `json:"remind_at,omitempty"`                 // RFC 3339 time, instead of when
+	Note     string     `json:"note,omitempty" binding:"max=500"`    // Replaces the note given in when
+	Timezone string     `json:"timezone,omitempty" binding:"max=64"` // IANA name, saved as the timezone of the user
+}
+
+// SnoozeReminderRequest represents the request to be reminded again later, in 15 minutes without a time
+type SnoozeReminderRequest struct {
+	When     string     `json:"when,omitempty" binding:"max=100"`
+	RemindAt *time.Time `json:"remind_at,omitempty"`
+}
+
+// ReminderSettings represents the reminder preferences of a user
+type ReminderSettings struct {
+	Timezone  string     `json:"timezone"`
+	IsDefault bool       `json:"is_default"` // The user never set a timezone
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateReminderSettingsRequest represents the request to change the reminder preferences of a user
+type UpdateReminderSettingsRequest struct {
+	Timezone string `json:"timezone" binding:"required,max=64"`
+}
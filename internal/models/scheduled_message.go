@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduledMessage represents a message to be posted at a future time
+type ScheduledMessage struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	ConversationID uuid.UUID   `json:"conversation_id" db:"conversation_id"`
+	SenderID       uuid.UUID   `json:"sender_id" db:"sender_id"`
+	Content        string      `json:"content" db:"content"`
+	Format         string      `json:"format" db:"format"` // "plain" or "markdown"
+	MessageType    string      `json:"message_type" db:"message_type"`
+	ReplyToID      *uuid.UUID  `json:"reply_to_id,omitempty" db:"reply_to_id"`
+	AttachmentIDs  []uuid.UUID `json:"attachment_ids" db:"attachment_ids"`
+	ScheduledAt    time.Time   `json:"scheduled_at" db:"scheduled_at"`
+	Status         string      `json:"status" db:"status"` // "pending", "sending", "sent" or "failed"
+	ClaimedAt      *time.Time  `json:"-" db:"claimed_at"`
+	MessageID      *uuid.UUID  `json:"message_id,omitempty" db:"message_id"` // Posted message once sent
+	Error          *string     `json:"error,omitempty" db:"error"`           // Reason of the failure
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// ScheduleMessageRequest represents the request to schedule a message or change a pending schedule
+type ScheduleMessageRequest struct {
+	Content       string      `json:"content" binding:"max=1000"`
+	Format        string      `json:"format,omitempty" binding:"omitempty,oneof=plain markdown"` // "plain" by default
+	MessageType   string      `json:"message_type" binding:"required,oneof=text image file"`
+	ReplyToID     *uuid.UUID  `json:"reply_to_id,omitempty"`
+	AttachmentIDs []uuid.UUID `json:"attachment_ids,omitempty" binding:"omitempty,max=10"`
+	ScheduledAt   time.Time   `json:"scheduled_at" binding:"required"` // RFC 3339 time in the future
+}
+
+// SendRequest builds the request used to post a scheduled message
+// The client message ID is derived from the schedule, so it is never posted twice,
+// and the draft the sender may be writing meanwhile is kept
+func (m *ScheduledMessage) SendRequest() *SendMessageRequest {
+	return &SendMessageRequest{
+		ConversationID: m.ConversationID,
+		Content:        m.Content,
+		Format:         m.Format,
+		MessageType:    m.MessageType,
+		ReplyToID:      m.ReplyToID,
+		AttachmentIDs:  m.AttachmentIDs,
+		ClientMsgID:    "scheduled:" + m.ID.String(),
+		KeepDraft:      true,
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"goswift/internal/highlight"
+
+	"github.com/google/uuid"
+)
+
+// Snippet represents the code of a snippet message
+type Snippet struct {
+	MessageID uuid.UUID `json:"message_id" db:"message_id"`
+	Language  string    `json:"language" db:"language"`
+	Filename  string    `json:"filename,omitempty" db:"filename"`
+	Code      string    `json:"code" db:"code"`
+	SizeBytes int       `json:"size_bytes" db:"size_bytes"`
+	LineCount int       `json:"line_count" db:"line_count"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// DownloadName returns the file name of the snippet for downloads, named after its language if it has none
+func (s *Snippet) DownloadName() string {
+	if s.Filename != "" {
+		return s.Filename
+	}
+
+	ext := ".txt"
+	if lang, ok := highlight.Lookup(s.Language); ok && len(lang.Extensions) > 0 {
+		ext = lang.Extensions[0]
+	}
+	return "snippet" + ext
+}
+
+// SnippetRequest represents the code sent with a snippet message
+type SnippetRequest struct {
+	Language string `json:"language,omitempty" binding:"max=30"`  // Detected from the file name if empty, see GET /messages/snippets/languages
+	Filename string `json:"filename,omitempty" binding:"max=255"` // Used for downloads
+	Code     string `json:"code" binding:"required"`
+}
+
+// SnippetPreview represents the collapsed snippet shown in message lists
+type SnippetPreview struct {
+	Language      string `json:"language"`
+	LanguageTitle string `json:"language_title"`
+	Filename      string `json:"filename,omitempty"`
+	SizeBytes     int    `json:"size_bytes"`
+	LineCount     int    `json:"line_count"`
+	PreviewHTML   string `json:"preview_html"` // Highlighted first lines
+	Truncated     bool   `json:"truncated"`    // The preview leaves out lines
+	RawURL        string `json:"raw_url"`
+}
+
+// SnippetResponse represents the full highlighted code of a snippet message
+type SnippetResponse struct {
+	SnippetPreview
+	MessageID uuid.UUID         `json:"message_id"`
+	Code      string            `json:"code"`
+	HTML      string            `json:"html"`             // Spans with hl-keyword, hl-builtin, hl-string, hl-number and hl-comment classes
+	Tokens    []highlight.Token `json:"tokens,omitempty"` // Only with ?tokens=true, for clients rendering natively
+}
+
+// SnippetLanguage represents a language snippets can be highlighted in
+type SnippetLanguage struct {
+	Name    string   `json:"name"`
+	Title   string   `json:"title"`
+	Aliases []string `json:"aliases,omitempty"`
+}
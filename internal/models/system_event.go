@@ -0,0 +1,40 @@
+package models
+
+import "github.com/google/uuid"
+
+// System events recorded as system messages
+const (
+	SystemEventConversationCreated = "conversation_created"
+	SystemEventParticipantsAdded   = "participants_added"
+	SystemEventParticipantRemoved  = "participant_removed"
+	SystemEventParticipantLeft     = "participant_left"
+	SystemEventConversationRenamed = "conversation_renamed"
+	SystemEventTopicChanged        = "topic_changed"
+	SystemEventAdminGranted        = "admin_granted"
+	SystemEventAdminRevoked        = "admin_revoked"
+	SystemEventMessageTTLChanged   = "message_ttl_changed"
+	SystemEventMessagePinned       = "message_pinned"
+	SystemEventMessageUnpinned     = "message_unpinned"
+)
+
+// SystemEvent is the structured payload of a system message, for clients to localize
+// Names are those at the time of the event, the IDs identify the users
+type SystemEvent struct {
+	Event     string            `json:"event"`
+	Actor     SystemEventUser   `json:"actor"`
+	Targets   []SystemEventUser `json:"targets,omitempty"`    // Users the event is about
+	MessageID *uuid.UUID        `json:"message_id,omitempty"` // Message the event is about
+	OldValue  interface{}       `json:"old_value,omitempty"`  // Setting before the change
+	NewValue  interface{}       `json:"new_value,omitempty"`  // Setting after the change
+}
+
+// SystemEventUser identifies a user in a system event
+type SystemEventUser struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"display_name"`
+}
+
+// NewSystemEventUser creates the system event reference to a user
+func NewSystemEventUser(user *User) SystemEventUser {
+	return SystemEventUser{ID: user.ID, DisplayName: user.DisplayName}
+}
@@ -0,0 +1,86 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxHTTPResponseSize limits the verdicts read from external classifiers
+const maxHTTPResponseSize = 1 << 20
+
+// HTTPHook sends messages to an external classifier
+// The classifier receives the Input as JSON and answers with a Verdict
+type HTTPHook struct {
+	url        string
+	client     *http.Client
+	failClosed bool
+}
+
+// NewHTTPHook creates a hook calling the classifier at url
+// With failClosed, messages are rejected when the classifier cannot be reached
+func NewHTTPHook(url string, timeout time.Duration, failClosed bool) *HTTPHook {
+	return &HTTPHook{
+		url:        url,
+		client:     &http.Client{Timeout: timeout},
+		failClosed: failClosed,
+	}
+}
+
+func (h *HTTPHook) Name() string {
+	return "classifier"
+}
+
+func (h *HTTPHook) Check(ctx context.Context, input *Input) (*Verdict, error) {
+	verdict, err := h.classify(ctx, input)
+	if err != nil && h.failClosed {
+		return &Verdict{Action: ActionReject, Reason: "message could not be checked"}, nil
+	}
+	return verdict, err
+}
+
+func (h *HTTPHook) classify(ctx context.Context, input *Input) (*Verdict, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call classifier: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("classifier returned status %d", resp.StatusCode)
+	}
+
+	var verdict Verdict
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxHTTPResponseSize)).Decode(&verdict); err != nil {
+		return nil, fmt.Errorf("failed to decode verdict: %w", err)
+	}
+
+	switch verdict.Action {
+	case "", ActionAllow:
+		return nil, nil
+	case ActionReject, ActionFlag:
+		return &verdict, nil
+	case ActionRedact:
+		if verdict.Content == "" {
+			return nil, fmt.Errorf("classifier redacted without content")
+		}
+		return &verdict, nil
+	default:
+		return nil, fmt.Errorf("classifier returned unknown action %q", verdict.Action)
+	}
+}
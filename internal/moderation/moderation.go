@@ -0,0 +1,100 @@
+package moderation
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// Actions a hook can take on a message
+const (
+	ActionAllow  = "allow"
+	ActionReject = "reject"
+	ActionRedact = "redact"
+	ActionFlag   = "flag"
+)
+
+// Input is a message checked before it is saved
+type Input struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Content        string    `json:"content"`
+}
+
+// Verdict is the decision of a hook on a message
+type Verdict struct {
+	Action  string `json:"action"`
+	Reason  string `json:"reason,omitempty"`
+	Content string `json:"content,omitempty"` // Redacted content of redact verdicts
+}
+
+// Hook checks messages before they are saved
+// A nil verdict allows the message
+type Hook interface {
+	Name() string
+	Check(ctx context.Context, input *Input) (*Verdict, error)
+}
+
+// Flag records why a hook flagged a message for review
+type Flag struct {
+	Hook   string
+	Reason string
+}
+
+// Result is the outcome of running a message through the pipeline
+type Result struct {
+	Rejected   bool
+	RejectedBy string
+	Reason     string // Reason of the rejection
+	Content    string // Content to save, after redactions
+	Redacted   bool
+	Flags      []Flag
+}
+
+// Pipeline runs a chain of hooks on each message
+type Pipeline struct {
+	hooks []Hook
+}
+
+// NewPipeline creates a pipeline running the hooks in order
+func NewPipeline(hooks ...Hook) *Pipeline {
+	return &Pipeline{hooks: hooks}
+}
+
+// Run runs the hooks on a message
+// A rejection stops the chain. Redactions are seen by the following hooks, flags are collected.
+// Hooks that fail are skipped, so an unavailable classifier does not block messages.
+func (p *Pipeline) Run(ctx context.Context, input *Input) *Result {
+	result := &Result{Content: input.Content}
+	current := *input
+
+	for _, hook := range p.hooks {
+		verdict, err := hook.Check(ctx, &current)
+		if err != nil {
+			log.Printf("Moderation hook %s failed: %v", hook.Name(), err)
+			continue
+		}
+		if verdict == nil {
+			continue
+		}
+
+		switch verdict.Action {
+		case ActionReject:
+			result.Rejected = true
+			result.RejectedBy = hook.Name()
+			result.Reason = verdict.Reason
+			return result
+		case ActionRedact:
+			if verdict.Content != current.Content {
+				current.Content = verdict.Content
+				result.Content = verdict.Content
+				result.Redacted = true
+			}
+		case ActionFlag:
+			result.Flags = append(result.Flags, Flag{Hook: hook.Name(), Reason: verdict.Reason})
+		}
+	}
+
+	return result
+}
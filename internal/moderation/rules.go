@@ -0,0 +1,180 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of rules
+const (
+	RuleWord   = "word"   // Whole word or phrase, case-insensitive
+	RuleDomain = "domain" // Links to the domain or its subdomains
+	RuleRegex  = "regex"  // RE2 regular expression
+)
+
+// redactedLink replaces links to blocked domains in redacted messages
+const redactedLink = "[link removed]"
+
+// linkPattern finds links in message content
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+
+// Rule is a workspace-wide moderation rule
+type Rule struct {
+	Name    string // Identifies the rule in flags and rejections
+	Kind    string
+	Pattern string
+	Action  string // "reject", "redact" or "flag"
+	Reason  string
+}
+
+// NormalizePattern validates the pattern of a rule and returns it in canonical form:
+// words are lowercased, domains reduced to their host and regular expressions compiled
+func NormalizePattern(kind, pattern string) (string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "", errors.New("pattern is required")
+	}
+
+	switch kind {
+	case RuleWord:
+		return strings.ToLower(pattern), nil
+	case RuleDomain:
+		host := strings.ToLower(pattern)
+		if strings.Contains(host, "://") {
+			parsed, err := url.Parse(host)
+			if err != nil || parsed.Hostname() == "" {
+				return "", fmt.Errorf("invalid domain %q", pattern)
+			}
+			host = parsed.Hostname()
+		}
+		host = strings.TrimPrefix(strings.TrimSuffix(host, "/"), "www.")
+		if strings.ContainsAny(host, " /:?#@") || !strings.Contains(host, ".") {
+			return "", fmt.Errorf("invalid domain %q", pattern)
+		}
+		return host, nil
+	case RuleRegex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return pattern, nil
+	default:
+		return "", fmt.Errorf("unknown rule kind %q", kind)
+	}
+}
+
+// NewRuleHook creates the hook applying a rule
+func NewRuleHook(rule Rule) (Hook, error) {
+	pattern, err := NormalizePattern(rule.Kind, rule.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &ruleHook{rule: rule}
+	switch rule.Kind {
+	case RuleWord:
+		hook.find = wordFinder(pattern)
+		hook.replace = mask
+	case RuleDomain:
+		hook.find = domainFinder(pattern)
+		hook.replace = func(string) string { return redactedLink }
+	case RuleRegex:
+		re := regexp.MustCompile(pattern)
+		hook.find = func(content string) [][]int { return re.FindAllStringIndex(content, -1) }
+		hook.replace = mask
+	}
+
+	return hook, nil
+}
+
+// ruleHook applies a rule to the matches of its pattern
+type ruleHook struct {
+	rule    Rule
+	find    func(content string) [][]int
+	replace func(match string) string
+}
+
+func (h *ruleHook) Name() string {
+	return h.rule.Name
+}
+
+func (h *ruleHook) Check(_ context.Context, input *Input) (*Verdict, error) {
+	matches := h.find(input.Content)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	verdict := &Verdict{Action: h.rule.Action, Reason: h.rule.Reason}
+	if h.rule.Action == ActionRedact {
+		verdict.Content = replaceMatches(input.Content, matches, h.replace)
+	}
+
+	return verdict, nil
+}
+
+// wordFinder finds a word or phrase not preceded or followed by a letter or digit
+func wordFinder(word string) func(string) [][]int {
+	re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(word))
+	return func(content string) [][]int {
+		var matches [][]int
+		for _, match := range re.FindAllStringIndex(content, -1) {
+			before, _ := utf8.DecodeLastRuneInString(content[:match[0]])
+			after, _ := utf8.DecodeRuneInString(content[match[1]:])
+			if !isWordRune(before) && !isWordRune(after) {
+				matches = append(matches, match)
+			}
+		}
+		return matches
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// domainFinder finds links to a domain or its subdomains
+func domainFinder(domain string) func(string) [][]int {
+	return func(content string) [][]int {
+		var matches [][]int
+		for _, match := range linkPattern.FindAllStringIndex(content, -1) {
+			// Punctuation ending a sentence or Markdown link is not part of the link
+			link := strings.TrimRight(content[match[0]:match[1]], ".,;:!?)]}")
+			match = []int{match[0], match[0] + len(link)}
+			if strings.HasPrefix(strings.ToLower(link), "www.") {
+				link = "http://" + link
+			}
+			parsed, err := url.Parse(link)
+			if err != nil {
+				continue
+			}
+			host := strings.ToLower(parsed.Hostname())
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				matches = append(matches, match)
+			}
+		}
+		return matches
+	}
+}
+
+// mask replaces every character of a match with an asterisk
+func mask(match string) string {
+	return strings.Repeat("*", utf8.RuneCountInString(match))
+}
+
+// replaceMatches replaces non-overlapping matches given in order
+func replaceMatches(content string, matches [][]int, replace func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		b.WriteString(content[last:match[0]])
+		b.WriteString(replace(content[match[0]:match[1]]))
+		last = match[1]
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
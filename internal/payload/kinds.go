@@ -0,0 +1,141 @@
+package payload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Location is a place shared in a conversation
+var Location = &Kind{
+	Name:        "location",
+	Description: "A place on the map, with an optional name and address",
+	Schema: json.RawMessage(`{
+		"type": "object",
+		"required": ["latitude", "longitude"],
+		"additionalProperties": false,
+		"properties": {
+			"latitude": {"type": "number", "minimum": -90, "maximum": 90},
+			"longitude": {"type": "number", "minimum": -180, "maximum": 180},
+			"name": {"type": "string", "maxLength": 200},
+			"address": {"type": "string", "maxLength": 500}
+		}
+	}`),
+	New: func() Payload { return &LocationPayload{} },
+}
+
+// LocationPayload is the payload of location messages
+type LocationPayload struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Name      string   `json:"name,omitempty"`
+	Address   string   `json:"address,omitempty"`
+}
+
+func (p *LocationPayload) Validate() error {
+	if p.Latitude == nil || *p.Latitude < -90 || *p.Latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if p.Longitude == nil || *p.Longitude < -180 || *p.Longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+
+	p.Name = strings.TrimSpace(p.Name)
+	p.Address = strings.TrimSpace(p.Address)
+	if utf8.RuneCountInString(p.Name) > 200 {
+		return errors.New("name must be at most 200 characters")
+	}
+	if utf8.RuneCountInString(p.Address) > 500 {
+		return errors.New("address must be at most 500 characters")
+	}
+	return nil
+}
+
+func (p *LocationPayload) Text() string {
+	coordinates := strconv.FormatFloat(*p.Latitude, 'f', -1, 64) + ", " + strconv.FormatFloat(*p.Longitude, 'f', -1, 64)
+
+	lines := []string{"📍 " + coordinates}
+	if p.Name != "" {
+		lines[0] = fmt.Sprintf("📍 %s (%s)", p.Name, coordinates)
+	}
+	if p.Address != "" {
+		lines = append(lines, p.Address)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Contact is a contact card shared in a conversation
+var Contact = &Kind{
+	Name:        "contact",
+	Description: "A contact card with phone numbers and emails, optionally linked to a user",
+	Schema: json.RawMessage(`{
+		"type": "object",
+		"required": ["name"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 100},
+			"phones": {"type": "array", "maxItems": 5, "items": {"type": "string", "pattern": "^\\+?[0-9 ()-]{3,32}$"}},
+			"emails": {"type": "array", "maxItems": 5, "items": {"type": "string", "format": "email", "maxLength": 255}},
+			"user_id": {"type": "string", "format": "uuid"}
+		}
+	}`),
+	New: func() Payload { return &ContactPayload{} },
+}
+
+// phonePattern matches the phone numbers of contact cards
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ()-]{3,32}$`)
+
+// ContactPayload is the payload of contact messages
+type ContactPayload struct {
+	Name   string     `json:"name"`
+	Phones []string   `json:"phones,omitempty"`
+	Emails []string   `json:"emails,omitempty"`
+	UserID *uuid.UUID `json:"user_id,omitempty"` // Set when the contact is a user, so clients can open a conversation
+}
+
+func (p *ContactPayload) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(p.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+
+	if len(p.Phones) > 5 {
+		return errors.New("at most 5 phone numbers are allowed")
+	}
+	for i, phone := range p.Phones {
+		p.Phones[i] = strings.TrimSpace(phone)
+		if !phonePattern.MatchString(p.Phones[i]) {
+			return fmt.Errorf("invalid phone number %q", phone)
+		}
+	}
+
+	if len(p.Emails) > 5 {
+		return errors.New("at most 5 emails are allowed")
+	}
+	for i, email := range p.Emails {
+		p.Emails[i] = strings.TrimSpace(email)
+		address, err := mail.ParseAddress(p.Emails[i])
+		if err != nil || address.Address != p.Emails[i] || len(p.Emails[i]) > 255 {
+			return fmt.Errorf("invalid email %q", email)
+		}
+	}
+
+	return nil
+}
+
+func (p *ContactPayload) Text() string {
+	lines := []string{"👤 " + p.Name}
+	lines = append(lines, p.Phones...)
+	lines = append(lines, p.Emails...)
+	return strings.Join(lines, "\n")
+}
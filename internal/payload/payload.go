@@ -0,0 +1,145 @@
+// Package payload validates the structured payloads of typed messages, like locations or contact cards
+// Each message kind has a JSON schema published to clients, a validator and a plain-text fallback
+// stored as the content of the message, used for search, previews, notifications and older clients
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// MaxSize is the maximum size in bytes of an encoded payload
+const MaxSize = 16 << 10
+
+// namePattern matches valid kind names, which are stored in the message_type column
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// reservedNames are the message types handled without a payload
+var reservedNames = map[string]bool{
+	"text":    true,
+	"image":   true,
+	"file":    true,
+	"system":  true,
+	"poll":    true,
+	"voice":   true,
+	"snippet": true,
+}
+
+// ErrInvalidName is returned when registering a kind with an invalid or reserved name
+var ErrInvalidName = errors.New("kind names must be 1 to 20 lowercase letters, digits or underscores and not a built-in message type")
+
+// ErrDuplicate is returned when registering a kind twice
+var ErrDuplicate = errors.New("kind is already registered")
+
+// Payload is the decoded payload of a message
+type Payload interface {
+	// Validate checks the fields and normalizes them, like trimming spaces
+	Validate() error
+	// Text describes the payload in plain text
+	Text() string
+}
+
+// Kind is a message type with a structured payload
+type Kind struct {
+	Name        string
+	Description string
+	Schema      json.RawMessage // JSON Schema of the payload
+	New         func() Payload  // Creates an empty payload to decode into
+}
+
+// Decode parses and validates a payload of the kind
+// Returns the payload with its normalized encoding, unknown fields are rejected
+func (k *Kind) Decode(raw json.RawMessage) (Payload, json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil, fmt.Errorf("%s messages require a payload", k.Name)
+	}
+	if len(raw) > MaxSize {
+		return nil, nil, fmt.Errorf("payload must be at most %d bytes", MaxSize)
+	}
+
+	p := k.New()
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(p); err != nil {
+		return nil, nil, fmt.Errorf("malformed %s payload: %v", k.Name, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, nil, fmt.Errorf("malformed %s payload: unexpected data after the payload", k.Name)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	normalized, err := json.Marshal(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, normalized, nil
+}
+
+// Registry holds the message kinds by name
+type Registry struct {
+	mu    sync.RWMutex
+	kinds map[string]*Kind
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{kinds: make(map[string]*Kind)}
+}
+
+// Register adds a message kind to the registry
+func (r *Registry) Register(kind *Kind) error {
+	if !namePattern.MatchString(kind.Name) || reservedNames[kind.Name] {
+		return ErrInvalidName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.kinds[kind.Name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicate, kind.Name)
+	}
+	r.kinds[kind.Name] = kind
+	return nil
+}
+
+// MustRegister adds message kinds to the registry, it panics on invalid or duplicate names
+func (r *Registry) MustRegister(kinds ...*Kind) {
+	for _, kind := range kinds {
+		if err := r.Register(kind); err != nil {
+			panic(fmt.Sprintf("failed to register message kind %s: %v", kind.Name, err))
+		}
+	}
+}
+
+// Lookup gets a message kind by name
+func (r *Registry) Lookup(name string) (*Kind, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kind, ok := r.kinds[name]
+	return kind, ok
+}
+
+// Kinds returns the registered kinds ordered by name
+func (r *Registry) Kinds() []*Kind {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kinds := make([]*Kind, 0, len(r.kinds))
+	for _, kind := range r.kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Name < kinds[j].Name })
+	return kinds
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// attachmentColumns is the column list used when selecting attachments
+const attachmentColumns = `id, conversation_id, message_id, uploader_id, file_name, content_type, size_bytes, storage_key, created_at,
+	width, height, blurhash, thumbnails, duration_ms, waveform`
+
+type AttachmentRepository struct {
+	db *database.DB
+}
+
+func NewAttachmentRepository(db *database.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// scanAttachment scans a row selected with attachmentColumns into an attachment
+func scanAttachment(scanner rowScanner) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	var thumbnails, waveform []byte
+	err := scanner.Scan(
+		&attachment.ID,
+		&attachment.ConversationID,
+		&attachment.MessageID,
+		&attachment.UploaderID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.SizeBytes,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+		&attachment.Width,
+		&attachment.Height,
+		&attachment.BlurHash,
+		&thumbnails,
+		&attachment.DurationMs,
+		&waveform,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(thumbnails, &attachment.Thumbnails); err != nil {
+		return nil, err
+	}
+
+	if waveform != nil {
+		if err := json.Unmarshal(waveform, &attachment.Waveform); err != nil {
+			return nil, err
+		}
+	}
+
+	return attachment, nil
+}
+
+// CreateAttachment creates a new attachment
+// The ID is kept if already set, so the storage key can be derived from it before insertion
+func (r *AttachmentRepository) CreateAttachment(attachment *models.Attachment) error {
+	query := `
+		INSERT INTO attachments (` + attachmentColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	if attachment.ID == uuid.Nil {
+		attachment.ID = uuid.New()
+	}
+	attachment.CreatedAt = time.Now()
+
+	thumbnails := attachment.Thumbnails
+	if thumbnails == nil {
+		thumbnails = []models.AttachmentThumbnail{}
+	}
+	thumbnailsJSON, err := json.Marshal(thumbnails)
+	if err != nil {
+		return err
+	}
+
+	var waveformJSON []byte
+	if attachment.Waveform != nil {
+		if waveformJSON, err = json.Marshal(attachment.Waveform); err != nil {
+			return err
+		}
+	}
+
+	_, err = r.db.Exec(query,
+		attachment.ID,
+		attachment.ConversationID,
+		attachment.MessageID,
+		attachment.UploaderID,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.SizeBytes,
+		attachment.StorageKey,
+		attachment.CreatedAt,
+		attachment.Width,
+		attachment.Height,
+		attachment.BlurHash,
+		thumbnailsJSON,
+		attachment.DurationMs,
+		waveformJSON,
+	)
+
+	return err
+}
+
+// GetAttachmentByID gets an attachment by ID
+func (r *AttachmentRepository) GetAttachmentByID(id uuid.UUID) (*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1`
+	return scanAttachment(r.db.QueryRow(query, id))
+}
+
+// GetAttachmentsByIDs gets several attachments by ID
+func (r *AttachmentRepository) GetAttachmentsByIDs(ids []uuid.UUID) ([]*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = ANY($1::uuid[])`
+
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// ErrAttachmentsNotLinked is returned when attachments of a new message were sent with another message meanwhile
+var ErrAttachmentsNotLinked = errors.New("attachments are no longer unsent uploads of the sender")
+
+// linkAttachments links unsent attachments of an uploader to a message in a transaction, see CreateMessageWithAttachments
+// Returns the number of attachments that were linked
+func linkAttachments(tx *sql.Tx, messageID, conversationID, uploaderID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	query := `
+		UPDATE attachments
+		SET message_id = $1
+		WHERE id = ANY($2::uuid[]) AND conversation_id = $3 AND uploader_id = $4 AND message_id IS NULL
+	`
+
+	result, err := tx.Exec(query, messageID, pq.Array(uuidStrings(ids)), conversationID, uploaderID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// copyAttachments copies the attachments of a message to another message in a transaction, see CreateForwardedMessages
+// The copies share the stored files of the originals, so nothing is uploaded again
+func copyAttachments(tx *sql.Tx, sourceMessageID, targetMessageID, targetConversationID, uploaderID uuid.UUID) ([]*models.Attachment, error) {
+	query := `
+		INSERT INTO attachments (` + attachmentColumns + `)
+		SELECT uuid_generate_v4(), $2, $3, $4, file_name, content_type, size_bytes, storage_key, NOW(),
+		       width, height, blurhash, thumbnails, duration_ms, waveform
+		FROM attachments
+		WHERE message_id = $1
+		ORDER BY created_at ASC
+		RETURNING ` + attachmentColumns
+
+	rows, err := tx.Query(query, sourceMessageID, targetConversationID, targetMessageID, uploaderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// GetAttachmentsByMessageIDs gets the attachments of several messages in a single query
+func (r *AttachmentRepository) GetAttachmentsByMessageIDs(messageIDs []uuid.UUID) (map[uuid.UUID][]*models.Attachment, error) {
+	attachments := make(map[uuid.UUID][]*models.Attachment)
+	if len(messageIDs) == 0 {
+		return attachments, nil
+	}
+
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE message_id = ANY($1::uuid[])
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(messageIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments[*attachment.MessageID] = append(attachments[*attachment.MessageID], attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// IsStorageKeyReferenced checks if any attachment still uses a stored file
+// Forwarded attachments share the files of their originals
+func (r *AttachmentRepository) IsStorageKeyReferenced(storageKey string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM attachments WHERE storage_key = $1)`
+
+	var exists bool
+	err := r.db.QueryRow(query, storageKey).Scan(&exists)
+	return exists, err
+}
+
+// uuidStrings converts UUIDs to strings for use with pq.Array
+func uuidStrings(ids []uuid.UUID) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.String())
+	}
+	return result
+}
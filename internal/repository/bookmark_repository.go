@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type BookmarkRepository struct {
+	db *database.DB
+}
+
+func NewBookmarkRepository(db *database.DB) *BookmarkRepository {
+	return &BookmarkRepository{db: db}
+}
+
+// SaveBookmark bookmarks a message for a user, or updates the note of an existing bookmark
+// Returns false if the bookmark already existed, the bookmark then holds the stored ID and time
+func (r *BookmarkRepository) SaveBookmark(bookmark *models.Bookmark) (bool, error) {
+	query := `
+		INSERT INTO message_bookmarks (id, user_id, message_id, conversation_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, message_id) DO UPDATE SET note = EXCLUDED.note
+		RETURNING id, created_at, (xmax = 0) AS inserted
+	`
+
+	var created bool
+	err := r.db.QueryRow(query,
+		uuid.New(),
+		bookmark.UserID,
+		bookmark.MessageID,
+		bookmark.ConversationID,
+		bookmark.Note,
+		time.Now(),
+	).Scan(&bookmark.ID, &bookmark.CreatedAt, &created)
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// DeleteBookmark removes the bookmark of a user on a message
+// Returns false if the message was not bookmarked
+func (r *BookmarkRepository) DeleteBookmark(userID, conversationID, messageID uuid.UUID) (bool, error) {
+	query := `DELETE FROM message_bookmarks WHERE user_id = $1 AND conversation_id = $2 AND message_id = $3`
+
+	result, err := r.db.Exec(query, userID, conversationID, messageID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetBookmarks gets a page of the bookmarks of a user with their messages, most recently bookmarked first
+// Bookmarks in conversations the user left and on expired messages are left out.
+// Pages continue before the (created_at, id) of the last bookmark of the previous page, nil for the first page
+func (r *BookmarkRepository) GetBookmarks(userID uuid.UUID, beforeCreatedAt *time.Time, beforeID uuid.UUID, limit int) ([]*models.Bookmark, []*models.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `, b.id, b.note, b.created_at
+		FROM message_bookmarks b
+		JOIN messages m ON m.id = b.message_id
+		JOIN users u ON m.sender_id = u.id
+		JOIN conversation_participants cp ON cp.conversation_id = b.conversation_id AND cp.user_id = b.user_id
+		WHERE b.user_id = $1 AND ` + notExpired + `
+		  AND ($2::timestamptz IS NULL OR (b.created_at, b.id) < ($2, $3))
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(query, userID, beforeCreatedAt, beforeID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var bookmarks []*models.Bookmark
+	var messages []*models.Message
+	for rows.Next() {
+		bookmark := &models.Bookmark{UserID: userID}
+		message, err := scanMessage(rows, &bookmark.ID, &bookmark.Note, &bookmark.CreatedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		bookmark.MessageID = message.ID
+		bookmark.ConversationID = message.ConversationID
+		bookmarks = append(bookmarks, bookmark)
+		messages = append(messages, message)
+	}
+
+	return bookmarks, messages, rows.Err()
+}
+
+// GetBookmarksByMessageIDs gets the bookmarks of a user on several messages in a single query
+func (r *BookmarkRepository) GetBookmarksByMessageIDs(userID uuid.UUID, messageIDs []uuid.UUID) (map[uuid.UUID]*models.Bookmark, error) {
+	bookmarks := make(map[uuid.UUID]*models.Bookmark)
+	if len(messageIDs) == 0 {
+		return bookmarks, nil
+	}
+
+	query := `
+		SELECT id, user_id, message_id, conversation_id, note, created_at
+		FROM message_bookmarks
+		WHERE user_id = $1 AND message_id = ANY($2::uuid[])
+	`
+
+	rows, err := r.db.Query(query, userID, pq.Array(uuidStrings(messageIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		bookmark := &models.Bookmark{}
+		err := rows.Scan(
+			&bookmark.ID,
+			&bookmark.UserID,
+			&bookmark.MessageID,
+			&bookmark.ConversationID,
+			&bookmark.Note,
+			&bookmark.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks[bookmark.MessageID] = bookmark
+	}
+
+	return bookmarks, rows.Err()
+}
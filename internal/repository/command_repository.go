@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type CommandRepository struct {
+	db *database.DB
+}
+
+func NewCommandRepository(db *database.DB) *CommandRepository {
+	return &CommandRepository{db: db}
+}
+
+// CreateCommand registers a third-party slash command
+// Returns false if a command with the same name exists
+func (r *CommandRepository) CreateCommand(command *models.SlashCommand) (bool, error) {
+	query := `
+		INSERT INTO slash_commands (id, name, url, usage, description, secret, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name) DO NOTHING
+	`
+
+	command.ID = uuid.New()
+	command.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(query,
+		command.ID,
+		command.Name,
+		command.URL,
+		command.Usage,
+		command.Description,
+		command.Secret,
+		command.CreatedBy,
+		command.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetCommands gets all third-party slash commands ordered by name
+func (r *CommandRepository) GetCommands() ([]*models.SlashCommand, error) {
+	query := `
+		SELECT id, name, url, usage, description, secret, created_by, created_at
+		FROM slash_commands
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commands []*models.SlashCommand
+	for rows.Next() {
+		command := &models.SlashCommand{}
+		err := rows.Scan(
+			&command.ID,
+			&command.Name,
+			&command.URL,
+			&command.Usage,
+			&command.Description,
+			&command.Secret,
+			&command.CreatedBy,
+			&command.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, rows.Err()
+}
+
+// DeleteCommand deletes a third-party slash command by name
+// Returns false if the command does not exist
+func (r *CommandRepository) DeleteCommand(name string) (bool, error) {
+	query := `DELETE FROM slash_commands WHERE name = $1`
+
+	result, err := r.db.Exec(query, name)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
@@ -43,7 +43,7 @@ func (r *ConversationRepository) CreateConversation(conversation *models.Convers
 // GetConversationByID gets a conversation by ID
 func (r *ConversationRepository) GetConversationByID(id uuid.UUID) (*models.Conversation, error) {
 	query := `
-		SELECT id, name, type, created_by, created_at, updated_at
+		SELECT id, name, type, topic, created_by, created_at, updated_at, message_ttl_seconds
 		FROM conversations
 		WHERE id = $1
 	`
@@ -53,9 +53,11 @@ func (r *ConversationRepository) GetConversationByID(id uuid.UUID) (*models.Conv
 		&conversation.ID,
 		&conversation.Name,
 		&conversation.Type,
+		&conversation.Topic,
 		&conversation.CreatedBy,
 		&conversation.CreatedAt,
 		&conversation.UpdatedAt,
+		&conversation.MessageTTLSeconds,
 	)
 
 	if err != nil {
@@ -68,7 +70,7 @@ func (r *ConversationRepository) GetConversationByID(id uuid.UUID) (*models.Conv
 // GetConversationsByUserID gets all conversations for a user
 func (r *ConversationRepository) GetConversationsByUserID(userID uuid.UUID) ([]*models.Conversation, error) {
 	query := `
-		SELECT DISTINCT c.id, c.name, c.type, c.created_by, c.created_at, c.updated_at
+		SELECT DISTINCT c.id, c.name, c.type, c.topic, c.created_by, c.created_at, c.updated_at, c.message_ttl_seconds
 		FROM conversations c
 		JOIN conversation_participants cp ON c.id = cp.conversation_id
 		WHERE cp.user_id = $1
@@ -88,9 +90,11 @@ func (r *ConversationRepository) GetConversationsByUserID(userID uuid.UUID) ([]*
 			&conversation.ID,
 			&conversation.Name,
 			&conversation.Type,
+			&conversation.Topic,
 			&conversation.CreatedBy,
 			&conversation.CreatedAt,
 			&conversation.UpdatedAt,
+			&conversation.MessageTTLSeconds,
 		)
 		if err != nil {
 			return nil, err
@@ -120,6 +124,31 @@ func (r *ConversationRepository) UpdateConversation(conversation *models.Convers
 	return err
 }
 
+// UpdateMessageTTL sets the disappearing messages TTL of a conversation, nil turns it off
+// Messages already sent keep their expiry
+func (r *ConversationRepository) UpdateMessageTTL(id uuid.UUID, ttlSeconds *int) error {
+	query := `
+		UPDATE conversations
+		SET message_ttl_seconds = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(query, id, ttlSeconds, time.Now())
+	return err
+}
+
+// UpdateTopic sets the topic of a conversation, an empty topic clears it
+func (r *ConversationRepository) UpdateTopic(id uuid.UUID, topic string) error {
+	query := `
+		UPDATE conversations
+		SET topic = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(query, id, topic, time.Now())
+	return err
+}
+
 // DeleteConversation deletes a conversation
 func (r *ConversationRepository) DeleteConversation(id uuid.UUID) error {
 	query := `DELETE FROM conversations WHERE id = $1`
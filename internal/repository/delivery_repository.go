@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// recipientJoin joins the participants a message m was sent to: everyone but the sender who was
+// tracked when it was sent
+const recipientJoin = `conversation_participants p ON p.conversation_id = m.conversation_id
+	AND p.user_id <> m.sender_id AND p.receipts_from <= m.created_at`
+
+type DeliveryRepository struct {
+	db *database.DB
+}
+
+func NewDeliveryRepository(db *database.DB) *DeliveryRepository {
+	return &DeliveryRepository{db: db}
+}
+
+// MarkDelivered moves the delivered cursor of a participant forward to upTo
+// Returns the previous cursor, and false if the cursor was already at or past upTo
+func (r *DeliveryRepository) MarkDelivered(conversationID, userID uuid.UUID, upTo time.Time) (time.Time, bool, error) {
+	query := `
+		WITH previous AS (
+			SELECT id, last_delivered_at AS cursor
+			FROM conversation_participants
+			WHERE conversation_id = $1 AND user_id = $2
+			FOR UPDATE
+		)
+		UPDATE conversation_participants p
+		SET last_delivered_at = $3
+		FROM previous
+		WHERE p.id = previous.id AND previous.cursor < $3
+		RETURNING previous.cursor
+	`
+
+	return r.advanceCursor(query, conversationID, userID, upTo)
+}
+
+// MarkRead moves the read cursor of a participant forward to upTo, and the delivered cursor with it
+// Returns the previous read cursor, and false if the cursor was already at or past upTo
+func (r *DeliveryRepository) MarkRead(conversationID, userID uuid.UUID, upTo time.Time) (time.Time, bool, error) {
+	query := `
+		WITH previous AS (
+			SELECT id, last_read_at AS cursor
+			FROM conversation_participants
+			WHERE conversation_id = $1 AND user_id = $2
+			FOR UPDATE
+		)
+		UPDATE conversation_participants p
+		SET last_read_at = $3, last_delivered_at = GREATEST(p.last_delivered_at, $3)
+		FROM previous
+		WHERE p.id = previous.id AND previous.cursor < $3
+		RETURNING previous.cursor
+	`
+
+	return r.advanceCursor(query, conversationID, userID, upTo)
+}
+
+// advanceCursor runs a cursor update returning the previous cursor
+func (r *DeliveryRepository) advanceCursor(query string, conversationID, userID uuid.UUID, upTo time.Time) (time.Time, bool, error) {
+	var previous time.Time
+	err := r.db.QueryRow(query, conversationID, userID, upTo).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, err
+	}
+
+	return previous, true, nil
+}
+
+// GetMessagesPassed gets the messages a recipient's cursor passed when it moved from after to upTo,
+// newest first and at most limit, keyed by sender
+// System messages are left out, they have no delivery state
+func (r *DeliveryRepository) GetMessagesPassed(conversationID, recipientID uuid.UUID, after, upTo time.Time, limit int) (map[uuid.UUID][]uuid.UUID, error) {
+	query := `
+		SELECT m.id, m.sender_id
+		FROM messages m
+		JOIN ` + recipientJoin + `
+		WHERE m.conversation_id = $1 AND p.user_id = $2
+			AND m.created_at > $3 AND m.created_at <= $4
+			AND m.message_type <> 'system' AND ` + notExpired + `
+		ORDER BY m.created_at DESC
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(query, conversationID, recipientID, after, upTo, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make(map[uuid.UUID][]uuid.UUID)
+	for rows.Next() {
+		var messageID, senderID uuid.UUID
+		if err := rows.Scan(&messageID, &senderID); err != nil {
+			return nil, err
+		}
+		messages[senderID] = append(messages[senderID], messageID)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetDeliveryStates gets the aggregated delivery state of several messages, keyed by message ID
+func (r *DeliveryRepository) GetDeliveryStates(messageIDs []uuid.UUID) (map[uuid.UUID]*models.DeliveryState, error) {
+	states := make(map[uuid.UUID]*models.DeliveryState)
+	if len(messageIDs) == 0 {
+		return states, nil
+	}
+
+	query := `
+		SELECT m.id,
+		       COUNT(p.id),
+		       COUNT(p.id) FILTER (WHERE p.last_delivered_at >= m.created_at),
+		       COUNT(p.id) FILTER (WHERE p.last_read_at >= m.created_at)
+		FROM messages m
+		LEFT JOIN ` + recipientJoin + `
+		WHERE m.id = ANY($1::uuid[])
+		GROUP BY m.id
+	`
+
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(messageIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID uuid.UUID
+		state := &models.DeliveryState{}
+		if err := rows.Scan(&messageID, &state.RecipientCount, &state.DeliveredCount, &state.ReadCount); err != nil {
+			return nil, err
+		}
+		state.SetStatus()
+		states[messageID] = state
+	}
+
+	return states, rows.Err()
+}
+
+// GetReceipts gets the delivery state of a message for each of its recipients
+func (r *DeliveryRepository) GetReceipts(messageID uuid.UUID) ([]*models.MessageReceipt, error) {
+	query := `
+		SELECT p.user_id, u.display_name,
+		       p.last_delivered_at >= m.created_at,
+		       p.last_read_at >= m.created_at
+		FROM messages m
+		JOIN ` + recipientJoin + `
+		JOIN users u ON u.id = p.user_id
+		WHERE m.id = $1
+		ORDER BY u.display_name ASC
+	`
+
+	rows, err := r.db.Query(query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := []*models.MessageReceipt{}
+	for rows.Next() {
+		var delivered, read bool
+		receipt := &models.MessageReceipt{Status: models.DeliverySent}
+		if err := rows.Scan(&receipt.UserID, &receipt.DisplayName, &delivered, &read); err != nil {
+			return nil, err
+		}
+		if read {
+			receipt.Status = models.DeliveryRead
+		} else if delivered {
+			receipt.Status = models.DeliveryDelivered
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, rows.Err()
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type DraftRepository struct {
+	db *database.DB
+}
+
+func NewDraftRepository(db *database.DB) *DraftRepository {
+	return &DraftRepository{db: db}
+}
+
+// SaveDraft saves a draft unless the stored draft is at least as recent
+// Returns false if the draft was not saved
+func (r *DraftRepository) SaveDraft(draft *models.MessageDraft) (bool, error) {
+	query := `
+		INSERT INTO message_drafts (user_id, conversation_id, content, format, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, conversation_id) DO UPDATE
+		SET content = EXCLUDED.content, format = EXCLUDED.format, updated_at = EXCLUDED.updated_at
+		WHERE message_drafts.updated_at < EXCLUDED.updated_at
+	`
+
+	result, err := r.db.Exec(query,
+		draft.UserID,
+		draft.ConversationID,
+		draft.Content,
+		draft.Format,
+		draft.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetDraft gets the stored draft of a user in a conversation, including cleared drafts
+// Returns sql.ErrNoRows if the user never saved one
+func (r *DraftRepository) GetDraft(userID, conversationID uuid.UUID) (*models.MessageDraft, error) {
+	query := `
+		SELECT user_id, conversation_id, content, format, updated_at
+		FROM message_drafts
+		WHERE user_id = $1 AND conversation_id = $2
+	`
+
+	draft := &models.MessageDraft{}
+	err := r.db.QueryRow(query, userID, conversationID).Scan(
+		&draft.UserID,
+		&draft.ConversationID,
+		&draft.Content,
+		&draft.Format,
+		&draft.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return draft, nil
+}
+
+// ClearDraft clears the draft of a user in a conversation if it was last updated before clearedAt
+// Returns false if there was no draft to clear
+func (r *DraftRepository) ClearDraft(userID, conversationID uuid.UUID, clearedAt time.Time) (bool, error) {
+	query := `
+		UPDATE message_drafts
+		SET content = '', format = 'plain', updated_at = $3
+		WHERE user_id = $1 AND conversation_id = $2 AND content <> '' AND updated_at <= $3
+	`
+
+	result, err := r.db.Exec(query, userID, conversationID, clearedAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
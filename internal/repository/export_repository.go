@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// exportColumns is the column list used when selecting conversation exports
+const exportColumns = `
+		id, conversation_id, requested_by, format, status, claimed_at, storage_key, file_name,
+		size_bytes, message_count, error, created_at, completed_at, expires_at`
+
+type ExportRepository struct {
+	db *database.DB
+}
+
+func NewExportRepository(db *database.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+func scanExport(scanner rowScanner) (*models.ConversationExport, error) {
+	export := &models.ConversationExport{}
+	err := scanner.Scan(
+		&export.ID,
+		&export.ConversationID,
+		&export.RequestedBy,
+		&export.Format,
+		&export.Status,
+		&export.ClaimedAt,
+		&export.StorageKey,
+		&export.FileName,
+		&export.SizeBytes,
+		&export.MessageCount,
+		&export.Error,
+		&export.CreatedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func scanExports(rows *sql.Rows) ([]*models.ConversationExport, error) {
+	defer rows.Close()
+
+	var exports []*models.ConversationExport
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
+
+// CreateExport creates an export request
+// Exports created as running are claimed by the caller, pending ones are left to the export worker
+func (r *ExportRepository) CreateExport(export *models.ConversationExport) error {
+	query := `
+		INSERT INTO conversation_exports (id, conversation_id, requested_by, format, status, claimed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	export.ID = uuid.New()
+	export.CreatedAt = time.Now()
+	if export.Status == "running" {
+		export.ClaimedAt = &export.CreatedAt
+	}
+
+	_, err := r.db.Exec(query,
+		export.ID,
+		export.ConversationID,
+		export.RequestedBy,
+		export.Format,
+		export.Status,
+		export.ClaimedAt,
+		export.CreatedAt,
+	)
+
+	return err
+}
+
+// GetExportByID gets an export by ID
+func (r *ExportRepository) GetExportByID(id uuid.UUID) (*models.ConversationExport, error) {
+	query := `SELECT ` + exportColumns + ` FROM conversation_exports WHERE id = $1`
+	return scanExport(r.db.QueryRow(query, id))
+}
+
+// ClaimExports claims up to limit pending exports, oldest first
+// Running exports claimed before staleBefore belong to a stopped instance and are claimed again,
+// rows locked by another instance are skipped
+func (r *ExportRepository) ClaimExports(limit int, staleBefore time.Time) ([]*models.ConversationExport, error) {
+	query := `
+		UPDATE conversation_exports
+		SET status = 'running', claimed_at = NOW()
+		WHERE id IN (
+			SELECT id FROM conversation_exports
+			WHERE status = 'pending' OR (status = 'running' AND claimed_at < $2)
+			ORDER BY created_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + exportColumns
+
+	rows, err := r.db.Query(query, limit, staleBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanExports(rows)
+}
+
+// MarkExportCompleted records the file of a finished export
+func (r *ExportRepository) MarkExportCompleted(export *models.ConversationExport) error {
+	query := `
+		UPDATE conversation_exports
+		SET status = 'completed', storage_key = $2, file_name = $3, size_bytes = $4, message_count = $5,
+		    completed_at = $6, expires_at = $7
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(query,
+		export.ID,
+		export.StorageKey,
+		export.FileName,
+		export.SizeBytes,
+		export.MessageCount,
+		export.CompletedAt,
+		export.ExpiresAt,
+	)
+
+	return err
+}
+
+// MarkExportFailed records why an export could not be produced
+func (r *ExportRepository) MarkExportFailed(id uuid.UUID, reason string) error {
+	query := `UPDATE conversation_exports SET status = 'failed', error = $2, completed_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(query, id, reason)
+	return err
+}
+
+// ExpireExports unsets the files of up to limit exports past their expiry and returns their storage keys
+// The rows are kept so requests for expired exports can be told apart from unknown ones
+func (r *ExportRepository) ExpireExports(limit int) ([]string, error) {
+	query := `
+		UPDATE conversation_exports e
+		SET storage_key = NULL
+		FROM (
+			SELECT id, storage_key FROM conversation_exports
+			WHERE storage_key IS NOT NULL AND expires_at <= NOW()
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) expired
+		WHERE e.id = expired.id
+		RETURNING expired.storage_key
+	`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
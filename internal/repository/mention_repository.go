@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type MentionRepository struct {
+	db *database.DB
+}
+
+func NewMentionRepository(db *database.DB) *MentionRepository {
+	return &MentionRepository{db: db}
+}
+
+// CreateMentions stores the mentions of a message in a single transaction
+func (r *MentionRepository) CreateMentions(mentions []*models.MessageMention) error {
+	query := `
+		INSERT INTO message_mentions (id, message_id, conversation_id, mention_type, user_id, start_offset, length, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, mention := range mentions {
+		mention.ID = uuid.New()
+
+		_, err := tx.Exec(query,
+			mention.ID,
+			mention.MessageID,
+			mention.ConversationID,
+			mention.Type,
+			mention.UserID,
+			mention.Offset,
+			mention.Length,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMentionsByMessageIDs gets the mentions of several messages in a single query, in content order
+func (r *MentionRepository) GetMentionsByMessageIDs(messageIDs []uuid.UUID) (map[uuid.UUID][]models.MessageMention, error) {
+	mentions := make(map[uuid.UUID][]models.MessageMention)
+	if len(messageIDs) == 0 {
+		return mentions, nil
+	}
+
+	query := `
+		SELECT id, message_id, conversation_id, mention_type, user_id, start_offset, length
+		FROM message_mentions
+		WHERE message_id = ANY($1::uuid[])
+		ORDER BY start_offset ASC
+	`
+
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(messageIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mention models.MessageMention
+		err := rows.Scan(
+			&mention.ID,
+			&mention.MessageID,
+			&mention.ConversationID,
+			&mention.Type,
+			&mention.UserID,
+			&mention.Offset,
+			&mention.Length,
+		)
+		if err != nil {
+			return nil, err
+		}
+		mentions[mention.MessageID] = append(mentions[mention.MessageID], mention)
+	}
+
+	return mentions, rows.Err()
+}
+
+// GetMentioningMessages gets the messages mentioning a user, newest first
+// @everyone and @here mentions count for every participant of the conversation except the sender
+func (r *MentionRepository) GetMentioningMessages(userID uuid.UUID, limit, offset int) ([]*models.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		JOIN users u ON m.sender_id = u.id
+		JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id AND cp.user_id = $1
+		WHERE m.sender_id <> $1 AND ` + notExpired + ` AND EXISTS (
+			SELECT 1 FROM message_mentions mm
+			WHERE mm.message_id = m.id AND (mm.user_id = $1 OR mm.user_id IS NULL)
+		)
+		ORDER BY m.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
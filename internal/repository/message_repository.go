@@ -1,14 +1,31 @@
 package repository
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"goswift/internal/database"
 	"goswift/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// messageColumns is the column list used when selecting messages joined with their sender
+const messageColumns = `
+		m.id, m.conversation_id, m.sender_id, m.content, m.message_type, m.is_read, m.created_at, m.updated_at,
+		m.reply_to_id, m.thread_root_id, m.reply_count, m.last_reply_at, m.last_reply_by,
+		m.forwarded_from_message_id, m.forwarded_from_sender_id, m.forwarded_from_sender_name, m.forwarded_from_created_at,
+		m.expires_at, m.client_msg_id, m.rich_content, m.system_event, m.edited_at, m.payload,
+		u.display_name as sender_name`
+
+// notExpired filters out disappearing messages that expired but were not purged yet
+const notExpired = `(m.expires_at IS NULL OR m.expires_at > NOW())`
+
 type MessageRepository struct {
 	db *database.DB
 }
@@ -17,13 +34,148 @@ func NewMessageRepository(db *database.DB) *MessageRepository {
 	return &MessageRepository{db: db}
 }
 
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMessage scans a row selected with messageColumns into a message
+// Extra destinations are scanned from the columns following messageColumns
+func scanMessage(scanner rowScanner, extra ...interface{}) (*models.Message, error) {
+	message := &models.Message{}
+	var richContent, systemEvent, payload []byte
+	dest := []interface{}{
+		&message.ID,
+		&message.ConversationID,
+		&message.SenderID,
+		&message.Content,
+		&message.MessageType,
+		&message.IsRead,
+		&message.CreatedAt,
+		&message.UpdatedAt,
+		&message.ReplyToID,
+		&message.ThreadRootID,
+		&message.ReplyCount,
+		&message.LastReplyAt,
+		&message.LastReplyBy,
+		&message.ForwardedFromMessageID,
+		&message.ForwardedFromSenderID,
+		&message.ForwardedFromSenderName,
+		&message.ForwardedFromCreatedAt,
+		&message.ExpiresAt,
+		&message.ClientMsgID,
+		&richContent,
+		&systemEvent,
+		&message.EditedAt,
+		&payload,
+		&message.SenderName,
+	}
+
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if richContent != nil {
+		if err := json.Unmarshal(richContent, &message.RichContent); err != nil {
+			return nil, err
+		}
+	}
+
+	if systemEvent != nil {
+		if err := json.Unmarshal(systemEvent, &message.SystemEvent); err != nil {
+			return nil, err
+		}
+	}
+
+	if payload != nil {
+		message.Payload = json.RawMessage(payload)
+	}
+
+	return message, nil
+}
+
 // CreateMessage creates a new message
-func (r *MessageRepository) CreateMessage(message *models.Message) error {
+// If the message is a thread reply, the thread root's reply count and last reply info are updated.
+// Returns false without creating anything if the sender already sent a message with the same client message ID
+func (r *MessageRepository) CreateMessage(message *models.Message) (bool, error) {
+	return r.CreateMessageWithAttachments(message, nil)
+}
+
+// CreateMessageWithAttachments creates a message and links unsent attachments of its sender to it in a single transaction
+// Nothing is created and ErrAttachmentsNotLinked is returned if an attachment was sent with another message meanwhile
+func (r *MessageRepository) CreateMessageWithAttachments(message *models.Message, attachmentIDs []uuid.UUID) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	created, err := insertMessage(tx, message)
+	if err != nil || !created {
+		return false, err
+	}
+
+	if len(attachmentIDs) > 0 {
+		linked, err := linkAttachments(tx, message.ID, message.ConversationID, message.SenderID, attachmentIDs)
+		if err != nil {
+			return false, err
+		}
+		if linked != int64(len(attachmentIDs)) {
+			return false, ErrAttachmentsNotLinked
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// ForwardedMessage is a copy of a message to create with CreateForwardedMessages
+type ForwardedMessage struct {
+	SourceID    uuid.UUID
+	Message     *models.Message
+	Attachments []*models.Attachment // Copies of the source attachments, set once created
+	Snippet     *models.Snippet      // Copy of the source snippet, set once created
+}
+
+// CreateForwardedMessages creates copies of messages with copies of their attachments and snippets in a single transaction
+// The copies share the stored files of the originals, so nothing is uploaded again
+func (r *MessageRepository) CreateForwardedMessages(forwards []*ForwardedMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, forward := range forwards {
+		if _, err := insertMessage(tx, forward.Message); err != nil {
+			return err
+		}
+
+		forward.Attachments, err = copyAttachments(tx, forward.SourceID, forward.Message.ID, forward.Message.ConversationID, forward.Message.SenderID)
+		if err != nil {
+			return err
+		}
+
+		if forward.Message.MessageType == "snippet" {
+			if forward.Snippet, err = copySnippet(tx, forward.SourceID, forward.Message.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertMessage inserts a message in a transaction, see CreateMessage
+func insertMessage(tx *sql.Tx, message *models.Message) (bool, error) {
 	query := `
-		INSERT INTO messages (id, conversation_id, sender_id, content, message_type, is_read, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO messages (id, conversation_id, sender_id, content, message_type, is_read, created_at, updated_at,
+		                      reply_to_id, thread_root_id,
+		                      forwarded_from_message_id, forwarded_from_sender_id, forwarded_from_sender_name, forwarded_from_created_at,
+		                      expires_at, client_msg_id, rich_content, system_event, edited_at, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		ON CONFLICT (sender_id, client_msg_id) WHERE client_msg_id IS NOT NULL DO NOTHING
 	`
-	
+
 	message.ID = uuid.New()
 	// Use provided timestamp or current time if not set
 	if message.CreatedAt.IsZero() {
@@ -32,8 +184,26 @@ func (r *MessageRepository) CreateMessage(message *models.Message) error {
 	if message.UpdatedAt.IsZero() {
 		message.UpdatedAt = time.Now()
 	}
-	
-	_, err := r.db.Exec(query,
+
+	var richContent []byte
+	if message.RichContent != nil {
+		encoded, err := json.Marshal(message.RichContent)
+		if err != nil {
+			return false, err
+		}
+		richContent = encoded
+	}
+
+	var systemEvent []byte
+	if message.SystemEvent != nil {
+		encoded, err := json.Marshal(message.SystemEvent)
+		if err != nil {
+			return false, err
+		}
+		systemEvent = encoded
+	}
+
+	result, err := tx.Exec(query,
 		message.ID,
 		message.ConversationID,
 		message.SenderID,
@@ -42,80 +212,167 @@ func (r *MessageRepository) CreateMessage(message *models.Message) error {
 		message.IsRead,
 		message.CreatedAt,
 		message.UpdatedAt,
+		message.ReplyToID,
+		message.ThreadRootID,
+		message.ForwardedFromMessageID,
+		message.ForwardedFromSenderID,
+		message.ForwardedFromSenderName,
+		message.ForwardedFromCreatedAt,
+		message.ExpiresAt,
+		message.ClientMsgID,
+		richContent,
+		systemEvent,
+		message.EditedAt,
+		[]byte(message.Payload),
 	)
-	
-	return err
+	if err != nil {
+		return false, err
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if created == 0 {
+		return false, nil
+	}
+
+	if message.ThreadRootID != nil {
+		updateRoot := `
+			UPDATE messages
+			SET reply_count = reply_count + 1, last_reply_at = $2, last_reply_by = $3
+			WHERE id = $1
+		`
+		_, err = tx.Exec(updateRoot, *message.ThreadRootID, message.CreatedAt, message.SenderID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// GetMessageByClientMsgID gets the message a sender sent with a client message ID
+func (r *MessageRepository) GetMessageByClientMsgID(senderID uuid.UUID, clientMsgID string) (*models.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		JOIN users u ON m.sender_id = u.id
+		WHERE m.sender_id = $1 AND m.client_msg_id = $2
+	`
+
+	return scanMessage(r.db.QueryRow(query, senderID, clientMsgID))
 }
 
 // GetMessagesByConversationID gets messages for a conversation
+// Thread replies are excluded, they are fetched with GetThreadReplies
 func (r *MessageRepository) GetMessagesByConversationID(conversationID uuid.UUID, limit, offset int) ([]*models.Message, error) {
 	query := `
-		SELECT m.id, m.conversation_id, m.sender_id, m.content, m.message_type, m.is_read, m.created_at, m.updated_at,
-		       u.display_name as sender_name
+		SELECT ` + messageColumns + `
 		FROM messages m
 		JOIN users u ON m.sender_id = u.id
-		WHERE m.conversation_id = $1
+		WHERE m.conversation_id = $1 AND m.thread_root_id IS NULL AND ` + notExpired + `
 		ORDER BY m.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
-	
+
 	rows, err := r.db.Query(query, conversationID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
+
 	var messages []*models.Message
 	for rows.Next() {
-		message := &models.Message{}
-		err := rows.Scan(
-			&message.ID,
-			&message.ConversationID,
-			&message.SenderID,
-			&message.Content,
-			&message.MessageType,
-			&message.IsRead,
-			&message.CreatedAt,
-			&message.UpdatedAt,
-			&message.SenderName,
-		)
+		message, err := scanMessage(rows)
 		if err != nil {
 			return nil, err
 		}
 		messages = append(messages, message)
 	}
-	
+
 	return messages, nil
 }
 
-// GetMessageByID gets a message by ID
-func (r *MessageRepository) GetMessageByID(id uuid.UUID) (*models.Message, error) {
+// GetConversationHistory gets the messages of a conversation with thread replies, oldest first
+// Pages continue after the (created_at, id) of the last message of the previous page, nil for the first page
+func (r *MessageRepository) GetConversationHistory(conversationID uuid.UUID, afterCreatedAt *time.Time, afterID uuid.UUID, limit int) ([]*models.Message, error) {
 	query := `
-		SELECT m.id, m.conversation_id, m.sender_id, m.content, m.message_type, m.is_read, m.created_at, m.updated_at,
-		       u.display_name as sender_name
+		SELECT ` + messageColumns + `
 		FROM messages m
 		JOIN users u ON m.sender_id = u.id
-		WHERE m.id = $1
+		WHERE m.conversation_id = $1 AND ` + notExpired + `
+		  AND ($2::timestamptz IS NULL OR (m.created_at, m.id) > ($2, $3))
+		ORDER BY m.created_at ASC, m.id ASC
+		LIMIT $4
 	`
-	
-	message := &models.Message{}
-	err := r.db.QueryRow(query, id).Scan(
-		&message.ID,
-		&message.ConversationID,
-		&message.SenderID,
-		&message.Content,
-		&message.MessageType,
-		&message.IsRead,
-		&message.CreatedAt,
-		&message.UpdatedAt,
-		&message.SenderName,
-	)
-	
+
+	rows, err := r.db.Query(query, conversationID, afterCreatedAt, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
-	
-	return message, nil
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// CountMessages counts the messages of a conversation, with thread replies
+func (r *MessageRepository) CountMessages(conversationID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM messages m WHERE m.conversation_id = $1 AND ` + notExpired
+
+	var count int
+	err := r.db.QueryRow(query, conversationID).Scan(&count)
+	return count, err
+}
+
+// GetThreadReplies gets the replies of a thread, oldest first
+func (r *MessageRepository) GetThreadReplies(threadRootID uuid.UUID, limit, offset int) ([]*models.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		JOIN users u ON m.sender_id = u.id
+		WHERE m.thread_root_id = $1 AND ` + notExpired + `
+		ORDER BY m.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, threadRootID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// GetMessageByID gets a message by ID
+func (r *MessageRepository) GetMessageByID(id uuid.UUID) (*models.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		JOIN users u ON m.sender_id = u.id
+		WHERE m.id = $1 AND ` + notExpired + `
+	`
+
+	return scanMessage(r.db.QueryRow(query, id))
 }
 
 // MarkMessageAsRead marks a message as read
@@ -126,33 +383,210 @@ func (r *MessageRepository) MarkMessageAsRead(id uuid.UUID) error {
 }
 
 // GetLastMessageByConversationID gets the last message for a conversation
+// Thread replies are left out, as in the message list the preview stands for
 func (r *MessageRepository) GetLastMessageByConversationID(conversationID uuid.UUID) (*models.Message, error) {
 	query := `
-		SELECT m.id, m.conversation_id, m.sender_id, m.content, m.message_type, m.is_read, m.created_at, m.updated_at,
-		       u.display_name as sender_name
+		SELECT ` + messageColumns + `
 		FROM messages m
 		JOIN users u ON m.sender_id = u.id
-		WHERE m.conversation_id = $1
+		WHERE m.conversation_id = $1 AND m.thread_root_id IS NULL AND ` + notExpired + `
 		ORDER BY m.created_at DESC
 		LIMIT 1
 	`
-	
-	message := &models.Message{}
-	err := r.db.QueryRow(query, conversationID).Scan(
-		&message.ID,
-		&message.ConversationID,
-		&message.SenderID,
-		&message.Content,
-		&message.MessageType,
-		&message.IsRead,
-		&message.CreatedAt,
-		&message.UpdatedAt,
-		&message.SenderName,
-	)
-	
+
+	return scanMessage(r.db.QueryRow(query, conversationID))
+}
+
+// PurgeExpiredMessages hard-deletes up to limit expired messages, with the replies of expired thread roots
+// The deleted messages and their attachments are returned, so clients can be notified and stored files removed
+// Rows are claimed with SKIP LOCKED, so several server instances can purge concurrently
+func (r *MessageRepository) PurgeExpiredMessages(limit int) ([]*models.Message, []*models.Attachment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	selectExpired := `
+		WITH expired AS (
+			SELECT id FROM messages
+			WHERE expires_at <= NOW()
+			ORDER BY expires_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		SELECT id, conversation_id, thread_root_id
+		FROM messages
+		WHERE id IN (SELECT id FROM expired) OR thread_root_id IN (SELECT id FROM expired)
+	`
+
+	messages, attachments, err := deleteMessages(tx, selectExpired, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return messages, attachments, nil
+}
+
+// deleteMessages deletes the messages selected by a query returning their id, conversation_id and thread_root_id
+// The query must also select the replies of selected thread roots, which are deleted with them
+// The deleted messages and their attachments are returned, and surviving thread roots get their reply info recomputed
+func deleteMessages(tx *sql.Tx, selectQuery string, args ...interface{}) ([]*models.Message, []*models.Attachment, error) {
+	rows, err := tx.Query(selectQuery, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var messages []*models.Message
+	var ids []uuid.UUID
+	for rows.Next() {
+		message := &models.Message{}
+		if err := rows.Scan(&message.ID, &message.ConversationID, &message.ThreadRootID); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		messages = append(messages, message)
+		ids = append(ids, message.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	selectAttachments := `SELECT ` + attachmentColumns + ` FROM attachments WHERE message_id = ANY($1::uuid[])`
+	rows, err = tx.Query(selectAttachments, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM messages WHERE id = ANY($1::uuid[])`, pq.Array(uuidStrings(ids))); err != nil {
+		return nil, nil, err
+	}
+
+	// Thread roots that outlive some of their replies get their reply info recomputed
+	updateRoots := `
+		UPDATE messages r
+		SET reply_count = (SELECT COUNT(*) FROM messages m WHERE m.thread_root_id = r.id),
+		    last_reply_at = (SELECT MAX(m.created_at) FROM messages m WHERE m.thread_root_id = r.id),
+		    last_reply_by = (
+		        SELECT m.sender_id FROM messages m
+		        WHERE m.thread_root_id = r.id
+		        ORDER BY m.created_at DESC
+		        LIMIT 1
+		    )
+		WHERE r.id = ANY($1::uuid[])
+	`
+
+	var rootIDs []uuid.UUID
+	for _, message := range messages {
+		if message.ThreadRootID != nil {
+			rootIDs = append(rootIDs, *message.ThreadRootID)
+		}
+	}
+	if len(rootIDs) > 0 {
+		if _, err := tx.Exec(updateRoots, pq.Array(uuidStrings(rootIDs))); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return messages, attachments, nil
+}
+
+// Highlight markers used by ts_headline, replaced after HTML escaping the snippet
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// MessageSearchHit is a message matching a search with its highlighted headline
+type MessageSearchHit struct {
+	Message  *models.Message
+	Headline string
+}
+
+// SearchMessages searches messages with full-text search in the conversations the user participates in
+// Results are ordered newest first so they can be paginated with a (created_at, id) cursor
+func (r *MessageRepository) SearchMessages(filter *models.MessageSearchFilter) ([]*MessageSearchHit, error) {
+	args := []interface{}{filter.Query, filter.UserID}
+	conditions := []string{
+		"m.search_vector @@ websearch_to_tsquery('simple', $1)",
+		notExpired,
+	}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ConversationID != nil {
+		addCondition("m.conversation_id = $%d", *filter.ConversationID)
+	}
+	if filter.SenderID != nil {
+		addCondition("m.sender_id = $%d", *filter.SenderID)
+	}
+	if filter.MessageType != "" {
+		addCondition("m.message_type = $%d", filter.MessageType)
+	}
+	if filter.From != nil {
+		addCondition("m.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("m.created_at < $%d", *filter.To)
+	}
+	if filter.BeforeCreatedAt != nil && filter.BeforeID != nil {
+		args = append(args, *filter.BeforeCreatedAt, *filter.BeforeID)
+		conditions = append(conditions, fmt.Sprintf("(m.created_at, m.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := `
+		SELECT ` + messageColumns + `,
+		       ts_headline('simple', m.content, websearch_to_tsquery('simple', $1),
+		                   'StartSel=` + HighlightStart + `, StopSel=` + HighlightStop + `, MaxFragments=2, MaxWords=20, MinWords=5')
+		FROM messages m
+		JOIN users u ON m.sender_id = u.id
+		JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id AND cp.user_id = $2
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
-	
-	return message, nil
+	defer rows.Close()
+
+	var hits []*MessageSearchHit
+	for rows.Next() {
+		hit := &MessageSearchHit{}
+		hit.Message, err = scanMessage(rows, &hit.Headline)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, rows.Err()
 }
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// moderationFlagColumns is the column list used when selecting flags joined with their sender
+const moderationFlagColumns = `
+		f.id, f.message_id, f.conversation_id, f.sender_id, COALESCE(u.display_name, ''),
+		f.hook, f.reason, f.content, f.status, f.reviewed_by, f.reviewed_at, f.created_at`
+
+type ModerationRepository struct {
+	db *database.DB
+}
+
+func NewModerationRepository(db *database.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// CreateRule adds a moderation rule
+// Returns false if a rule with the same kind and pattern exists
+func (r *ModerationRepository) CreateRule(rule *models.ModerationRule) (bool, error) {
+	query := `
+		INSERT INTO moderation_rules (id, kind, pattern, action, reason, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kind, pattern) DO NOTHING
+	`
+
+	rule.ID = uuid.New()
+	rule.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(query,
+		rule.ID,
+		rule.Kind,
+		rule.Pattern,
+		rule.Action,
+		rule.Reason,
+		rule.CreatedBy,
+		rule.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetRules gets all moderation rules, oldest first
+func (r *ModerationRepository) GetRules() ([]*models.ModerationRule, error) {
+	query := `
+		SELECT id, kind, pattern, action, reason, created_by, created_at
+		FROM moderation_rules
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.ModerationRule
+	for rows.Next() {
+		rule := &models.ModerationRule{}
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Kind,
+			&rule.Pattern,
+			&rule.Action,
+			&rule.Reason,
+			&rule.CreatedBy,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteRule deletes a moderation rule
+// Returns false if the rule does not exist
+func (r *ModerationRepository) DeleteRule(id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM moderation_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// CreateFlags adds flagged messages to the review queue
+func (r *ModerationRepository) CreateFlags(flags []*models.ModerationFlag) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO moderation_flags (id, message_id, conversation_id, sender_id, hook, reason, content, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	now := time.Now()
+	for _, flag := range flags {
+		flag.ID = uuid.New()
+		flag.Status = "pending"
+		flag.CreatedAt = now
+
+		_, err := tx.Exec(query,
+			flag.ID,
+			flag.MessageID,
+			flag.ConversationID,
+			flag.SenderID,
+			flag.Hook,
+			flag.Reason,
+			flag.Content,
+			flag.Status,
+			flag.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func scanModerationFlag(scanner rowScanner) (*models.ModerationFlag, error) {
+	flag := &models.ModerationFlag{}
+	err := scanner.Scan(
+		&flag.ID,
+		&flag.MessageID,
+		&flag.ConversationID,
+		&flag.SenderID,
+		&flag.SenderName,
+		&flag.Hook,
+		&flag.Reason,
+		&flag.Content,
+		&flag.Status,
+		&flag.ReviewedBy,
+		&flag.ReviewedAt,
+		&flag.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// GetFlags gets the flags with a status
+// Pending flags are returned oldest first so the queue is reviewed in order, reviewed flags newest first
+func (r *ModerationRepository) GetFlags(status string, limit, offset int) ([]*models.ModerationFlag, error) {
+	order := "f.created_at DESC"
+	if status == "pending" {
+		order = "f.created_at ASC"
+	}
+
+	query := `
+		SELECT ` + moderationFlagColumns + `
+		FROM moderation_flags f
+		LEFT JOIN users u ON u.id = f.sender_id
+		WHERE f.status = $1
+		ORDER BY ` + order + `
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []*models.ModerationFlag
+	for rows.Next() {
+		flag, err := scanModerationFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, rows.Err()
+}
+
+// GetFlagByID gets a flag by ID
+func (r *ModerationRepository) GetFlagByID(id uuid.UUID) (*models.ModerationFlag, error) {
+	query := `
+		SELECT ` + moderationFlagColumns + `
+		FROM moderation_flags f
+		LEFT JOIN users u ON u.id = f.sender_id
+		WHERE f.id = $1
+	`
+
+	return scanModerationFlag(r.db.QueryRow(query, id))
+}
+
+// DismissFlag marks a pending flag as reviewed without action
+// Returns false if the flag does not exist or was already reviewed
+func (r *ModerationRepository) DismissFlag(id, reviewerID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE moderation_flags
+		SET status = 'dismissed', reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := r.db.Exec(query, id, reviewerID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// RemoveFlaggedMessage deletes the message of a pending flag, with its thread replies if it is a thread root
+// Every pending flag of the message is marked as removed. Returns false if the flag does not exist or was already reviewed.
+// The deleted messages and their attachments are returned, so clients can be notified and stored files removed
+func (r *ModerationRepository) RemoveFlaggedMessage(id, reviewerID uuid.UUID) (bool, []*models.Message, []*models.Attachment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var messageID *uuid.UUID
+	err = tx.QueryRow(`SELECT message_id FROM moderation_flags WHERE id = $1 AND status = 'pending' FOR UPDATE`, id).Scan(&messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil, nil, nil
+		}
+		return false, nil, nil, err
+	}
+
+	// Flags are marked before the message is deleted, which unsets their message ID
+	query := `
+		UPDATE moderation_flags
+		SET status = 'removed', reviewed_by = $2, reviewed_at = NOW()
+		WHERE status = 'pending' AND (id = $1 OR message_id = $3)
+	`
+	if _, err := tx.Exec(query, id, reviewerID, messageID); err != nil {
+		return false, nil, nil, err
+	}
+
+	var messages []*models.Message
+	var attachments []*models.Attachment
+	if messageID != nil {
+		selectMessage := `
+			SELECT id, conversation_id, thread_root_id
+			FROM messages
+			WHERE id = $1 OR thread_root_id = $1
+		`
+
+		messages, attachments, err = deleteMessages(tx, selectMessage, *messageID)
+		if err != nil {
+			return false, nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, nil, nil, err
+	}
+
+	return true, messages, attachments, nil
+}
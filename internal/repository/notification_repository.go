@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type NotificationRepository struct {
+	db *database.DB
+}
+
+func NewNotificationRepository(db *database.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// CreateNotification creates a new notification
+func (r *NotificationRepository) CreateNotification(notification *models.Notification) error {
+	query := `
+		INSERT INTO notifications (id, user_id, type, conversation_id, message_id, actor_id, content, is_read, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	notification.ID = uuid.New()
+	notification.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(query,
+		notification.ID,
+		notification.UserID,
+		notification.Type,
+		notification.ConversationID,
+		notification.MessageID,
+		notification.ActorID,
+		notification.Content,
+		notification.IsRead,
+		notification.CreatedAt,
+	)
+
+	return err
+}
+
+// GetNotificationsByUserID gets the notifications of a user, newest first
+func (r *NotificationRepository) GetNotificationsByUserID(userID uuid.UUID, limit, offset int) ([]*models.Notification, error) {
+	query := `
+		SELECT id, user_id, type, conversation_id, message_id, actor_id, content, is_read, created_at
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	for rows.Next() {
+		notification := &models.Notification{}
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.ConversationID,
+			&notification.MessageID,
+			&notification.ActorID,
+			&notification.Content,
+			&notification.IsRead,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationAsRead marks a notification of a user as read
+// Returns false if the notification does not exist or belongs to another user
+func (r *NotificationRepository) MarkNotificationAsRead(id, userID uuid.UUID) (bool, error) {
+	query := `UPDATE notifications SET is_read = true WHERE id = $1 AND user_id = $2`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
@@ -41,7 +41,7 @@ func (r *ParticipantRepository) AddParticipant(participant *models.ConversationP
 // GetParticipantsByConversationID gets all participants for a conversation
 func (r *ParticipantRepository) GetParticipantsByConversationID(conversationID uuid.UUID) ([]*models.ConversationParticipant, error) {
 	query := `
-		SELECT cp.id, cp.conversation_id, cp.user_id, cp.joined_at, cp.is_admin,
+		SELECT cp.id, cp.conversation_id, cp.user_id, cp.joined_at, cp.is_admin, cp.is_muted,
 		       u.id, u.email, u.display_name, u.avatar_url, u.is_online, u.last_seen, u.created_at, u.updated_at
 		FROM conversation_participants cp
 		JOIN users u ON cp.user_id = u.id
@@ -66,6 +66,7 @@ func (r *ParticipantRepository) GetParticipantsByConversationID(conversationID u
 			&participant.UserID,
 			&participant.JoinedAt,
 			&participant.IsAdmin,
+			&participant.IsMuted,
 			&user.ID,
 			&user.Email,
 			&user.DisplayName,
@@ -86,6 +87,30 @@ func (r *ParticipantRepository) GetParticipantsByConversationID(conversationID u
 	return participants, nil
 }
 
+// GetParticipant gets the participant record of a user in a conversation
+func (r *ParticipantRepository) GetParticipant(conversationID, userID uuid.UUID) (*models.ConversationParticipant, error) {
+	query := `
+		SELECT id, conversation_id, user_id, joined_at, is_admin, is_muted
+		FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2
+	`
+
+	participant := &models.ConversationParticipant{}
+	err := r.db.QueryRow(query, conversationID, userID).Scan(
+		&participant.ID,
+		&participant.ConversationID,
+		&participant.UserID,
+		&participant.JoinedAt,
+		&participant.IsAdmin,
+		&participant.IsMuted,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return participant, nil
+}
+
 // RemoveParticipant removes a user from a conversation
 func (r *ParticipantRepository) RemoveParticipant(conversationID, userID uuid.UUID) error {
 	query := `DELETE FROM conversation_participants WHERE conversation_id = $1 AND user_id = $2`
@@ -101,3 +126,40 @@ func (r *ParticipantRepository) IsParticipant(conversationID, userID uuid.UUID)
 	err := r.db.QueryRow(query, conversationID, userID).Scan(&exists)
 	return exists, err
 }
+
+// SetMuted mutes or unmutes a conversation for a participant
+// Returns false if the user is not a participant
+func (r *ParticipantRepository) SetMuted(conversationID, userID uuid.UUID, isMuted bool) (bool, error) {
+	query := `UPDATE conversation_participants SET is_muted = $3 WHERE conversation_id = $1 AND user_id = $2`
+
+	result, err := r.db.Exec(query, conversationID, userID, isMuted)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// SetAdmin grants or revokes the admin role of a participant
+// Returns false if the user is not a participant or already had that role
+func (r *ParticipantRepository) SetAdmin(conversationID, userID uuid.UUID, isAdmin bool) (bool, error) {
+	query := `UPDATE conversation_participants SET is_admin = $3 WHERE conversation_id = $1 AND user_id = $2 AND is_admin <> $3`
+
+	result, err := r.db.Exec(query, conversationID, userID, isAdmin)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// CountAdmins counts the admins of a conversation
+func (r *ParticipantRepository) CountAdmins(conversationID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM conversation_participants WHERE conversation_id = $1 AND is_admin = true`
+
+	var count int
+	err := r.db.QueryRow(query, conversationID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PinResult is the outcome of pinning a message
+type PinResult int
+
+const (
+	PinCreated PinResult = iota
+	PinAlreadyExists
+	PinLimitReached
+)
+
+type PinRepository struct {
+	db *database.DB
+}
+
+func NewPinRepository(db *database.DB) *PinRepository {
+	return &PinRepository{db: db}
+}
+
+// PinMessage pins a message unless the conversation already has maxPins pinned messages
+// Pins of a conversation are serialized by locking the conversation row, so the limit holds under concurrency
+func (r *PinRepository) PinMessage(pin *models.PinnedMessage, maxPins int) (PinResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`SELECT id FROM conversations WHERE id = $1 FOR UPDATE`, pin.ConversationID)
+	if err != nil {
+		return 0, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM pinned_messages WHERE message_id = $1)`, pin.MessageID).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return PinAlreadyExists, nil
+	}
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM pinned_messages WHERE conversation_id = $1`, pin.ConversationID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if count >= maxPins {
+		return PinLimitReached, nil
+	}
+
+	query := `
+		INSERT INTO pinned_messages (id, conversation_id, message_id, pinned_by, pinned_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	pin.ID = uuid.New()
+	pin.PinnedAt = time.Now()
+
+	_, err = tx.Exec(query, pin.ID, pin.ConversationID, pin.MessageID, pin.PinnedBy, pin.PinnedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return PinCreated, tx.Commit()
+}
+
+// UnpinMessage unpins a message
+// Returns false if the message is not pinned
+func (r *PinRepository) UnpinMessage(messageID uuid.UUID) (bool, error) {
+	query := `DELETE FROM pinned_messages WHERE message_id = $1`
+	result, err := r.db.Exec(query, messageID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetPinsByMessageIDs gets the pin state of several messages in a single query
+func (r *PinRepository) GetPinsByMessageIDs(messageIDs []uuid.UUID) (map[uuid.UUID]*models.PinnedMessage, error) {
+	pins := make(map[uuid.UUID]*models.PinnedMessage)
+	if len(messageIDs) == 0 {
+		return pins, nil
+	}
+
+	query := `
+		SELECT id, conversation_id, message_id, pinned_by, pinned_at
+		FROM pinned_messages
+		WHERE message_id = ANY($1::uuid[])
+	`
+
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(messageIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		pin := &models.PinnedMessage{}
+		if err := rows.Scan(&pin.ID, &pin.ConversationID, &pin.MessageID, &pin.PinnedBy, &pin.PinnedAt); err != nil {
+			return nil, err
+		}
+		pins[pin.MessageID] = pin
+	}
+
+	return pins, rows.Err()
+}
+
+// GetPinnedMessages gets the pinned messages of a conversation, most recently pinned first
+func (r *PinRepository) GetPinnedMessages(conversationID uuid.UUID) ([]*models.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM pinned_messages pm
+		JOIN messages m ON m.id = pm.message_id
+		JOIN users u ON m.sender_id = u.id
+		WHERE pm.conversation_id = $1 AND ` + notExpired + `
+		ORDER BY pm.pinned_at DESC
+	`
+
+	rows, err := r.db.Query(query, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
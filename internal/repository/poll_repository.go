@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// pollColumns is the column list used when selecting polls, ending with whether the poll is closed
+const pollColumns = `id, message_id, conversation_id, question, allows_multiple, is_anonymous, voter_count,
+	closes_at, closed_at, closed_by, created_by, created_at,
+	(closed_at IS NOT NULL OR (closes_at IS NOT NULL AND closes_at <= NOW()))`
+
+// VoteResult is the outcome of voting in a poll
+type VoteResult int
+
+const (
+	VoteRecorded VoteResult = iota
+	VotePollClosed
+	VoteInvalidOption
+)
+
+type PollRepository struct {
+	db *database.DB
+}
+
+func NewPollRepository(db *database.DB) *PollRepository {
+	return &PollRepository{db: db}
+}
+
+// scanPoll scans a row selected with pollColumns into a poll
+func scanPoll(scanner rowScanner) (*models.Poll, error) {
+	poll := &models.Poll{}
+	err := scanner.Scan(
+		&poll.ID,
+		&poll.MessageID,
+		&poll.ConversationID,
+		&poll.Question,
+		&poll.AllowsMultiple,
+		&poll.IsAnonymous,
+		&poll.VoterCount,
+		&poll.ClosesAt,
+		&poll.ClosedAt,
+		&poll.ClosedBy,
+		&poll.CreatedBy,
+		&poll.CreatedAt,
+		&poll.IsClosed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+// CreatePoll creates a poll message with its poll and options in a single transaction
+func (r *PollRepository) CreatePoll(message *models.Message, poll *models.Poll) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := insertMessage(tx, message); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO polls (id, message_id, conversation_id, question, allows_multiple, is_anonymous, closes_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	poll.ID = uuid.New()
+	poll.MessageID = message.ID
+	poll.CreatedAt = time.Now()
+
+	_, err = tx.Exec(query,
+		poll.ID,
+		poll.MessageID,
+		poll.ConversationID,
+		poll.Question,
+		poll.AllowsMultiple,
+		poll.IsAnonymous,
+		poll.ClosesAt,
+		poll.CreatedBy,
+		poll.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	insertOption := `INSERT INTO poll_options (id, poll_id, position, text) VALUES ($1, $2, $3, $4)`
+	for i, option := range poll.Options {
+		option.ID = uuid.New()
+		option.PollID = poll.ID
+		option.Position = i
+		if _, err := tx.Exec(insertOption, option.ID, option.PollID, option.Position, option.Text); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPollByID gets a poll by ID, without its options
+func (r *PollRepository) GetPollByID(id uuid.UUID) (*models.Poll, error) {
+	query := `SELECT ` + pollColumns + ` FROM polls WHERE id = $1`
+	return scanPoll(r.db.QueryRow(query, id))
+}
+
+// GetPollsByMessageIDs gets the polls of several messages with their options, keyed by message ID
+// The votes of the user are set on each poll, and voters are listed on the options of public polls
+func (r *PollRepository) GetPollsByMessageIDs(messageIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]*models.Poll, error) {
+	polls := make(map[uuid.UUID]*models.Poll)
+	if len(messageIDs) == 0 {
+		return polls, nil
+	}
+
+	query := `SELECT ` + pollColumns + ` FROM polls WHERE message_id = ANY($1::uuid[])`
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(messageIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pollsByID := make(map[uuid.UUID]*models.Poll)
+	var pollIDs []uuid.UUID
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, err
+		}
+		poll.Options = []*models.PollOption{}
+		poll.MyVotes = []uuid.UUID{}
+		polls[poll.MessageID] = poll
+		pollsByID[poll.ID] = poll
+		pollIDs = append(pollIDs, poll.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(pollIDs) == 0 {
+		return polls, nil
+	}
+
+	optionsQuery := `
+		SELECT id, poll_id, position, text, vote_count
+		FROM poll_options
+		WHERE poll_id = ANY($1::uuid[])
+		ORDER BY position ASC
+	`
+
+	optionRows, err := r.db.Query(optionsQuery, pq.Array(uuidStrings(pollIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer optionRows.Close()
+
+	options := make(map[uuid.UUID]*models.PollOption)
+	for optionRows.Next() {
+		option := &models.PollOption{}
+		if err := optionRows.Scan(&option.ID, &option.PollID, &option.Position, &option.Text, &option.VoteCount); err != nil {
+			return nil, err
+		}
+		poll := pollsByID[option.PollID]
+		poll.Options = append(poll.Options, option)
+		options[option.ID] = option
+	}
+	if err := optionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	votesQuery := `
+		SELECT v.poll_id, v.option_id, v.user_id
+		FROM poll_votes v
+		JOIN polls p ON p.id = v.poll_id
+		WHERE v.poll_id = ANY($1::uuid[]) AND (v.user_id = $2 OR p.is_anonymous = false)
+		ORDER BY v.created_at ASC
+	`
+
+	voteRows, err := r.db.Query(votesQuery, pq.Array(uuidStrings(pollIDs)), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer voteRows.Close()
+
+	for voteRows.Next() {
+		var pollID, optionID, voterID uuid.UUID
+		if err := voteRows.Scan(&pollID, &optionID, &voterID); err != nil {
+			return nil, err
+		}
+
+		poll := pollsByID[pollID]
+		if voterID == userID {
+			poll.MyVotes = append(poll.MyVotes, optionID)
+		}
+		if !poll.IsAnonymous {
+			options[optionID].Voters = append(options[optionID].Voters, voterID)
+		}
+	}
+
+	return polls, voteRows.Err()
+}
+
+// Vote replaces the votes of a user in a poll, an empty list of options retracts them
+// Votes of a poll are serialized by locking the poll row, so the stored counts stay consistent
+func (r *PollRepository) Vote(pollID, userID uuid.UUID, optionIDs []uuid.UUID) (VoteResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var isClosed bool
+	lockPoll := `
+		SELECT closed_at IS NOT NULL OR (closes_at IS NOT NULL AND closes_at <= NOW())
+		FROM polls
+		WHERE id = $1
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(lockPoll, pollID).Scan(&isClosed); err != nil {
+		return 0, err
+	}
+	if isClosed {
+		return VotePollClosed, nil
+	}
+
+	if len(optionIDs) > 0 {
+		var count int
+		countOptions := `SELECT COUNT(*) FROM poll_options WHERE poll_id = $1 AND id = ANY($2::uuid[])`
+		if err := tx.QueryRow(countOptions, pollID, pq.Array(uuidStrings(optionIDs))).Scan(&count); err != nil {
+			return 0, err
+		}
+		if count != len(optionIDs) {
+			return VoteInvalidOption, nil
+		}
+	}
+
+	rows, err := tx.Query(`DELETE FROM poll_votes WHERE poll_id = $1 AND user_id = $2 RETURNING option_id`, pollID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var previous []uuid.UUID
+	for rows.Next() {
+		var optionID uuid.UUID
+		if err := rows.Scan(&optionID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		previous = append(previous, optionID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(previous) > 0 {
+		decrement := `UPDATE poll_options SET vote_count = vote_count - 1 WHERE id = ANY($1::uuid[])`
+		if _, err := tx.Exec(decrement, pq.Array(uuidStrings(previous))); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(optionIDs) > 0 {
+		insertVotes := `
+			INSERT INTO poll_votes (poll_id, option_id, user_id, created_at)
+			SELECT $1, option_id, $2, NOW() FROM unnest($3::uuid[]) AS option_id
+		`
+		if _, err := tx.Exec(insertVotes, pollID, userID, pq.Array(uuidStrings(optionIDs))); err != nil {
+			return 0, err
+		}
+
+		increment := `UPDATE poll_options SET vote_count = vote_count + 1 WHERE id = ANY($1::uuid[])`
+		if _, err := tx.Exec(increment, pq.Array(uuidStrings(optionIDs))); err != nil {
+			return 0, err
+		}
+	}
+
+	// The voter count changes when the user votes for the first time or retracts
+	voterDelta := 0
+	if len(previous) == 0 && len(optionIDs) > 0 {
+		voterDelta = 1
+	} else if len(previous) > 0 && len(optionIDs) == 0 {
+		voterDelta = -1
+	}
+	if voterDelta != 0 {
+		if _, err := tx.Exec(`UPDATE polls SET voter_count = voter_count + $2 WHERE id = $1`, pollID, voterDelta); err != nil {
+			return 0, err
+		}
+	}
+
+	return VoteRecorded, tx.Commit()
+}
+
+// ClosePoll closes a poll early
+// Returns false if the poll is already closed
+func (r *PollRepository) ClosePoll(pollID, closedBy uuid.UUID) (bool, error) {
+	query := `
+		UPDATE polls
+		SET closed_at = NOW(), closed_by = $2
+		WHERE id = $1 AND closed_at IS NULL AND (closes_at IS NULL OR closes_at > NOW())
+	`
+
+	result, err := r.db.Exec(query, pollID, closedBy)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
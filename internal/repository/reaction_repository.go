@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ReactionRepository struct {
+	db *database.DB
+}
+
+func NewReactionRepository(db *database.DB) *ReactionRepository {
+	return &ReactionRepository{db: db}
+}
+
+// AddReaction adds a reaction to a message
+// Returns false if the user already reacted with the same emoji
+func (r *ReactionRepository) AddReaction(reaction *models.Reaction) (bool, error) {
+	query := `
+		INSERT INTO message_reactions (id, message_id, user_id, emoji, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (message_id, user_id, emoji) DO NOTHING
+	`
+
+	reaction.ID = uuid.New()
+	reaction.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(query,
+		reaction.ID,
+		reaction.MessageID,
+		reaction.UserID,
+		reaction.Emoji,
+		reaction.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// RemoveReaction removes a user's reaction from a message
+// Returns false if the reaction does not exist
+func (r *ReactionRepository) RemoveReaction(messageID, userID uuid.UUID, emoji string) (bool, error) {
+	query := `DELETE FROM message_reactions WHERE message_id = $1 AND user_id = $2 AND emoji = $3`
+	result, err := r.db.Exec(query, messageID, userID, emoji)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// CountReactions counts the reactions with an emoji on a message
+func (r *ReactionRepository) CountReactions(messageID uuid.UUID, emoji string) (int, error) {
+	query := `SELECT COUNT(*) FROM message_reactions WHERE message_id = $1 AND emoji = $2`
+
+	var count int
+	err := r.db.QueryRow(query, messageID, emoji).Scan(&count)
+	return count, err
+}
+
+// GetReactionSummaries gets the aggregated reactions of several messages in a single query
+// Emojis are ordered by their first use on each message
+func (r *ReactionRepository) GetReactionSummaries(messageIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID][]models.ReactionSummary, error) {
+	summaries := make(map[uuid.UUID][]models.ReactionSummary)
+	if len(messageIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := `
+		SELECT message_id, emoji, COUNT(*), BOOL_OR(user_id = $2)
+		FROM message_reactions
+		WHERE message_id = ANY($1::uuid[])
+		GROUP BY message_id, emoji
+		ORDER BY message_id, MIN(created_at)
+	`
+
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(messageIDs)), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID uuid.UUID
+		var summary models.ReactionSummary
+		if err := rows.Scan(&messageID, &summary.Emoji, &summary.Count, &summary.ReactedByMe); err != nil {
+			return nil, err
+		}
+		summaries[messageID] = append(summaries[messageID], summary)
+	}
+
+	return summaries, rows.Err()
+}
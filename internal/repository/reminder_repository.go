@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// reminderColumns is the column list used when selecting reminders joined with their message as m
+const reminderColumns = `r.id, r.user_id, r.conversation_id, r.message_id, COALESCE(m.content, ''), r.note, r.remind_at, r.timezone,
+	r.status, r.claimed_at, r.delivered_at, r.notification_id, r.created_at, r.updated_at`
+
+type ReminderRepository struct {
+	db *database.DB
+}
+
+func NewReminderRepository(db *database.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// scanReminder scans a row selected with reminderColumns into a reminder
+func scanReminder(scanner rowScanner) (*models.Reminder, error) {
+	reminder := &models.Reminder{}
+	err := scanner.Scan(
+		&reminder.ID,
+		&reminder.UserID,
+		&reminder.ConversationID,
+		&reminder.MessageID,
+		&reminder.MessagePreview,
+		&reminder.Note,
+		&reminder.RemindAt,
+		&reminder.Timezone,
+		&reminder.Status,
+		&reminder.ClaimedAt,
+		&reminder.DeliveredAt,
+		&reminder.NotificationID,
+		&reminder.CreatedAt,
+		&reminder.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+// scanReminders scans all rows selected with reminderColumns
+func scanReminders(rows *sql.Rows) ([]*models.Reminder, error) {
+	defer rows.Close()
+
+	var reminders []*models.Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// CreateReminder creates a new pending reminder
+func (r *ReminderRepository) CreateReminder(reminder *models.Reminder) error {
+	query := `
+		INSERT INTO reminders (id, user_id, conversation_id, message_id, note, remind_at, timezone, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	reminder.ID = uuid.New()
+	reminder.Status = "pending"
+	reminder.CreatedAt = time.Now()
+	reminder.UpdatedAt = reminder.CreatedAt
+
+	_, err := r.db.Exec(query,
+		reminder.ID,
+		reminder.UserID,
+		reminder.ConversationID,
+		reminder.MessageID,
+		reminder.Note,
+		reminder.RemindAt,
+		reminder.Timezone,
+		reminder.Status,
+		reminder.CreatedAt,
+		reminder.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetReminderByID gets a reminder by ID
+func (r *ReminderRepository) GetReminderByID(id uuid.UUID) (*models.Reminder, error) {
+	query := `
+		SELECT ` + reminderColumns + `
+		FROM reminders r
+		LEFT JOIN messages m ON m.id = r.message_id
+		WHERE r.id = $1
+	`
+	return scanReminder(r.db.QueryRow(query, id))
+}
+
+// GetRemindersByUser gets the reminders of a user with one of the given statuses
+// Upcoming reminders are listed soonest first, past ones latest first
+func (r *ReminderRepository) GetRemindersByUser(userID uuid.UUID, statuses []string, latestFirst bool, limit, offset int) ([]*models.Reminder, error) {
+	order := "ASC"
+	if latestFirst {
+		order = "DESC"
+	}
+
+	query := `
+		SELECT ` + reminderColumns + `
+		FROM reminders r
+		LEFT JOIN messages m ON m.id = r.message_id
+		WHERE r.user_id = $1 AND r.status = ANY($2::text[])
+		ORDER BY r.remind_at ` + order + `
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.Query(query, userID, pq.Array(statuses), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReminders(rows)
+}
+
+// SnoozeReminder sets a new time for a reminder of a user, making it pending again
+// Returns false if there is no such reminder
+func (r *ReminderRepository) SnoozeReminder(id, userID uuid.UUID, remindAt time.Time) (bool, error) {
+	query := `
+		UPDATE reminders
+		SET remind_at = $3, status = 'pending', claimed_at = NULL, delivered_at = NULL, notification_id = NULL, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.Exec(query, id, userID, remindAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// DeleteReminder deletes a reminder of a user
+// Returns false if there is no such reminder
+func (r *ReminderRepository) DeleteReminder(id, userID uuid.UUID) (bool, error) {
+	query := `DELETE FROM reminders WHERE id = $1 AND user_id = $2`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ClaimDueReminders claims up to limit due reminders for delivery
+// Rows locked by another instance are skipped, so a reminder is claimed by a single instance
+func (r *ReminderRepository) ClaimDueReminders(limit int) ([]*models.Reminder, error) {
+	query := `
+		WITH r AS (
+			UPDATE reminders
+			SET status = 'delivering', claimed_at = NOW(), updated_at = NOW()
+			WHERE id IN (
+				SELECT id FROM reminders
+				WHERE status = 'pending' AND remind_at <= NOW()
+				ORDER BY remind_at ASC
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + reminderColumns + `
+		FROM r
+		LEFT JOIN messages m ON m.id = r.message_id
+	`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReminders(rows)
+}
+
+// MarkReminderDelivered records the notification delivered for a claimed reminder
+// A reminder snoozed while it was delivered stays pending
+func (r *ReminderRepository) MarkReminderDelivered(id, notificationID uuid.UUID) error {
+	query := `
+		UPDATE reminders
+		SET status = 'delivered', delivered_at = NOW(), notification_id = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'delivering'
+	`
+	_, err := r.db.Exec(query, id, notificationID)
+	return err
+}
+
+// ReleaseStaleClaims makes the reminders claimed before the given time pending again
+// Such claims belong to an instance that stopped while delivering, a reminder may then be delivered twice but is never lost
+func (r *ReminderRepository) ReleaseStaleClaims(claimedBefore time.Time) (int64, error) {
+	query := `
+		UPDATE reminders
+		SET status = 'pending', claimed_at = NULL, updated_at = NOW()
+		WHERE status = 'delivering' AND claimed_at < $1
+	`
+
+	result, err := r.db.Exec(query, claimedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetReminderTimezone gets the timezone a user set for reminders
+func (r *ReminderRepository) GetReminderTimezone(userID uuid.UUID) (string, time.Time, error) {
+	query := `SELECT timezone, updated_at FROM reminder_settings WHERE user_id = $1`
+
+	var timezone string
+	var updatedAt time.Time
+	err := r.db.QueryRow(query, userID).Scan(&timezone, &updatedAt)
+	return timezone, updatedAt, err
+}
+
+// SetReminderTimezone sets the timezone of a user for reminders
+func (r *ReminderRepository) SetReminderTimezone(userID uuid.UUID, timezone string) (time.Time, error) {
+	query := `
+		INSERT INTO reminder_settings (user_id, timezone, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	var updatedAt time.Time
+	err := r.db.QueryRow(query, userID, timezone).Scan(&updatedAt)
+	return updatedAt, err
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// scheduledMessageColumns is the column list used when selecting scheduled messages
+const scheduledMessageColumns = `id, conversation_id, sender_id, content, format, message_type, reply_to_id, attachment_ids,
+	scheduled_at, status, claimed_at, message_id, error, created_at, updated_at`
+
+type ScheduledMessageRepository struct {
+	db *database.DB
+}
+
+func NewScheduledMessageRepository(db *database.DB) *ScheduledMessageRepository {
+	return &ScheduledMessageRepository{db: db}
+}
+
+// scanScheduledMessage scans a row selected with scheduledMessageColumns into a scheduled message
+func scanScheduledMessage(scanner rowScanner) (*models.ScheduledMessage, error) {
+	scheduled := &models.ScheduledMessage{}
+	var attachmentIDs []string
+	err := scanner.Scan(
+		&scheduled.ID,
+		&scheduled.ConversationID,
+		&scheduled.SenderID,
+		&scheduled.Content,
+		&scheduled.Format,
+		&scheduled.MessageType,
+		&scheduled.ReplyToID,
+		pq.Array(&attachmentIDs),
+		&scheduled.ScheduledAt,
+		&scheduled.Status,
+		&scheduled.ClaimedAt,
+		&scheduled.MessageID,
+		&scheduled.Error,
+		&scheduled.CreatedAt,
+		&scheduled.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled.AttachmentIDs = make([]uuid.UUID, 0, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		scheduled.AttachmentIDs = append(scheduled.AttachmentIDs, parsed)
+	}
+
+	return scheduled, nil
+}
+
+// scanScheduledMessages scans all rows selected with scheduledMessageColumns
+func scanScheduledMessages(rows *sql.Rows) ([]*models.ScheduledMessage, error) {
+	defer rows.Close()
+
+	var scheduledMessages []*models.ScheduledMessage
+	for rows.Next() {
+		scheduled, err := scanScheduledMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		scheduledMessages = append(scheduledMessages, scheduled)
+	}
+
+	return scheduledMessages, rows.Err()
+}
+
+// CreateScheduledMessage creates a new pending scheduled message
+func (r *ScheduledMessageRepository) CreateScheduledMessage(scheduled *models.ScheduledMessage) error {
+	query := `
+		INSERT INTO scheduled_messages (id, conversation_id, sender_id, content, format, message_type, reply_to_id, attachment_ids,
+		                                scheduled_at, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::uuid[], $9, $10, $11, $12)
+	`
+
+	scheduled.ID = uuid.New()
+	scheduled.Status = "pending"
+	scheduled.CreatedAt = time.Now()
+	scheduled.UpdatedAt = scheduled.CreatedAt
+
+	_, err := r.db.Exec(query,
+		scheduled.ID,
+		scheduled.ConversationID,
+		scheduled.SenderID,
+		scheduled.Content,
+		scheduled.Format,
+		scheduled.MessageType,
+		scheduled.ReplyToID,
+		pq.Array(uuidStrings(scheduled.AttachmentIDs)),
+		scheduled.ScheduledAt,
+		scheduled.Status,
+		scheduled.CreatedAt,
+		scheduled.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetScheduledMessageByID gets a scheduled message by ID
+func (r *ScheduledMessageRepository) GetScheduledMessageByID(id uuid.UUID) (*models.ScheduledMessage, error) {
+	query := `SELECT ` + scheduledMessageColumns + ` FROM scheduled_messages WHERE id = $1`
+	return scanScheduledMessage(r.db.QueryRow(query, id))
+}
+
+// GetScheduledMessagesBySender gets the scheduled messages of a sender with a given status, soonest first
+func (r *ScheduledMessageRepository) GetScheduledMessagesBySender(senderID uuid.UUID, status string, limit, offset int) ([]*models.ScheduledMessage, error) {
+	query := `
+		SELECT ` + scheduledMessageColumns + `
+		FROM scheduled_messages
+		WHERE sender_id = $1 AND status = $2
+		ORDER BY scheduled_at ASC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.Query(query, senderID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanScheduledMessages(rows)
+}
+
+// UpdatePendingScheduledMessage replaces the content and time of a scheduled message that is still pending
+// Returns false if the message does not belong to the sender or is no longer pending
+func (r *ScheduledMessageRepository) UpdatePendingScheduledMessage(scheduled *models.ScheduledMessage) (bool, error) {
+	query := `
+		UPDATE scheduled_messages
+		SET content = $3, format = $4, message_type = $5, reply_to_id = $6, attachment_ids = $7::uuid[], scheduled_at = $8, updated_at = $9
+		WHERE id = $1 AND sender_id = $2 AND status = 'pending'
+	`
+
+	scheduled.UpdatedAt = time.Now()
+
+	result, err := r.db.Exec(query,
+		scheduled.ID,
+		scheduled.SenderID,
+		scheduled.Content,
+		scheduled.Format,
+		scheduled.MessageType,
+		scheduled.ReplyToID,
+		pq.Array(uuidStrings(scheduled.AttachmentIDs)),
+		scheduled.ScheduledAt,
+		scheduled.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// DeleteScheduledMessage deletes a scheduled message of a sender that is pending or failed
+// Returns false if there is no such message
+func (r *ScheduledMessageRepository) DeleteScheduledMessage(id, senderID uuid.UUID) (bool, error) {
+	query := `DELETE FROM scheduled_messages WHERE id = $1 AND sender_id = $2 AND status IN ('pending', 'failed')`
+	result, err := r.db.Exec(query, id, senderID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ClaimDueScheduledMessages claims up to limit due scheduled messages for sending
+// Rows locked by another instance are skipped, so a message is claimed by a single instance
+func (r *ScheduledMessageRepository) ClaimDueScheduledMessages(limit int) ([]*models.ScheduledMessage, error) {
+	query := `
+		UPDATE scheduled_messages
+		SET status = 'sending', claimed_at = NOW(), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM scheduled_messages
+			WHERE status = 'pending' AND scheduled_at <= NOW()
+			ORDER BY scheduled_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduledMessageColumns
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanScheduledMessages(rows)
+}
+
+// MarkScheduledMessageSent records the message posted for a claimed scheduled message
+func (r *ScheduledMessageRepository) MarkScheduledMessageSent(id, messageID uuid.UUID) error {
+	query := `UPDATE scheduled_messages SET status = 'sent', message_id = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(query, id, messageID)
+	return err
+}
+
+// MarkScheduledMessageFailed records why a claimed scheduled message could not be posted
+func (r *ScheduledMessageRepository) MarkScheduledMessageFailed(id uuid.UUID, reason string) error {
+	query := `UPDATE scheduled_messages SET status = 'failed', error = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(query, id, reason)
+	return err
+}
+
+// FailStaleClaims fails the messages claimed before the given time that were never marked as sent
+// Such claims belong to an instance that stopped while sending, they are not retried to avoid double sends
+func (r *ScheduledMessageRepository) FailStaleClaims(claimedBefore time.Time, reason string) ([]*models.ScheduledMessage, error) {
+	query := `
+		UPDATE scheduled_messages
+		SET status = 'failed', error = $2, updated_at = NOW()
+		WHERE status = 'sending' AND claimed_at < $1
+		RETURNING ` + scheduledMessageColumns
+
+	rows, err := r.db.Query(query, claimedBefore, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanScheduledMessages(rows)
+}
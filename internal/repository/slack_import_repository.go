@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ImportedMessage is a Slack message to import with its Slack identifiers
+type ImportedMessage struct {
+	Message   *models.Message
+	TS        string                 // Timestamp identifying the message in its channel
+	ThreadTS  string                 // Timestamp of the thread root, empty if the message is not a reply
+	Reactions map[string][]uuid.UUID // Users who reacted, by emoji
+}
+
+type SlackImportRepository struct {
+	db *database.DB
+}
+
+func NewSlackImportRepository(db *database.DB) *SlackImportRepository {
+	return &SlackImportRepository{db: db}
+}
+
+// GetUserIDsByEmails gets the IDs of the users with the given emails, keyed by lowercase email
+func (r *SlackImportRepository) GetUserIDsByEmails(emails []string) (map[string]uuid.UUID, error) {
+	lowered := make([]string, 0, len(emails))
+	for _, email := range emails {
+		lowered = append(lowered, strings.ToLower(email))
+	}
+
+	query := `SELECT LOWER(email), id FROM users WHERE LOWER(email) = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(lowered))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var email string
+		var id uuid.UUID
+		if err := rows.Scan(&email, &id); err != nil {
+			return nil, err
+		}
+		userIDs[email] = id
+	}
+
+	return userIDs, rows.Err()
+}
+
+// GetImportedConversationID gets the conversation a Slack channel was imported into
+// Returns sql.ErrNoRows if the channel was not imported yet
+func (r *SlackImportRepository) GetImportedConversationID(slackChannelID string) (uuid.UUID, error) {
+	query := `SELECT conversation_id FROM slack_imported_channels WHERE slack_channel_id = $1`
+
+	var conversationID uuid.UUID
+	err := r.db.QueryRow(query, slackChannelID).Scan(&conversationID)
+	return conversationID, err
+}
+
+// CreateImportedConversation creates the conversation of a Slack channel with its creator as admin
+// The conversation keeps the creation time of the channel
+func (r *SlackImportRepository) CreateImportedConversation(conversation *models.Conversation, slackChannelID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	conversation.ID = uuid.New()
+	if conversation.CreatedAt.IsZero() {
+		conversation.CreatedAt = time.Now()
+	}
+	conversation.UpdatedAt = time.Now()
+
+	_, err = tx.Exec(`
+		INSERT INTO conversations (id, name, type, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		conversation.ID,
+		conversation.Name,
+		conversation.Type,
+		conversation.CreatedBy,
+		conversation.CreatedAt,
+		conversation.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO conversation_participants (id, conversation_id, user_id, joined_at, is_admin)
+		VALUES ($1, $2, $3, NOW(), TRUE)
+	`, uuid.New(), conversation.ID, conversation.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO slack_imported_channels (slack_channel_id, conversation_id)
+		VALUES ($1, $2)
+	`, slackChannelID, conversation.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddParticipants adds the users who are not members of a conversation yet
+// Returns the number of members added
+func (r *SlackImportRepository) AddParticipants(conversationID uuid.UUID, userIDs []uuid.UUID) (int, error) {
+	query := `
+		INSERT INTO conversation_participants (conversation_id, user_id, joined_at, is_admin)
+		SELECT $1, user_id, NOW(), FALSE
+		FROM UNNEST($2::uuid[]) AS user_id
+		ON CONFLICT (conversation_id, user_id) DO NOTHING
+	`
+
+	result, err := r.db.Exec(query, conversationID, pq.Array(uuidStrings(userIDs)))
+	if err != nil {
+		return 0, err
+	}
+
+	added, err := result.RowsAffected()
+	return int(added), err
+}
+
+// GetImportedMessages gets the messages imported from a Slack channel, by timestamp
+// Messages deleted since they were imported map to uuid.Nil
+func (r *SlackImportRepository) GetImportedMessages(slackChannelID string) (map[string]uuid.UUID, error) {
+	query := `
+		SELECT i.ts, m.id
+		FROM slack_imported_messages i
+		LEFT JOIN messages m ON m.id = i.message_id
+		WHERE i.slack_channel_id = $1
+	`
+
+	rows, err := r.db.Query(query, slackChannelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imported := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var ts string
+		var messageID *uuid.UUID
+		if err := rows.Scan(&ts, &messageID); err != nil {
+			return nil, err
+		}
+		imported[ts] = uuid.Nil
+		if messageID != nil {
+			imported[ts] = *messageID
+		}
+	}
+
+	return imported, rows.Err()
+}
+
+// ImportMessages inserts a batch of messages of a Slack channel with their reactions in a single transaction
+// Replies are attached to their thread root found in imported, which gets the inserted messages.
+// Replies whose root was not imported or was deleted become regular messages.
+// Returns the number of reactions inserted
+func (r *SlackImportRepository) ImportMessages(slackChannelID string, messages []*ImportedMessage, imported map[string]uuid.UUID) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	insertedIDs := make(map[string]uuid.UUID, len(messages))
+	reactions := 0
+	for _, m := range messages {
+		if m.ThreadTS != "" {
+			rootID, ok := insertedIDs[m.ThreadTS]
+			if !ok {
+				rootID = imported[m.ThreadTS]
+			}
+			if rootID != uuid.Nil {
+				m.Message.ReplyToID = &rootID
+				m.Message.ThreadRootID = &rootID
+			}
+		}
+
+		if _, err := insertMessage(tx, m.Message); err != nil {
+			return 0, err
+		}
+		insertedIDs[m.TS] = m.Message.ID
+
+		_, err = tx.Exec(`
+			INSERT INTO slack_imported_messages (slack_channel_id, ts, conversation_id, message_id)
+			VALUES ($1, $2, $3, $4)
+		`, slackChannelID, m.TS, m.Message.ConversationID, m.Message.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		for emoji, userIDs := range m.Reactions {
+			result, err := tx.Exec(`
+				INSERT INTO message_reactions (message_id, user_id, emoji, created_at)
+				SELECT $1, user_id, $2, $3
+				FROM UNNEST($4::uuid[]) AS user_id
+				ON CONFLICT (message_id, user_id, emoji) DO NOTHING
+			`, m.Message.ID, emoji, m.Message.CreatedAt, pq.Array(uuidStrings(userIDs)))
+			if err != nil {
+				return 0, err
+			}
+			added, err := result.RowsAffected()
+			if err != nil {
+				return 0, err
+			}
+			reactions += int(added)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// The thread roots are only known to later batches once the transaction is committed
+	for ts, id := range insertedIDs {
+		imported[ts] = id
+	}
+
+	return reactions, nil
+}
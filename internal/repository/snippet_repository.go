@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"goswift/internal/database"
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type SnippetRepository struct {
+	db *database.DB
+}
+
+func NewSnippetRepository(db *database.DB) *SnippetRepository {
+	return &SnippetRepository{db: db}
+}
+
+// CreateSnippetMessage creates a snippet message with its code in a single transaction
+// Returns false without creating anything if the sender already sent a message with the same client message ID
+func (r *SnippetRepository) CreateSnippetMessage(message *models.Message, snippet *models.Snippet) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	created, err := insertMessage(tx, message)
+	if err != nil || !created {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO message_snippets (message_id, language, filename, code, size_bytes, line_count, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	snippet.MessageID = message.ID
+	snippet.CreatedAt = message.CreatedAt
+
+	_, err = tx.Exec(query,
+		snippet.MessageID,
+		snippet.Language,
+		snippet.Filename,
+		snippet.Code,
+		snippet.SizeBytes,
+		snippet.LineCount,
+		snippet.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// GetSnippet gets the snippet of a message
+func (r *SnippetRepository) GetSnippet(messageID uuid.UUID) (*models.Snippet, error) {
+	query := `
+		SELECT message_id, language, filename, code, size_bytes, line_count, created_at
+		FROM message_snippets
+		WHERE message_id = $1
+	`
+
+	snippet := &models.Snippet{}
+	err := r.db.QueryRow(query, messageID).Scan(
+		&snippet.MessageID,
+		&snippet.Language,
+		&snippet.Filename,
+		&snippet.Code,
+		&snippet.SizeBytes,
+		&snippet.LineCount,
+		&snippet.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return snippet, nil
+}
+
+// GetSnippetsByMessageIDs gets the snippets of several messages in a single query, keyed by message ID
+// Only the first headLength characters of the code are loaded, 0 loads all of it
+func (r *SnippetRepository) GetSnippetsByMessageIDs(messageIDs []uuid.UUID, headLength int) (map[uuid.UUID]*models.Snippet, error) {
+	snippets := make(map[uuid.UUID]*models.Snippet)
+	if len(messageIDs) == 0 {
+		return snippets, nil
+	}
+
+	query := `
+		SELECT message_id, language, filename,
+		       CASE WHEN $2::int > 0 THEN left(code, $2::int) ELSE code END,
+		       size_bytes, line_count, created_at
+		FROM message_snippets
+		WHERE message_id = ANY($1::uuid[])
+	`
+
+	rows, err := r.db.Query(query, pq.Array(uuidStrings(messageIDs)), headLength)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		snippet := &models.Snippet{}
+		err := rows.Scan(
+			&snippet.MessageID,
+			&snippet.Language,
+			&snippet.Filename,
+			&snippet.Code,
+			&snippet.SizeBytes,
+			&snippet.LineCount,
+			&snippet.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		snippets[snippet.MessageID] = snippet
+	}
+
+	return snippets, rows.Err()
+}
+
+// copySnippet copies the snippet of a message to a forwarded copy of it in a transaction, see CreateForwardedMessages
+// Returns nil if the source message has no snippet
+func copySnippet(tx *sql.Tx, sourceMessageID, targetMessageID uuid.UUID) (*models.Snippet, error) {
+	query := `
+		INSERT INTO message_snippets (message_id, language, filename, code, size_bytes, line_count, created_at)
+		SELECT $2, language, filename, code, size_bytes, line_count, NOW()
+		FROM message_snippets
+		WHERE message_id = $1
+		RETURNING message_id, language, filename, code, size_bytes, line_count, created_at
+	`
+
+	snippet := &models.Snippet{}
+	err := tx.QueryRow(query, sourceMessageID, targetMessageID).Scan(
+		&snippet.MessageID,
+		&snippet.Language,
+		&snippet.Filename,
+		&snippet.Code,
+		&snippet.SizeBytes,
+		&snippet.LineCount,
+		&snippet.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return snippet, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"goswift/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type ThreadRepository struct {
+	db *database.DB
+}
+
+func NewThreadRepository(db *database.DB) *ThreadRepository {
+	return &ThreadRepository{db: db}
+}
+
+// AutoFollow makes a user follow a thread unless they already have a follow state for it
+// An explicit unfollow is kept, so replying again does not re-subscribe the user
+func (r *ThreadRepository) AutoFollow(threadRootID, userID uuid.UUID) error {
+	query := `
+		INSERT INTO thread_follows (thread_root_id, user_id, is_following, created_at, updated_at)
+		VALUES ($1, $2, true, $3, $3)
+		ON CONFLICT (thread_root_id, user_id) DO NOTHING
+	`
+	_, err := r.db.Exec(query, threadRootID, userID, time.Now())
+	return err
+}
+
+// SetFollowing sets the follow state of a user for a thread
+func (r *ThreadRepository) SetFollowing(threadRootID, userID uuid.UUID, isFollowing bool) error {
+	query := `
+		INSERT INTO thread_follows (thread_root_id, user_id, is_following, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (thread_root_id, user_id) DO UPDATE SET is_following = $3, updated_at = $4
+	`
+	_, err := r.db.Exec(query, threadRootID, userID, isFollowing, time.Now())
+	return err
+}
+
+// IsFollowing checks if a user follows a thread
+func (r *ThreadRepository) IsFollowing(threadRootID, userID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM thread_follows WHERE thread_root_id = $1 AND user_id = $2 AND is_following = true)`
+
+	var exists bool
+	err := r.db.QueryRow(query, threadRootID, userID).Scan(&exists)
+	return exists, err
+}
+
+// GetFollowerIDs gets the users following a thread who are still participants in its conversation
+// Participants who muted the conversation are left out
+func (r *ThreadRepository) GetFollowerIDs(threadRootID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT tf.user_id
+		FROM thread_follows tf
+		JOIN messages m ON m.id = tf.thread_root_id
+		JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id AND cp.user_id = tf.user_id
+		WHERE tf.thread_root_id = $1 AND tf.is_following = true AND cp.is_muted = false
+	`
+
+	rows, err := r.db.Query(query, threadRootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
@@ -274,3 +274,44 @@ func (r *UserRepository) UpdateOnlineStatus(userID uuid.UUID, isOnline bool) err
 	
 	return nil
 }
+
+// GetUsersByHandle gets the users mentioned with a lowercase @handle: the local part of their email,
+// their display name without spaces or their full email address
+func (r *UserRepository) GetUsersByHandle(handle string) ([]*models.User, error) {
+	query := `
+		SELECT id, email, password_hash, display_name, avatar_url, is_online, last_seen, created_at, updated_at
+		FROM users
+		WHERE LOWER(email) = $1
+		   OR LOWER(SPLIT_PART(email, '@', 1)) = $1
+		   OR LOWER(REGEXP_REPLACE(display_name, '\s+', '', 'g')) = $1
+		LIMIT 2
+	`
+
+	rows, err := r.db.Query(query, handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users by handle: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.PasswordHash,
+			&user.DisplayName,
+			&user.AvatarURL,
+			&user.IsOnline,
+			&user.LastSeen,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,89 @@
+package richtext
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PlainText renders a document as plain text, used for search, previews and notifications
+// Formatting is dropped, list items keep their marker and links keep their URL
+func PlainText(doc *Node) string {
+	var b strings.Builder
+	writeBlocks(&b, doc.Children, "")
+	return strings.TrimSpace(b.String())
+}
+
+// writeBlocks writes blocks on their own lines, each line starting with the prefix
+func writeBlocks(b *strings.Builder, blocks []*Node, prefix string) {
+	for _, block := range blocks {
+		switch block.Type {
+		case NodeParagraph:
+			writeLines(b, inlineText(block.Children), prefix)
+
+		case NodeCodeBlock:
+			writeLines(b, strings.TrimRight(block.Text, "\n"), prefix)
+
+		case NodeList:
+			number := block.Start
+			for _, item := range block.Children {
+				marker := "- "
+				if block.Ordered {
+					marker = strconv.Itoa(number) + ". "
+					number++
+				}
+				writeListItem(b, item, prefix, marker)
+			}
+
+		case NodeQuote:
+			writeBlocks(b, block.Children, prefix+"> ")
+
+		default:
+			writeBlocks(b, block.Children, prefix)
+		}
+	}
+}
+
+// writeListItem writes a list item, its first line after the marker and the rest indented
+func writeListItem(b *strings.Builder, item *Node, prefix, marker string) {
+	var content strings.Builder
+	writeBlocks(&content, item.Children, "")
+
+	indent := strings.Repeat(" ", len(marker))
+	lines := strings.Split(strings.TrimRight(content.String(), "\n"), "\n")
+	for i, line := range lines {
+		if i == 0 {
+			b.WriteString(prefix + marker + line + "\n")
+			continue
+		}
+		b.WriteString(prefix + indent + line + "\n")
+	}
+}
+
+// writeLines writes text line by line, each line starting with the prefix
+func writeLines(b *strings.Builder, text, prefix string) {
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(prefix + line + "\n")
+	}
+}
+
+// inlineText renders inline nodes as text
+func inlineText(nodes []*Node) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		switch node.Type {
+		case NodeText, NodeCode:
+			b.WriteString(node.Text)
+		case NodeLineBreak:
+			b.WriteString("\n")
+		case NodeLink:
+			label := inlineText(node.Children)
+			b.WriteString(label)
+			if label != node.URL && "mailto:"+label != node.URL {
+				b.WriteString(" (" + node.URL + ")")
+			}
+		default:
+			b.WriteString(inlineText(node.Children))
+		}
+	}
+	return b.String()
+}
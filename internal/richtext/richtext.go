@@ -0,0 +1,253 @@
+package richtext
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Node types of a rich-text document
+const (
+	NodeDocument  = "document"
+	NodeParagraph = "paragraph"
+	NodeText      = "text"
+	NodeBold      = "bold"
+	NodeItalic    = "italic"
+	NodeCode      = "code"
+	NodeCodeBlock = "code_block"
+	NodeLink      = "link"
+	NodeList      = "list"
+	NodeListItem  = "list_item"
+	NodeQuote     = "quote"
+	NodeLineBreak = "line_break"
+)
+
+// maxDepth limits the nesting of lists and quotes, deeper blocks are flattened
+const maxDepth = 8
+
+// maxLanguageLength limits the language hint of code blocks
+const maxLanguageLength = 32
+
+// allowedSchemes lists the URL schemes links may use
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// Node is a node of a rich-text document
+// The tree holds no markup, so clients render it without interpreting HTML
+type Node struct {
+	Type     string  `json:"type"`
+	Text     string  `json:"text,omitempty"`     // Text, code and code blocks
+	Language string  `json:"language,omitempty"` // Code blocks
+	URL      string  `json:"url,omitempty"`      // Links
+	Ordered  bool    `json:"ordered,omitempty"`  // Lists
+	Start    int     `json:"start,omitempty"`    // Ordered lists
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Parse parses a Markdown subset into a document
+// Bold, italics, code, code blocks, links, lists and quotes are kept. Other constructs are
+// reduced to their text: headings become paragraphs, images become links and raw HTML becomes text.
+// Links with a scheme other than http, https or mailto are replaced by their text.
+func Parse(markdown string) *Node {
+	source := []byte(markdown)
+	root := goldmark.DefaultParser().Parse(text.NewReader(source))
+
+	c := &converter{source: source}
+	return &Node{Type: NodeDocument, Children: c.blocks(root, 0)}
+}
+
+// converter converts a goldmark AST into a document
+type converter struct {
+	source []byte
+}
+
+// blocks converts the block children of a node
+func (c *converter) blocks(parent ast.Node, depth int) []*Node {
+	var nodes []*Node
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		nodes = append(nodes, c.block(child, depth)...)
+	}
+	return nodes
+}
+
+// block converts a block node, which may produce no or several nodes when flattened
+func (c *converter) block(node ast.Node, depth int) []*Node {
+	switch n := node.(type) {
+	case *ast.Paragraph, *ast.TextBlock, *ast.Heading:
+		children := c.inlines(n)
+		if len(children) == 0 {
+			return nil
+		}
+		return []*Node{{Type: NodeParagraph, Children: children}}
+
+	case *ast.FencedCodeBlock:
+		return []*Node{{Type: NodeCodeBlock, Text: c.lines(n), Language: sanitizeLanguage(string(n.Language(c.source)))}}
+
+	case *ast.CodeBlock:
+		return []*Node{{Type: NodeCodeBlock, Text: c.lines(n)}}
+
+	case *ast.HTMLBlock:
+		return []*Node{{Type: NodeParagraph, Children: []*Node{{Type: NodeText, Text: strings.TrimRight(c.lines(n), "\n")}}}}
+
+	case *ast.List:
+		if depth >= maxDepth {
+			return c.blocks(n, depth)
+		}
+		list := &Node{Type: NodeList, Ordered: n.IsOrdered()}
+		if n.IsOrdered() {
+			list.Start = n.Start
+		}
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			list.Children = append(list.Children, &Node{Type: NodeListItem, Children: c.blocks(item, depth+1)})
+		}
+		return []*Node{list}
+
+	case *ast.Blockquote:
+		if depth >= maxDepth {
+			return c.blocks(n, depth)
+		}
+		return []*Node{{Type: NodeQuote, Children: c.blocks(n, depth+1)}}
+
+	case *ast.ThematicBreak:
+		return nil
+
+	default:
+		return c.blocks(n, depth)
+	}
+}
+
+// lines joins the raw lines of a code or HTML block
+func (c *converter) lines(node ast.Node) string {
+	var buf bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		buf.Write(segment.Value(c.source))
+	}
+	return buf.String()
+}
+
+// inlines converts the inline children of a node, merging adjacent text
+func (c *converter) inlines(parent ast.Node) []*Node {
+	var nodes []*Node
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		for _, node := range c.inline(child) {
+			last := len(nodes) - 1
+			if node.Type == NodeText && last >= 0 && nodes[last].Type == NodeText {
+				nodes[last].Text += node.Text
+				continue
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// inline converts an inline node, unwrapping unsupported or unsafe nodes into their children
+func (c *converter) inline(node ast.Node) []*Node {
+	switch n := node.(type) {
+	case *ast.Text:
+		nodes := []*Node{{Type: NodeText, Text: string(n.Value(c.source))}}
+		if n.SoftLineBreak() || n.HardLineBreak() {
+			nodes = append(nodes, &Node{Type: NodeLineBreak})
+		}
+		return nodes
+
+	case *ast.String:
+		return []*Node{{Type: NodeText, Text: string(n.Value)}}
+
+	case *ast.Emphasis:
+		nodeType := NodeItalic
+		if n.Level >= 2 {
+			nodeType = NodeBold
+		}
+		return []*Node{{Type: nodeType, Children: c.inlines(n)}}
+
+	case *ast.CodeSpan:
+		return []*Node{{Type: NodeCode, Text: c.rawText(n)}}
+
+	case *ast.Link:
+		return c.link(string(n.Destination), c.inlines(n))
+
+	case *ast.Image:
+		return c.link(string(n.Destination), c.inlines(n))
+
+	case *ast.AutoLink:
+		label := string(n.Label(c.source))
+		destination := string(n.URL(c.source))
+		if n.AutoLinkType == ast.AutoLinkEmail {
+			destination = "mailto:" + destination
+		}
+		return c.link(destination, []*Node{{Type: NodeText, Text: label}})
+
+	case *ast.RawHTML:
+		var buf bytes.Buffer
+		for i := 0; i < n.Segments.Len(); i++ {
+			segment := n.Segments.At(i)
+			buf.Write(segment.Value(c.source))
+		}
+		return []*Node{{Type: NodeText, Text: buf.String()}}
+
+	default:
+		return c.inlines(n)
+	}
+}
+
+// link creates a link node, or returns its children if the destination is not safe
+func (c *converter) link(destination string, children []*Node) []*Node {
+	safe, ok := SafeURL(destination)
+	if !ok {
+		return children
+	}
+	if len(children) == 0 {
+		children = []*Node{{Type: NodeText, Text: safe}}
+	}
+	return []*Node{{Type: NodeLink, URL: safe, Children: children}}
+}
+
+// rawText concatenates the text of the children of a code span
+func (c *converter) rawText(node ast.Node) string {
+	var buf bytes.Buffer
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Text:
+			buf.Write(n.Value(c.source))
+		case *ast.String:
+			buf.Write(n.Value)
+		}
+	}
+	return buf.String()
+}
+
+// SafeURL checks that a link destination is an absolute URL with an allowed scheme
+// Returns the normalized URL
+func SafeURL(raw string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !allowedSchemes[strings.ToLower(parsed.Scheme)] {
+		return "", false
+	}
+	if parsed.Scheme != "mailto" && parsed.Host == "" {
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+// sanitizeLanguage keeps a code block language hint only if it is a plain identifier like go or c++
+func sanitizeLanguage(language string) string {
+	if len(language) > maxLanguageLength {
+		return ""
+	}
+	for _, r := range language {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("+#._-", r)) {
+			return ""
+		}
+	}
+	return language
+}
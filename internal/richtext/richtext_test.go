@@ -0,0 +1,268 @@
+package richtext
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{raw: "https://example.com/path?q=1#top", want: "https://example.com/path?q=1#top", ok: true},
+		{raw: "http://example.com", want: "http://example.com", ok: true},
+		{raw: "  https://example.com  ", want: "https://example.com", ok: true},
+		{raw: "HTTPS://Example.com", want: "https://Example.com", ok: true},
+		{raw: "mailto:someone@example.com", want: "mailto:someone@example.com", ok: true},
+		{raw: "MailTo:someone@example.com", want: "mailto:someone@example.com", ok: true},
+		{raw: "javascript:alert(1)"},
+		{raw: "JaVaScRiPt:alert(1)"},
+		{raw: " javascript:alert(1)"},
+		{raw: "java\tscript:alert(1)"},
+		{raw: "vbscript:msgbox(1)"},
+		{raw: "data:text/html;base64,PHNjcmlwdD4="},
+		{raw: "file:///etc/passwd"},
+		{raw: "//evil.example/path"},
+		{raw: "/relative/path"},
+		{raw: "example.com"},
+		{raw: "https:evil"},
+		{raw: "http://"},
+		{raw: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := SafeURL(tt.raw)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("SafeURL(%q) = %q, %v, want %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func doc(children ...*Node) *Node { return &Node{Type: NodeDocument, Children: children} }
+
+func paragraph(children ...*Node) *Node { return &Node{Type: NodeParagraph, Children: children} }
+
+func plain(value string) *Node { return &Node{Type: NodeText, Text: value} }
+
+func bold(children ...*Node) *Node { return &Node{Type: NodeBold, Children: children} }
+
+func italic(children ...*Node) *Node { return &Node{Type: NodeItalic, Children: children} }
+
+func code(value string) *Node { return &Node{Type: NodeCode, Text: value} }
+
+func link(url string, children ...*Node) *Node {
+	return &Node{Type: NodeLink, URL: url, Children: children}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     *Node
+	}{
+		{
+			name:     "plain text",
+			markdown: "hello world",
+			want:     doc(paragraph(plain("hello world"))),
+		},
+		{
+			name:     "nested emphasis",
+			markdown: "**bold _and italic_ text**",
+			want:     doc(paragraph(bold(plain("bold "), italic(plain("and italic")), plain(" text")))),
+		},
+		{
+			name:     "italic around bold",
+			markdown: "*a **b** c*",
+			want:     doc(paragraph(italic(plain("a "), bold(plain("b")), plain(" c")))),
+		},
+		{
+			name:     "code span keeps markup",
+			markdown: "run `**not bold** <b>` now",
+			want:     doc(paragraph(plain("run "), code("**not bold** <b>"), plain(" now"))),
+		},
+		{
+			name:     "code span with backticks",
+			markdown: "``a ` b``",
+			want:     doc(paragraph(code("a ` b"))),
+		},
+		{
+			name:     "code span in emphasis",
+			markdown: "_see `x`_",
+			want:     doc(paragraph(italic(plain("see "), code("x")))),
+		},
+		{
+			name:     "link",
+			markdown: "[docs](https://example.com/docs)",
+			want:     doc(paragraph(link("https://example.com/docs", plain("docs")))),
+		},
+		{
+			name:     "formatted link label",
+			markdown: "[**docs**](https://example.com)",
+			want:     doc(paragraph(link("https://example.com", bold(plain("docs"))))),
+		},
+		{
+			name:     "mailto link",
+			markdown: "[write](mailto:someone@example.com)",
+			want:     doc(paragraph(link("mailto:someone@example.com", plain("write")))),
+		},
+		{
+			name:     "javascript link",
+			markdown: "[click](javascript:alert(1))",
+			want:     doc(paragraph(plain("click"))),
+		},
+		{
+			name:     "mixed case javascript link",
+			markdown: "[click](JaVaScRiPt:alert(1))",
+			want:     doc(paragraph(plain("click"))),
+		},
+		{
+			name:     "formatted label of an unsafe link",
+			markdown: "[**click**](javascript:alert(1))",
+			want:     doc(paragraph(bold(plain("click")))),
+		},
+		{
+			name:     "scheme-relative link",
+			markdown: "[click](//evil.example/path)",
+			want:     doc(paragraph(plain("click"))),
+		},
+		{
+			name:     "reference link",
+			markdown: "[click][x]\n\n[x]: javascript:alert(1)",
+			want:     doc(paragraph(plain("click"))),
+		},
+		{
+			name:     "autolink",
+			markdown: "<https://example.com>",
+			want:     doc(paragraph(link("https://example.com", plain("https://example.com")))),
+		},
+		{
+			name:     "email autolink",
+			markdown: "<someone@example.com>",
+			want:     doc(paragraph(link("mailto:someone@example.com", plain("someone@example.com")))),
+		},
+		{
+			name:     "javascript autolink",
+			markdown: "<javascript:alert(1)>",
+			want:     doc(paragraph(plain("javascript:alert(1)"))),
+		},
+		{
+			name:     "image becomes a link",
+			markdown: "![cat](https://example.com/cat.png)",
+			want:     doc(paragraph(link("https://example.com/cat.png", plain("cat")))),
+		},
+		{
+			name:     "image without alt text",
+			markdown: "![](https://example.com/cat.png)",
+			want:     doc(paragraph(link("https://example.com/cat.png", plain("https://example.com/cat.png")))),
+		},
+		{
+			name:     "inline HTML stays text",
+			markdown: "a <img src=x onerror=alert(1)> b",
+			want:     doc(paragraph(plain("a <img src=x onerror=alert(1)> b"))),
+		},
+		{
+			name:     "HTML block stays text",
+			markdown: "<script>alert(1)</script>",
+			want:     doc(paragraph(plain("<script>alert(1)</script>"))),
+		},
+		{
+			name:     "heading becomes a paragraph",
+			markdown: "# Title",
+			want:     doc(paragraph(plain("Title"))),
+		},
+		{
+			name:     "line break",
+			markdown: "one\ntwo",
+			want:     doc(paragraph(plain("one"), &Node{Type: NodeLineBreak}, plain("two"))),
+		},
+		{
+			name:     "fenced code block",
+			markdown: "```go\nfmt.Println(\"*hi*\")\n```",
+			want:     doc(&Node{Type: NodeCodeBlock, Language: "go", Text: "fmt.Println(\"*hi*\")\n"}),
+		},
+		{
+			name:     "code block language with markup",
+			markdown: "```<script>\nx\n```",
+			want:     doc(&Node{Type: NodeCodeBlock, Text: "x\n"}),
+		},
+		{
+			name:     "ordered list",
+			markdown: "3. three\n4. four",
+			want: doc(&Node{Type: NodeList, Ordered: true, Start: 3, Children: []*Node{
+				{Type: NodeListItem, Children: []*Node{paragraph(plain("three"))}},
+				{Type: NodeListItem, Children: []*Node{paragraph(plain("four"))}},
+			}}),
+		},
+		{
+			name:     "quote",
+			markdown: "> quoted *text*",
+			want:     doc(&Node{Type: NodeQuote, Children: []*Node{paragraph(plain("quoted "), italic(plain("text")))}}),
+		},
+		{
+			name:     "thematic break is dropped",
+			markdown: "a\n\n---\n\nb",
+			want:     doc(paragraph(plain("a")), paragraph(plain("b"))),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.markdown)
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("Parse(%q) =\n%s\nwant\n%s", tt.markdown, gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestParseFlattensDeepNesting(t *testing.T) {
+	got := Parse(strings.Repeat("> ", 20) + "deep")
+
+	depth := 0
+	node := got.Children[0]
+	for node.Type == NodeQuote {
+		depth++
+		node = node.Children[0]
+	}
+
+	if depth != maxDepth {
+		t.Errorf("got %d nested quotes, want %d", depth, maxDepth)
+	}
+	if !reflect.DeepEqual(node, paragraph(plain("deep"))) {
+		t.Errorf("innermost node = %+v, want the paragraph", node)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{name: "formatting is dropped", markdown: "**bold** and _italic_ and `code`", want: "bold and italic and code"},
+		{name: "link keeps its URL", markdown: "[docs](https://example.com)", want: "docs (https://example.com)"},
+		{name: "autolink is not repeated", markdown: "<https://example.com>", want: "https://example.com"},
+		{name: "email is not repeated", markdown: "<someone@example.com>", want: "someone@example.com"},
+		{name: "unsafe link keeps its text only", markdown: "[click](javascript:alert(1))", want: "click"},
+		{name: "lists keep their markers", markdown: "- a\n- b\n\n2. c\n3. d", want: "- a\n- b\n2. c\n3. d"},
+		{name: "list items are indented", markdown: "10. one\n    two", want: "10. one\n    two"},
+		{name: "quotes keep their marker", markdown: "> a\n>\n> b", want: "> a\n> b"},
+		{name: "code blocks keep their lines", markdown: "```\na\n  b\n```", want: "a\n  b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(Parse(tt.markdown)); got != tt.want {
+				t.Errorf("PlainText(Parse(%q)) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAttachmentRoutes sets up attachment routes
+func SetupAttachmentRoutes(router *gin.Engine, attachmentHandler *handlers.AttachmentHandler, authMiddleware gin.HandlerFunc) {
+	// Attachment routes group
+	attachmentRoutes := router.Group("/api/v1")
+	attachmentRoutes.Use(authMiddleware) // Require authentication
+
+	{
+		attachmentRoutes.POST("/conversations/:id/attachments", attachmentHandler.UploadAttachment)    // Upload file
+		attachmentRoutes.GET("/attachments/:id", attachmentHandler.DownloadAttachment)                 // Download file
+		attachmentRoutes.GET("/attachments/:id/thumbnails/:size", attachmentHandler.DownloadThumbnail) // Download image thumbnail
+	}
+}
@@ -14,13 +14,60 @@ func SetupChatRoutes(router *gin.Engine, chatHandler *handlers.ChatHandler, auth
 
 	{
 		// Conversation management
-		chatRoutes.POST("", chatHandler.CreateConversation) // Create conversation
-		chatRoutes.GET("", chatHandler.GetConversations)    // Get user conversations
-		chatRoutes.GET("/:id", chatHandler.GetConversation) // Get specific conversation
+		chatRoutes.POST("", chatHandler.CreateConversation)      // Create conversation
+		chatRoutes.GET("", chatHandler.GetConversations)         // Get user conversations
+		chatRoutes.GET("/:id", chatHandler.GetConversation)      // Get specific conversation
+		chatRoutes.PATCH("/:id", chatHandler.UpdateConversation) // Rename conversation
+
+		// Members
+		chatRoutes.POST("/:id/participants", chatHandler.AddParticipants)                   // Add members
+		chatRoutes.DELETE("/:id/participants/:user_id", chatHandler.RemoveParticipant)      // Remove member or leave
+		chatRoutes.PUT("/:id/participants/:user_id/admin", chatHandler.SetParticipantAdmin) // Grant or revoke admin
+
+		// Notification settings
+		chatRoutes.POST("/:id/mute", chatHandler.MuteConversation)     // Mute conversation
+		chatRoutes.DELETE("/:id/mute", chatHandler.UnmuteConversation) // Unmute conversation
+
+		// Disappearing messages
+		chatRoutes.PUT("/:id/message-ttl", chatHandler.UpdateMessageTTL) // Update message TTL
+
+		// Drafts
+		chatRoutes.GET("/:id/draft", chatHandler.GetDraft)      // Get draft
+		chatRoutes.PUT("/:id/draft", chatHandler.SaveDraft)     // Save draft
+		chatRoutes.DELETE("/:id/draft", chatHandler.ClearDraft) // Clear draft
 
 		// Message management
-		chatRoutes.POST("/:id/messages", chatHandler.SendMessage)                        // Send message
-		chatRoutes.GET("/:id/messages", chatHandler.GetMessages)                         // Get messages
-		chatRoutes.POST("/:id/messages/:message_id/read", chatHandler.MarkMessageAsRead) // Mark as read
+		chatRoutes.POST("/:id/messages", chatHandler.SendMessage)                            // Send message
+		chatRoutes.GET("/:id/messages", chatHandler.GetMessages)                             // Get messages
+		chatRoutes.POST("/:id/messages/:message_id/read", chatHandler.MarkMessageAsRead)     // Mark as read
+		chatRoutes.GET("/:id/messages/:message_id/receipts", chatHandler.GetMessageReceipts) // Get delivery receipts
+
+		// Threads
+		chatRoutes.GET("/:id/messages/:message_id/thread", chatHandler.GetThread)         // Get thread replies
+		chatRoutes.POST("/:id/messages/:message_id/follow", chatHandler.FollowThread)     // Follow thread
+		chatRoutes.DELETE("/:id/messages/:message_id/follow", chatHandler.UnfollowThread) // Unfollow thread
+
+		// Reactions
+		chatRoutes.POST("/:id/messages/:message_id/reactions", chatHandler.AddReaction)             // Add reaction
+		chatRoutes.DELETE("/:id/messages/:message_id/reactions/:emoji", chatHandler.RemoveReaction) // Remove reaction
+
+		// Pins
+		chatRoutes.GET("/:id/pins", chatHandler.GetPinnedMessages)                   // Get pinned messages
+		chatRoutes.POST("/:id/messages/:message_id/pin", chatHandler.PinMessage)     // Pin message
+		chatRoutes.DELETE("/:id/messages/:message_id/pin", chatHandler.UnpinMessage) // Unpin message
+
+		// Bookmarks
+		chatRoutes.POST("/:id/messages/:message_id/bookmark", chatHandler.BookmarkMessage)  // Bookmark message or update note
+		chatRoutes.DELETE("/:id/messages/:message_id/bookmark", chatHandler.RemoveBookmark) // Remove bookmark
+
+		// Snippets
+		chatRoutes.GET("/:id/messages/:message_id/snippet", chatHandler.GetSnippet)          // Get highlighted snippet
+		chatRoutes.GET("/:id/messages/:message_id/snippet/raw", chatHandler.DownloadSnippet) // Download snippet code
+
+		// Polls
+		chatRoutes.POST("/:id/polls", chatHandler.CreatePoll)               // Create poll
+		chatRoutes.GET("/:id/polls/:poll_id", chatHandler.GetPoll)          // Get poll results
+		chatRoutes.PUT("/:id/polls/:poll_id/votes", chatHandler.VotePoll)   // Vote in poll
+		chatRoutes.POST("/:id/polls/:poll_id/close", chatHandler.ClosePoll) // Close poll early
 	}
 }
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCommandRoutes sets up slash command routes
+func SetupCommandRoutes(router *gin.Engine, commandHandler *handlers.CommandHandler, authMiddleware gin.HandlerFunc) {
+	// Slash command routes group
+	commandRoutes := router.Group("/api/v1/commands")
+	commandRoutes.Use(authMiddleware) // Require authentication, workspace admin checks are done by the service
+
+	{
+		commandRoutes.GET("", commandHandler.GetCommands)            // List commands
+		commandRoutes.POST("", commandHandler.CreateCommand)         // Register third-party command
+		commandRoutes.DELETE("/:name", commandHandler.DeleteCommand) // Delete third-party command
+	}
+}
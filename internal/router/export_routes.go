@@ -0,0 +1,20 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupExportRoutes sets up conversation export routes
+func SetupExportRoutes(router *gin.Engine, exportHandler *handlers.ExportHandler, authMiddleware gin.HandlerFunc) {
+	// Export routes group
+	exportRoutes := router.Group("/api/v1")
+	exportRoutes.Use(authMiddleware) // Require authentication
+
+	{
+		exportRoutes.POST("/conversations/:id/exports", exportHandler.CreateExport) // Export conversation
+		exportRoutes.GET("/exports/:id", exportHandler.GetExport)                   // Get export status
+		exportRoutes.GET("/exports/:id/download", exportHandler.DownloadExport)     // Download export file
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupImportRoutes sets up chat history import routes
+func SetupImportRoutes(router *gin.Engine, importHandler *handlers.ImportHandler, authMiddleware gin.HandlerFunc) {
+	// Import routes group
+	importRoutes := router.Group("/api/v1/imports")
+	importRoutes.Use(authMiddleware) // Require authentication
+
+	{
+		importRoutes.POST("/slack", importHandler.ImportSlack) // Import Slack export archive
+	}
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMessageRoutes sets up routes working on messages across conversations
+func SetupMessageRoutes(router *gin.Engine, chatHandler *handlers.ChatHandler, authMiddleware gin.HandlerFunc) {
+	// Message routes group
+	messageRoutes := router.Group("/api/v1/messages")
+	messageRoutes.Use(authMiddleware) // Require authentication
+
+	{
+		messageRoutes.GET("/search", chatHandler.SearchMessages)                  // Search message history
+		messageRoutes.GET("/mentions", chatHandler.GetMentions)                   // Messages mentioning me
+		messageRoutes.GET("/bookmarks", chatHandler.GetBookmarks)                 // My bookmarked messages
+		messageRoutes.GET("/kinds", chatHandler.GetMessageKinds)                  // Message types with a payload
+		messageRoutes.GET("/snippets/languages", chatHandler.GetSnippetLanguages) // Highlighted snippet languages
+		messageRoutes.POST("/forward", chatHandler.ForwardMessages)               // Forward messages to other conversations
+	}
+}
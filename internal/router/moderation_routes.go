@@ -0,0 +1,22 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupModerationRoutes sets up moderation rule and review queue routes
+func SetupModerationRoutes(router *gin.Engine, moderationHandler *handlers.ModerationHandler, authMiddleware gin.HandlerFunc) {
+	// Moderation routes group
+	moderationRoutes := router.Group("/api/v1/moderation")
+	moderationRoutes.Use(authMiddleware) // Require authentication, moderator checks are done by the service
+
+	{
+		moderationRoutes.GET("/rules", moderationHandler.GetRules)               // Get rules
+		moderationRoutes.POST("/rules", moderationHandler.CreateRule)            // Add rule
+		moderationRoutes.DELETE("/rules/:id", moderationHandler.DeleteRule)      // Delete rule
+		moderationRoutes.GET("/flags", moderationHandler.GetFlags)               // Get review queue
+		moderationRoutes.POST("/flags/:id/review", moderationHandler.ReviewFlag) // Dismiss flag or remove message
+	}
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupNotificationRoutes sets up notification routes
+func SetupNotificationRoutes(router *gin.Engine, notificationHandler *handlers.NotificationHandler, authMiddleware gin.HandlerFunc) {
+	// Notification routes group
+	notificationRoutes := router.Group("/api/v1/notifications")
+	notificationRoutes.Use(authMiddleware) // Require authentication
+
+	{
+		notificationRoutes.GET("", notificationHandler.GetNotifications)                 // Get notification feed
+		notificationRoutes.POST("/:id/read", notificationHandler.MarkNotificationAsRead) // Mark as read
+	}
+}
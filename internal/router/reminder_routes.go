@@ -0,0 +1,24 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupReminderRoutes sets up message reminder routes
+func SetupReminderRoutes(router *gin.Engine, reminderHandler *handlers.ReminderHandler, authMiddleware gin.HandlerFunc) {
+	// Reminder routes group
+	reminderRoutes := router.Group("/api/v1")
+	reminderRoutes.Use(authMiddleware) // Require authentication
+
+	{
+		reminderRoutes.POST("/conversations/:id/messages/:message_id/reminders", reminderHandler.CreateReminder) // Remind me about a message
+		reminderRoutes.GET("/reminders", reminderHandler.GetReminders)                                           // Get my reminders
+		reminderRoutes.GET("/reminders/settings", reminderHandler.GetReminderSettings)                           // Get my reminder timezone
+		reminderRoutes.PUT("/reminders/settings", reminderHandler.UpdateReminderSettings)                        // Set my reminder timezone
+		reminderRoutes.GET("/reminders/:id", reminderHandler.GetReminder)                                        // Get reminder
+		reminderRoutes.POST("/reminders/:id/snooze", reminderHandler.SnoozeReminder)                             // Snooze reminder
+		reminderRoutes.DELETE("/reminders/:id", reminderHandler.CancelReminder)                                  // Cancel reminder
+	}
+}
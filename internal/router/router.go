@@ -6,9 +6,13 @@ import (
 	"goswift/internal/cache"
 	"goswift/internal/database"
 	"goswift/internal/handlers"
+	"goswift/internal/jobs"
 	"goswift/internal/middleware"
+	"goswift/internal/moderation"
+	"goswift/internal/payload"
 	"goswift/internal/repository"
 	"goswift/internal/service"
+	"goswift/internal/storage"
 	"goswift/internal/websocket"
 	"goswift/pkg/jwt"
 	"goswift/pkg/utils"
@@ -18,7 +22,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(config *utils.Config, db *database.DB, redisClient *cache.RedisClient) *gin.Engine {
+func SetupRouter(config *utils.Config, db *database.DB, redisClient *cache.RedisClient, fileStorage storage.Storage) *gin.Engine {
 	// Set Gin mode
 	if config.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -41,14 +45,46 @@ func SetupRouter(config *utils.Config, db *database.DB, redisClient *cache.Redis
 	conversationRepo := repository.NewConversationRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	participantRepo := repository.NewParticipantRepository(db)
+	threadRepo := repository.NewThreadRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	reactionRepo := repository.NewReactionRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	mentionRepo := repository.NewMentionRepository(db)
+	pinRepo := repository.NewPinRepository(db)
+	scheduledMessageRepo := repository.NewScheduledMessageRepository(db)
+	pollRepo := repository.NewPollRepository(db)
+	draftRepo := repository.NewDraftRepository(db)
+	deliveryRepo := repository.NewDeliveryRepository(db)
+	bookmarkRepo := repository.NewBookmarkRepository(db)
+	snippetRepo := repository.NewSnippetRepository(db)
+	moderationRepo := repository.NewModerationRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	slackImportRepo := repository.NewSlackImportRepository(db)
+	commandRepo := repository.NewCommandRepository(db)
+	reminderRepo := repository.NewReminderRepository(db)
 
 	// Initialize JWT manager with Redis
 	jwtManager := jwt.NewJWTManager(config.JWTSecret, config.JWTTokenDuration, redisClient)
 
 	// Initialize services
 	authService := service.NewAuthService(userRepo, jwtManager)
-	chatService := service.NewChatService(conversationRepo, messageRepo, participantRepo, userRepo)
+	var classifier moderation.Hook
+	if config.ModerationWebhookURL != "" {
+		classifier = moderation.NewHTTPHook(config.ModerationWebhookURL, config.ModerationWebhookTimeout, config.ModerationWebhookFailClosed)
+	}
+	moderationService := service.NewModerationService(moderationRepo, config.ModeratorUserIDs, classifier)
+	// Message types with a structured payload, new kinds only need to be registered here
+	messageKinds := payload.NewRegistry()
+	messageKinds.MustRegister(payload.Location, payload.Contact)
+	chatService := service.NewChatService(conversationRepo, messageRepo, participantRepo, userRepo, threadRepo, reactionRepo, attachmentRepo, mentionRepo, pinRepo, pollRepo, draftRepo, deliveryRepo, bookmarkRepo, snippetRepo, moderationService, messageKinds, config.MaxPinsPerConversation, config.MaxVoiceDuration, config.MaxSnippetSize)
+	notificationService := service.NewNotificationService(notificationRepo)
+	attachmentService := service.NewAttachmentService(attachmentRepo, participantRepo, fileStorage, config.MaxUploadSize, config.MaxVoiceDuration)
 	userService := service.NewUserService(userRepo)
+	scheduledMessageService := service.NewScheduledMessageService(scheduledMessageRepo, participantRepo)
+	exportService := service.NewExportService(exportRepo, conversationRepo, participantRepo, messageRepo, attachmentRepo, snippetRepo, fileStorage, config.AdminUserIDs, config.ExportSyncMaxMessages, config.ExportRetention)
+	importService := service.NewImportService(slackImportRepo, config.AdminUserIDs, config.ImportMaxArchiveSize)
+	commandService := service.NewCommandService(commandRepo, participantRepo, conversationRepo, userRepo, config.AdminUserIDs, config.SlashCommandTimeout)
+	reminderService := service.NewReminderService(reminderRepo, participantRepo, messageRepo, notificationService, config.DefaultTimezone)
 
 	// Initialize WebSocket manager
 	wsManager := websocket.NewManager()
@@ -57,9 +93,29 @@ func SetupRouter(config *utils.Config, db *database.DB, redisClient *cache.Redis
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler(db, redisClient, config)
 	authHandler := handlers.NewAuthHandler(authService)
-	wsHandler := websocket.NewHandler(wsManager, chatService)
-	chatHandler := handlers.NewChatHandler(chatService, wsHandler)
+	wsHandler := websocket.NewHandler(wsManager, chatService, jwtManager)
+	chatHandler := handlers.NewChatHandler(chatService, notificationService, commandService, reminderService, wsHandler)
+	wsHandler.SetMessagePublisher(chatHandler)
+	wsHandler.SetCommandRunner(chatHandler)
 	userHandler := handlers.NewUserHandler(userService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	scheduledMessageHandler := handlers.NewScheduledMessageHandler(scheduledMessageService)
+	moderationHandler := handlers.NewModerationHandler(moderationService, attachmentService, wsHandler)
+	exportHandler := handlers.NewExportHandler(exportService)
+	importHandler := handlers.NewImportHandler(importService)
+	commandHandler := handlers.NewCommandHandler(commandService)
+	reminderHandler := handlers.NewReminderHandler(reminderService)
+
+	// Start background jobs
+	scheduledMessageDispatcher := jobs.NewScheduledMessageDispatcher(scheduledMessageService, chatService, chatHandler, wsHandler, config.ScheduledMessagePollInterval)
+	go scheduledMessageDispatcher.Start()
+	messagePurger := jobs.NewMessagePurger(chatService, attachmentService, wsHandler, config.MessagePurgeInterval)
+	go messagePurger.Start()
+	exportWorker := jobs.NewExportWorker(exportService, wsHandler, config.ExportPollInterval)
+	go exportWorker.Start()
+	reminderDispatcher := jobs.NewReminderDispatcher(reminderService, wsHandler, config.ReminderPollInterval)
+	go reminderDispatcher.Start()
 
 	// Health check endpoint (root level)
 	r.GET("/health", healthHandler.HealthCheck)
@@ -80,9 +136,36 @@ func SetupRouter(config *utils.Config, db *database.DB, redisClient *cache.Redis
 	// Setup chat routes
 	SetupChatRoutes(r, chatHandler, middleware.AuthMiddleware(jwtManager))
 
+	// Setup message routes
+	SetupMessageRoutes(r, chatHandler, middleware.AuthMiddleware(jwtManager))
+
 	// Setup user routes
 	SetupUserRoutes(r, userHandler, middleware.AuthMiddleware(jwtManager))
 
+	// Setup attachment routes
+	SetupAttachmentRoutes(r, attachmentHandler, middleware.AuthMiddleware(jwtManager))
+
+	// Setup notification routes
+	SetupNotificationRoutes(r, notificationHandler, middleware.AuthMiddleware(jwtManager))
+
+	// Setup scheduled message routes
+	SetupScheduledMessageRoutes(r, scheduledMessageHandler, middleware.AuthMiddleware(jwtManager))
+
+	// Setup moderation routes
+	SetupModerationRoutes(r, moderationHandler, middleware.AuthMiddleware(jwtManager))
+
+	// Setup export routes
+	SetupExportRoutes(r, exportHandler, middleware.AuthMiddleware(jwtManager))
+
+	// Setup import routes
+	SetupImportRoutes(r, importHandler, middleware.AuthMiddleware(jwtManager))
+
+	// Setup slash command routes
+	SetupCommandRoutes(r, commandHandler, middleware.AuthMiddleware(jwtManager))
+
+	// Setup reminder routes
+	SetupReminderRoutes(r, reminderHandler, middleware.AuthMiddleware(jwtManager))
+
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"goswift/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupScheduledMessageRoutes sets up send-later routes
+func SetupScheduledMessageRoutes(router *gin.Engine, scheduledMessageHandler *handlers.ScheduledMessageHandler, authMiddleware gin.HandlerFunc) {
+	// Scheduled message routes group
+	scheduledRoutes := router.Group("/api/v1")
+	scheduledRoutes.Use(authMiddleware) // Require authentication
+
+	{
+		scheduledRoutes.POST("/conversations/:id/scheduled-messages", scheduledMessageHandler.ScheduleMessage) // Schedule message
+		scheduledRoutes.GET("/scheduled-messages", scheduledMessageHandler.GetScheduledMessages)               // Get my scheduled messages
+		scheduledRoutes.GET("/scheduled-messages/:id", scheduledMessageHandler.GetScheduledMessage)            // Get scheduled message
+		scheduledRoutes.PUT("/scheduled-messages/:id", scheduledMessageHandler.UpdateScheduledMessage)         // Update pending schedule
+		scheduledRoutes.DELETE("/scheduled-messages/:id", scheduledMessageHandler.CancelScheduledMessage)      // Cancel schedule
+	}
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"goswift/internal/audioproc"
+	"goswift/internal/imageproc"
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/internal/storage"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// allowedContentTypes lists the sniffed content types accepted for upload
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":         true,
+	"image/png":          true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"application/pdf":    true,
+	"application/zip":    true, // Also covers docx, xlsx and pptx
+	"application/x-gzip": true,
+	"text/plain":         true,
+	"audio/mpeg":         true,
+	"audio/wave":         true,
+	"application/ogg":    true,
+	"video/mp4":          true,
+	"video/webm":         true,
+}
+
+// processedImageTypes lists the content types that are run through image processing
+var processedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// imageExtensions maps the content types produced by image processing to a file extension
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// AttachmentService handles file uploads and downloads
+type AttachmentService struct {
+	attachmentRepo   *repository.AttachmentRepository
+	participantRepo  *repository.ParticipantRepository
+	storage          storage.Storage
+	maxUploadSize    int64
+	maxVoiceDuration time.Duration
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(
+	attachmentRepo *repository.AttachmentRepository,
+	participantRepo *repository.ParticipantRepository,
+	fileStorage storage.Storage,
+	maxUploadSize int64,
+	maxVoiceDuration time.Duration,
+) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo:   attachmentRepo,
+		participantRepo:  participantRepo,
+		storage:          fileStorage,
+		maxUploadSize:    maxUploadSize,
+		maxVoiceDuration: maxVoiceDuration,
+	}
+}
+
+// MaxUploadSize returns the maximum accepted file size in bytes
+func (s *AttachmentService) MaxUploadSize() int64 {
+	return s.maxUploadSize
+}
+
+// Upload stores a file uploaded to a conversation
+// The attachment is linked to a message later, when the message is sent
+func (s *AttachmentService) Upload(ctx context.Context, conversationID, uploaderID uuid.UUID, fileName string, file io.Reader, size int64) (*models.Attachment, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, uploaderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	if size <= 0 || size > s.maxUploadSize {
+		return nil, utils.ErrFileTooLarge
+	}
+
+	// Sniff the content type instead of trusting the client
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return nil, utils.ErrFileTypeNotAllowed
+	}
+
+	// Some M4A recordings are not sniffed as MP4, the container is checked when parsing them
+	isAudio := audioproc.Detect(head)
+	if !allowedContentTypes[contentType] && !isAudio {
+		return nil, utils.ErrFileTypeNotAllowed
+	}
+
+	attachment := &models.Attachment{
+		ID:             uuid.New(),
+		ConversationID: conversationID,
+		UploaderID:     uploaderID,
+		FileName:       sanitizeFileName(fileName),
+		ContentType:    contentType,
+		SizeBytes:      size,
+	}
+	attachment.StorageKey = fmt.Sprintf("attachments/%s/%s", conversationID, attachment.ID)
+
+	if processedImageTypes[contentType] {
+		return s.uploadImage(ctx, attachment, io.MultiReader(bytes.NewReader(head), file))
+	}
+	if isAudio {
+		return s.uploadAudio(ctx, attachment, io.MultiReader(bytes.NewReader(head), file))
+	}
+
+	err = s.storage.Put(ctx, attachment.StorageKey, io.MultiReader(bytes.NewReader(head), file), size, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if err := s.createAttachment(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// uploadImage strips the metadata of an image, generates its thumbnails and stores them all
+func (s *AttachmentService) uploadImage(ctx context.Context, attachment *models.Attachment, file io.Reader) (*models.Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(file, s.maxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if int64(len(data)) > s.maxUploadSize {
+		return nil, utils.ErrFileTooLarge
+	}
+
+	processed, err := imageproc.Process(data)
+	if err != nil {
+		switch err {
+		case imageproc.ErrImageTooLarge:
+			return nil, utils.ErrImageTooLarge
+		case imageproc.ErrUnsupportedImage:
+			return nil, utils.ErrInvalidImage
+		default:
+			return nil, fmt.Errorf("failed to process image: %w", err)
+		}
+	}
+
+	// The image may have been converted to another format
+	if processed.ContentType != attachment.ContentType {
+		extension := filepath.Ext(attachment.FileName)
+		attachment.FileName = strings.TrimSuffix(attachment.FileName, extension) + imageExtensions[processed.ContentType]
+	}
+
+	attachment.ContentType = processed.ContentType
+	attachment.SizeBytes = int64(len(processed.Data))
+	attachment.Width = &processed.Width
+	attachment.Height = &processed.Height
+	attachment.BlurHash = &processed.BlurHash
+
+	err = s.storage.Put(ctx, attachment.StorageKey, bytes.NewReader(processed.Data), attachment.SizeBytes, attachment.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	for _, thumb := range processed.Thumbnails {
+		thumbnail := models.AttachmentThumbnail{
+			Size:        thumb.Name,
+			Width:       thumb.Width,
+			Height:      thumb.Height,
+			ContentType: thumb.ContentType,
+		}
+
+		err := s.storage.Put(ctx, thumbnailStorageKey(attachment, thumbnail.Size), bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType)
+		if err != nil {
+			s.deleteFiles(ctx, attachment)
+			return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+		}
+
+		attachment.Thumbnails = append(attachment.Thumbnails, thumbnail)
+	}
+
+	if err := s.createAttachment(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// uploadAudio reads the duration and waveform of a recording that can be sent as a voice message
+// Other Ogg and MP4 files, like videos or recordings longer than voice messages, are stored as plain files
+func (s *AttachmentService) uploadAudio(ctx context.Context, attachment *models.Attachment, file io.Reader) (*models.Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(file, s.maxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if int64(len(data)) > s.maxUploadSize {
+		return nil, utils.ErrFileTooLarge
+	}
+
+	recording, err := audioproc.Process(data, s.maxVoiceDuration)
+	switch {
+	case err == nil:
+		durationMs := int(recording.Duration.Milliseconds())
+		attachment.ContentType = recording.ContentType
+		attachment.DurationMs = &durationMs
+		attachment.Waveform = recording.Waveform
+	case errors.Is(err, audioproc.ErrInvalidAudio):
+		return nil, utils.ErrInvalidAudio
+	case !allowedContentTypes[attachment.ContentType]: // Other files are kept if their type is allowed
+		return nil, utils.ErrFileTypeNotAllowed
+	}
+
+	attachment.SizeBytes = int64(len(data))
+	err = s.storage.Put(ctx, attachment.StorageKey, bytes.NewReader(data), attachment.SizeBytes, attachment.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if err := s.createAttachment(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// createAttachment records a stored attachment, deleting its files if that fails
+func (s *AttachmentService) createAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.attachmentRepo.CreateAttachment(attachment); err != nil {
+		s.deleteFiles(ctx, attachment)
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	setAttachmentURLs(attachment)
+
+	return nil
+}
+
+// deleteFiles deletes the stored file and thumbnails of an attachment
+func (s *AttachmentService) deleteFiles(ctx context.Context, attachment *models.Attachment) {
+	keys := []string{attachment.StorageKey}
+	for _, thumbnail := range attachment.Thumbnails {
+		keys = append(keys, thumbnailStorageKey(attachment, thumbnail.Size))
+	}
+
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting orphaned file %s: %v", key, err)
+		}
+	}
+}
+
+// DeleteUnreferencedFiles deletes the stored files of deleted attachments
+// Files still shared with other attachments, such as forwarded copies, are kept
+func (s *AttachmentService) DeleteUnreferencedFiles(ctx context.Context, attachments []*models.Attachment) {
+	deleted := make(map[string]bool)
+	for _, attachment := range attachments {
+		if deleted[attachment.StorageKey] {
+			continue
+		}
+
+		referenced, err := s.attachmentRepo.IsStorageKeyReferenced(attachment.StorageKey)
+		if err != nil {
+			log.Printf("Error checking references to file %s: %v", attachment.StorageKey, err)
+			continue
+		}
+		if referenced {
+			continue
+		}
+
+		s.deleteFiles(ctx, attachment)
+		deleted[attachment.StorageKey] = true
+	}
+}
+
+// Open opens an attachment for download if the user participates in its conversation
+func (s *AttachmentService) Open(ctx context.Context, attachmentID, userID uuid.UUID) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.getAccessibleAttachment(attachmentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := s.openFile(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, reader, nil
+}
+
+// OpenThumbnail opens a thumbnail of an image attachment for download
+func (s *AttachmentService) OpenThumbnail(ctx context.Context, attachmentID, userID uuid.UUID, size string) (*models.AttachmentThumbnail, io.ReadCloser, error) {
+	attachment, err := s.getAccessibleAttachment(attachmentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range attachment.Thumbnails {
+		thumbnail := &attachment.Thumbnails[i]
+		if thumbnail.Size != size {
+			continue
+		}
+
+		reader, err := s.openFile(ctx, thumbnailStorageKey(attachment, size))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return thumbnail, reader, nil
+	}
+
+	return nil, nil, utils.ErrThumbnailNotFound
+}
+
+// getAccessibleAttachment gets an attachment if the user participates in its conversation
+func (s *AttachmentService) getAccessibleAttachment(attachmentID, userID uuid.UUID) (*models.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetAttachmentByID(attachmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	isParticipant, err := s.participantRepo.IsParticipant(attachment.ConversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	// Unsent uploads are only visible to their uploader
+	if !isParticipant || (attachment.MessageID == nil && attachment.UploaderID != userID) {
+		return nil, utils.ErrNotParticipant
+	}
+
+	setAttachmentURLs(attachment)
+
+	return attachment, nil
+}
+
+// openFile opens a stored file
+func (s *AttachmentService) openFile(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := s.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, utils.ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return reader, nil
+}
+
+// attachmentURL returns the authorized download URL of an attachment
+func attachmentURL(id uuid.UUID) string {
+	return "/api/v1/attachments/" + id.String()
+}
+
+// setAttachmentURLs sets the download URLs of an attachment and its thumbnails
+func setAttachmentURLs(attachment *models.Attachment) {
+	attachment.URL = attachmentURL(attachment.ID)
+	for i := range attachment.Thumbnails {
+		attachment.Thumbnails[i].URL = attachment.URL + "/thumbnails/" + attachment.Thumbnails[i].Size
+	}
+}
+
+// thumbnailStorageKey returns the storage key of a thumbnail of an attachment
+func thumbnailStorageKey(attachment *models.Attachment, size string) string {
+	return attachment.StorageKey + "_thumb_" + size
+}
+
+// sanitizeFileName strips directories and control characters from an uploaded file name
+func sanitizeFileName(fileName string) string {
+	fileName = filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	fileName = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, fileName)
+
+	if fileName == "" || fileName == "." || fileName == "/" {
+		return "file"
+	}
+
+	if runes := []rune(fileName); len(runes) > 255 {
+		fileName = string(runes[len(runes)-255:])
+	}
+
+	return fileName
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// BookmarkMessage bookmarks a message of a conversation the user participates in
+// Bookmarking a message again replaces its note. Returns false if the message was already bookmarked
+func (s *ChatService) BookmarkMessage(conversationID, messageID, userID uuid.UUID, note string) (*models.Bookmark, bool, error) {
+	message, err := s.getConversationMessage(conversationID, messageID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	bookmark := &models.Bookmark{
+		UserID:         userID,
+		MessageID:      message.ID,
+		ConversationID: message.ConversationID,
+		Note:           strings.TrimSpace(note),
+	}
+
+	created, err := s.bookmarkRepo.SaveBookmark(bookmark)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save bookmark: %w", err)
+	}
+
+	return bookmark, created, nil
+}
+
+// RemoveBookmark removes the bookmark of a user on a message
+// Users who left the conversation can still remove their bookmarks
+func (s *ChatService) RemoveBookmark(conversationID, messageID, userID uuid.UUID) error {
+	deleted, err := s.bookmarkRepo.DeleteBookmark(userID, conversationID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to remove bookmark: %w", err)
+	}
+	if !deleted {
+		return utils.ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// GetBookmarks gets a page of the bookmarks of a user across their conversations, most recently bookmarked first
+// The cursor is the next_cursor value of a previous page
+func (s *ChatService) GetBookmarks(userID uuid.UUID, limit int, cursor string) (*models.BookmarkPage, error) {
+	var beforeCreatedAt *time.Time
+	var beforeID uuid.UUID
+	if cursor != "" {
+		createdAt, id, err := decodeMessageCursor(cursor)
+		if err != nil {
+			return nil, utils.ErrInvalidCursor
+		}
+		beforeCreatedAt, beforeID = &createdAt, id
+	}
+
+	// Fetch one extra bookmark to know if there is a next page
+	bookmarks, messages, err := s.bookmarkRepo.GetBookmarks(userID, beforeCreatedAt, beforeID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks: %w", err)
+	}
+
+	page := &models.BookmarkPage{Bookmarks: []*models.Bookmark{}}
+	if len(bookmarks) > limit {
+		bookmarks, messages = bookmarks[:limit], messages[:limit]
+		last := bookmarks[len(bookmarks)-1]
+		page.NextCursor = encodeMessageCursor(last.CreatedAt, last.ID)
+	}
+
+	responses := make([]*models.MessageResponse, 0, len(messages))
+	for i, message := range messages {
+		bookmarks[i].Message = newMessageResponse(message)
+		responses = append(responses, bookmarks[i].Message)
+	}
+
+	if err := s.enrichMessages(responses, userID); err != nil {
+		return nil, err
+	}
+
+	page.Bookmarks = append(page.Bookmarks, bookmarks...)
+	return page, nil
+}
@@ -1,12 +1,22 @@
 package service
 
 import (
+	"database/sql"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"goswift/internal/models"
+	"goswift/internal/moderation"
+	"goswift/internal/payload"
 	"goswift/internal/repository"
+	"goswift/internal/richtext"
+	"goswift/pkg/utils"
 
 	"github.com/google/uuid"
 )
@@ -16,6 +26,23 @@ type ChatService struct {
 	messageRepo      *repository.MessageRepository
 	participantRepo  *repository.ParticipantRepository
 	userRepo         *repository.UserRepository
+	threadRepo       *repository.ThreadRepository
+	reactionRepo     *repository.ReactionRepository
+	attachmentRepo   *repository.AttachmentRepository
+	mentionRepo      *repository.MentionRepository
+	pinRepo          *repository.PinRepository
+	pollRepo         *repository.PollRepository
+	draftRepo        *repository.DraftRepository
+	deliveryRepo     *repository.DeliveryRepository
+	bookmarkRepo     *repository.BookmarkRepository
+	snippetRepo      *repository.SnippetRepository
+
+	moderationService *ModerationService
+	messageKinds      *payload.Registry
+
+	maxPinsPerConversation int
+	maxVoiceDuration       time.Duration
+	maxSnippetSize         int
 }
 
 func NewChatService(
@@ -23,29 +50,101 @@ func NewChatService(
 	messageRepo *repository.MessageRepository,
 	participantRepo *repository.ParticipantRepository,
 	userRepo *repository.UserRepository,
+	threadRepo *repository.ThreadRepository,
+	reactionRepo *repository.ReactionRepository,
+	attachmentRepo *repository.AttachmentRepository,
+	mentionRepo *repository.MentionRepository,
+	pinRepo *repository.PinRepository,
+	pollRepo *repository.PollRepository,
+	draftRepo *repository.DraftRepository,
+	deliveryRepo *repository.DeliveryRepository,
+	bookmarkRepo *repository.BookmarkRepository,
+	snippetRepo *repository.SnippetRepository,
+	moderationService *ModerationService,
+	messageKinds *payload.Registry,
+	maxPinsPerConversation int,
+	maxVoiceDuration time.Duration,
+	maxSnippetSize int,
 ) *ChatService {
 	return &ChatService{
 		conversationRepo: conversationRepo,
 		messageRepo:      messageRepo,
 		participantRepo:  participantRepo,
 		userRepo:         userRepo,
+		threadRepo:       threadRepo,
+		reactionRepo:     reactionRepo,
+		attachmentRepo:   attachmentRepo,
+		mentionRepo:      mentionRepo,
+		pinRepo:          pinRepo,
+		pollRepo:         pollRepo,
+		draftRepo:        draftRepo,
+		deliveryRepo:     deliveryRepo,
+		bookmarkRepo:     bookmarkRepo,
+		snippetRepo:      snippetRepo,
+
+		moderationService: moderationService,
+		messageKinds:      messageKinds,
+
+		maxPinsPerConversation: maxPinsPerConversation,
+		maxVoiceDuration:       maxVoiceDuration,
+		maxSnippetSize:         maxSnippetSize,
+	}
+}
+
+// newMessageResponse converts a message to its response format
+func newMessageResponse(msg *models.Message) *models.MessageResponse {
+	var forwardedFrom *models.ForwardedFrom
+	if msg.ForwardedFromCreatedAt != nil {
+		forwardedFrom = &models.ForwardedFrom{
+			MessageID: msg.ForwardedFromMessageID,
+			SenderID:  msg.ForwardedFromSenderID,
+			CreatedAt: *msg.ForwardedFromCreatedAt,
+		}
+		if msg.ForwardedFromSenderName != nil {
+			forwardedFrom.SenderName = *msg.ForwardedFromSenderName
+		}
+	}
+
+	return &models.MessageResponse{
+		ID:             msg.ID,
+		ConversationID: msg.ConversationID,
+		SenderID:       msg.SenderID,
+		Content:        msg.Content,
+		MessageType:    msg.MessageType,
+		IsRead:         msg.IsRead,
+		CreatedAt:      msg.CreatedAt,
+		UpdatedAt:      msg.UpdatedAt,
+		SenderName:     msg.SenderName,
+		ReplyToID:      msg.ReplyToID,
+		ThreadRootID:   msg.ThreadRootID,
+		ReplyCount:     msg.ReplyCount,
+		LastReplyAt:    msg.LastReplyAt,
+		LastReplyBy:    msg.LastReplyBy,
+		ForwardedFrom:  forwardedFrom,
+		ExpiresAt:      msg.ExpiresAt,
+		ClientMsgID:    msg.ClientMsgID,
+		RichContent:    msg.RichContent,
+		SystemEvent:    msg.SystemEvent,
+		EditedAt:       msg.EditedAt,
+		Payload:        msg.Payload,
 	}
 }
 
 // CreateConversation creates a new conversation
-func (s *ChatService) CreateConversation(req *models.CreateConversationRequest) (*models.ConversationResponse, error) {
+// Returns the system message recording it, which is nil if it could not be created
+func (s *ChatService) CreateConversation(req *models.CreateConversationRequest) (*models.ConversationResponse, *models.MessageResponse, error) {
 	// Validate that all users exist
 	for _, userID := range req.UserIDs {
 		_, err := s.userRepo.GetUserByID(userID)
 		if err != nil {
-			return nil, fmt.Errorf("user %s not found", userID)
+			return nil, nil, fmt.Errorf("user %s not found", userID)
 		}
 	}
 
 	// Validate that creator exists
-	_, err := s.userRepo.GetUserByID(req.CreatedBy)
+	creator, err := s.userRepo.GetUserByID(req.CreatedBy)
 	if err != nil {
-		return nil, fmt.Errorf("creator %s not found", req.CreatedBy)
+		return nil, nil, fmt.Errorf("creator %s not found", req.CreatedBy)
 	}
 
 	// Create conversation
@@ -57,7 +156,7 @@ func (s *ChatService) CreateConversation(req *models.CreateConversationRequest)
 
 	err = s.conversationRepo.CreateConversation(conversation)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create conversation: %w", err)
+		return nil, nil, fmt.Errorf("failed to create conversation: %w", err)
 	}
 
 	// Add creator as participant first
@@ -69,7 +168,7 @@ func (s *ChatService) CreateConversation(req *models.CreateConversationRequest)
 
 	err = s.participantRepo.AddParticipant(creatorParticipant)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add creator as participant: %w", err)
+		return nil, nil, fmt.Errorf("failed to add creator as participant: %w", err)
 	}
 
 	// Add other participants
@@ -87,35 +186,55 @@ func (s *ChatService) CreateConversation(req *models.CreateConversationRequest)
 
 		err := s.participantRepo.AddParticipant(participant)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add participant %s: %w", userID, err)
+			return nil, nil, fmt.Errorf("failed to add participant %s: %w", userID, err)
 		}
 	}
 
 	// Get participants for response
 	participants, err := s.participantRepo.GetParticipantsByConversationID(conversation.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get participants: %w", err)
+		return nil, nil, fmt.Errorf("failed to get participants: %w", err)
 	}
 
 	// Convert to response format
 	users := make([]models.User, 0, len(participants))
+	targets := make([]models.SystemEventUser, 0, len(participants))
 	for _, p := range participants {
 		if p.User != nil {
 			users = append(users, *p.User)
+			if p.UserID != req.CreatedBy {
+				targets = append(targets, models.NewSystemEventUser(p.User))
+			}
 		}
 	}
 
+	event := &models.SystemEvent{
+		Event:    models.SystemEventConversationCreated,
+		Targets:  targets,
+		NewValue: conversation.Name,
+	}
+	content := fmt.Sprintf("%s started the conversation", creator.DisplayName)
+	if conversation.Type == "group" {
+		content = fmt.Sprintf("%s created the group %q", creator.DisplayName, conversation.Name)
+	}
+
+	systemMessage, err := s.createSystemMessage(conversation.ID, creator, event, content)
+	if err != nil {
+		log.Printf("Error recording creation of conversation %s: %v", conversation.ID, err)
+	}
+
 	response := &models.ConversationResponse{
 		ID:           conversation.ID,
 		Name:         conversation.Name,
 		Type:         conversation.Type,
+		Topic:        conversation.Topic,
 		CreatedBy:    conversation.CreatedBy,
 		CreatedAt:    conversation.CreatedAt,
 		UpdatedAt:    conversation.UpdatedAt,
 		Participants: users,
 	}
 
-	return response, nil
+	return response, systemMessage, nil
 }
 
 // GetConversationsByUserID gets all conversations for a user
@@ -143,11 +262,14 @@ func (s *ChatService) GetConversationsByUserID(userID uuid.UUID) ([]*models.Conv
 			ID:           conv.ID,
 			Name:         conv.Name,
 			Type:         conv.Type,
+			Topic:        conv.Topic,
 			CreatedBy:    conv.CreatedBy,
 			CreatedAt:    conv.CreatedAt,
 			UpdatedAt:    conv.UpdatedAt,
 			LastMessage:  lastMessage,
 			Participants: users,
+
+			MessageTTLSeconds: conv.MessageTTLSeconds,
 		}
 
 		responses = append(responses, response)
@@ -194,66 +316,395 @@ func (s *ChatService) GetConversationByID(conversationID, userID uuid.UUID) (*mo
 		ID:           conversation.ID,
 		Name:         conversation.Name,
 		Type:         conversation.Type,
+		Topic:        conversation.Topic,
 		CreatedBy:    conversation.CreatedBy,
 		CreatedAt:    conversation.CreatedAt,
 		UpdatedAt:    conversation.UpdatedAt,
 		LastMessage:  lastMessage,
 		Participants: users,
+
+		MessageTTLSeconds: conversation.MessageTTLSeconds,
 	}
 
 	return response, nil
 }
 
 // SendMessage sends a message to a conversation
-func (s *ChatService) SendMessage(req *models.SendMessageRequest, senderID uuid.UUID) (*models.MessageResponse, error) {
+// If the request has a client message ID the sender already used, the original message is
+// returned instead of sending again and created is false
+func (s *ChatService) SendMessage(req *models.SendMessageRequest, senderID uuid.UUID) (*models.MessageResponse, bool, error) {
 	// Check if user is participant
 	isParticipant, err := s.participantRepo.IsParticipant(req.ConversationID, senderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check participant status: %w", err)
+		return nil, false, fmt.Errorf("failed to check participant status: %w", err)
 	}
 
 	if !isParticipant {
-		return nil, errors.New("user is not a participant in this conversation")
+		return nil, false, errors.New("user is not a participant in this conversation")
+	}
+
+	// Retries are answered before validation, the attachments of the original are already linked
+	if req.ClientMsgID != "" {
+		original, err := s.getSentMessage(req, senderID)
+		if err == nil || !errors.Is(err, sql.ErrNoRows) {
+			return original, false, err
+		}
+	}
+
+	response, created, err := s.sendMessage(req, senderID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A concurrent retry inserted the message first
+	if !created {
+		original, err := s.getSentMessage(req, senderID)
+		return original, false, err
+	}
+
+	return response, true, nil
+}
+
+// getSentMessage gets the message a sender already sent with the client message ID of a request
+// Returns sql.ErrNoRows if there is none
+func (s *ChatService) getSentMessage(req *models.SendMessageRequest, senderID uuid.UUID) (*models.MessageResponse, error) {
+	message, err := s.messageRepo.GetMessageByClientMsgID(senderID, req.ClientMsgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	if message.ConversationID != req.ConversationID {
+		return nil, utils.ErrClientMsgIDConflict
+	}
+
+	responses := []*models.MessageResponse{newMessageResponse(message)}
+	if err := s.enrichMessages(responses, senderID); err != nil {
+		return nil, err
+	}
+
+	return responses[0], nil
+}
+
+// sendMessage validates and creates a message
+// Returns false if a message with the same client message ID was created concurrently
+func (s *ChatService) sendMessage(req *models.SendMessageRequest, senderID uuid.UUID) (*models.MessageResponse, bool, error) {
+	// Typed messages are sent as the plain-text fallback of their payload
+	encodedPayload, err := s.decodePayload(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Snippet messages are sent as a description of their code, which is stored apart
+	snippet, err := s.prepareSnippet(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	attachments, err := s.validateAttachments(req, senderID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Hooks see the content as written, Markdown is parsed from the redacted text
+	moderated := s.moderationService.Moderate(req.ConversationID, senderID, req.Content)
+	if moderated.Rejected {
+		if moderated.Reason == "" {
+			return nil, false, utils.ErrMessageRejected
+		}
+		return nil, false, fmt.Errorf("%w: %s", utils.ErrMessageRejected, moderated.Reason)
+	}
+	// The fallback of a payload cannot be redacted without the payload disagreeing with it
+	if encodedPayload != nil && moderated.Content != req.Content {
+		return nil, false, fmt.Errorf("%w: the payload contains blocked content", utils.ErrMessageRejected)
+	}
+
+	// Code cannot be redacted without breaking it either
+	var codeFlags []moderation.Flag
+	if snippet != nil {
+		moderatedCode := s.moderationService.Moderate(req.ConversationID, senderID, snippet.Code)
+		if moderatedCode.Rejected || moderatedCode.Redacted {
+			return nil, false, fmt.Errorf("%w: the snippet contains blocked content", utils.ErrMessageRejected)
+		}
+		codeFlags = moderatedCode.Flags
+	}
+
+	content, richContent := formatContent(moderated.Content, req.Format)
+	if content == "" && len(attachments) == 0 {
+		return nil, false, utils.ErrContentRequired
+	}
+
+	// Mention offsets refer to the stored plain-text content
+	// Snippet descriptions are generated from the file name and mention nobody
+	var mentions []models.MessageMention
+	if snippet == nil {
+		mentions, err = s.resolveMentions(req.ConversationID, content)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to resolve mentions: %w", err)
+		}
+	}
+
+	// Attachment messages without caption use the file name as content for previews and search
+	if content == "" && len(attachments) > 0 {
+		content = attachments[0].FileName
+		if req.MessageType == "voice" {
+			content = voiceMessageText(attachments[0])
+		}
+	}
+
+	// Resolve the thread root when replying to a message
+	var parent *models.Message
+	var threadRootID *uuid.UUID
+	if req.ReplyToID != nil {
+		parent, err = s.messageRepo.GetMessageByID(*req.ReplyToID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, false, utils.ErrMessageNotFound
+			}
+			return nil, false, fmt.Errorf("failed to get reply target: %w", err)
+		}
+
+		if parent.ConversationID != req.ConversationID {
+			return nil, false, utils.ErrReplyTargetMismatch
+		}
+
+		// Replies to a reply stay in the same thread
+		rootID := parent.ID
+		if parent.ThreadRootID != nil {
+			rootID = *parent.ThreadRootID
+		}
+		threadRootID = &rootID
 	}
 
 	// Create message with current timestamp in Vietnam timezone
 	vietnamLoc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
 	now := time.Now().In(vietnamLoc)
-	
+
+	expiresAt, err := s.messageExpiry(req.ConversationID, now)
+	if err != nil {
+		return nil, false, err
+	}
+
 	message := &models.Message{
 		ConversationID: req.ConversationID,
 		SenderID:       senderID,
-		Content:        req.Content,
+		Content:        content,
 		MessageType:    req.MessageType,
 		IsRead:         false,
 		CreatedAt:      now,
 		UpdatedAt:      now,
+		ReplyToID:      req.ReplyToID,
+		ThreadRootID:   threadRootID,
+		ExpiresAt:      expiresAt,
+		RichContent:    richContent,
+		Payload:        encodedPayload,
+	}
+	if req.ClientMsgID != "" {
+		message.ClientMsgID = &req.ClientMsgID
+	}
+
+	attachmentIDs := make([]uuid.UUID, 0, len(attachments))
+	for _, attachment := range attachments {
+		attachmentIDs = append(attachmentIDs, attachment.ID)
 	}
 
-	err = s.messageRepo.CreateMessage(message)
+	var created bool
+	if snippet != nil {
+		created, err = s.snippetRepo.CreateSnippetMessage(message, snippet)
+	} else {
+		created, err = s.messageRepo.CreateMessageWithAttachments(message, attachmentIDs)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create message: %w", err)
+		// The attachments were sent with another message after they were checked
+		if errors.Is(err, repository.ErrAttachmentsNotLinked) {
+			return nil, false, utils.ErrAttachmentInvalid
+		}
+		return nil, false, fmt.Errorf("failed to create message: %w", err)
+	}
+	if !created {
+		return nil, false, nil
+	}
+
+	if len(moderated.Flags) > 0 {
+		if err := s.moderationService.RecordFlags(message, req.Content, moderated.Flags); err != nil {
+			log.Printf("Error queueing flagged message %s for review: %v", message.ID, err)
+		}
+	}
+	if len(codeFlags) > 0 {
+		if err := s.moderationService.RecordFlags(message, snippet.Code, codeFlags); err != nil {
+			log.Printf("Error queueing flagged snippet %s for review: %v", message.ID, err)
+		}
+	}
+
+	for _, attachment := range attachments {
+		attachment.MessageID = &message.ID
+		setAttachmentURLs(attachment)
+	}
+
+	if len(mentions) > 0 {
+		records := make([]*models.MessageMention, 0, len(mentions))
+		for i := range mentions {
+			mentions[i].MessageID = message.ID
+			records = append(records, &mentions[i])
+		}
+
+		if err := s.mentionRepo.CreateMentions(records); err != nil {
+			return nil, false, fmt.Errorf("failed to save mentions: %w", err)
+		}
+	}
+
+	// Thread root author and repliers follow the thread automatically
+	if threadRootID != nil {
+		root := parent
+		if root.ID != *threadRootID {
+			root, err = s.messageRepo.GetMessageByID(*threadRootID)
+		}
+		if err == nil {
+			if err := s.threadRepo.AutoFollow(root.ID, root.SenderID); err != nil {
+				log.Printf("Error auto-following thread %s for user %s: %v", root.ID, root.SenderID, err)
+			}
+		}
+		if err := s.threadRepo.AutoFollow(*threadRootID, senderID); err != nil {
+			log.Printf("Error auto-following thread %s for user %s: %v", *threadRootID, senderID, err)
+		}
 	}
 
 	// Get sender info
 	sender, err := s.userRepo.GetUserByID(senderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sender info: %w", err)
+		return nil, false, fmt.Errorf("failed to get sender info: %w", err)
 	}
 
-	response := &models.MessageResponse{
-		ID:             message.ID,
-		ConversationID: message.ConversationID,
-		SenderID:       message.SenderID,
-		Content:        message.Content,
-		MessageType:    message.MessageType,
-		IsRead:         message.IsRead,
-		CreatedAt:      message.CreatedAt,
-		UpdatedAt:      message.UpdatedAt,
-		SenderName:     sender.DisplayName,
+	message.SenderName = sender.DisplayName
+
+	response := newMessageResponse(message)
+	response.Attachments = attachments
+	response.Mentions = mentions
+	if snippet != nil {
+		response.Snippet = newSnippetPreview(message.ConversationID, snippet)
 	}
 
-	return response, nil
+	// The draft was written for this message, unless it was edited after sending
+	if !req.KeepDraft {
+		cleared, err := s.draftRepo.ClearDraft(senderID, message.ConversationID, now)
+		if err != nil {
+			log.Printf("Error clearing draft of user %s in conversation %s: %v", senderID, message.ConversationID, err)
+		}
+		response.DraftCleared = cleared
+	}
+
+	states, err := s.deliveryRepo.GetDeliveryStates([]uuid.UUID{message.ID})
+	if err != nil {
+		log.Printf("Error getting delivery state of message %s: %v", message.ID, err)
+	}
+	response.Delivery = states[message.ID]
+
+	return response, true, nil
+}
+
+// messageFormat returns the format of a message, plain text unless Markdown was requested
+func messageFormat(format string) string {
+	if format == "markdown" {
+		return format
+	}
+	return "plain"
+}
+
+// formatContent prepares the content of a message in the given format
+// Markdown is parsed into a sanitized rich-text document and rendered as plain text for the
+// content, which is used for search, previews and notifications
+func formatContent(content, format string) (string, *richtext.Node) {
+	if messageFormat(format) != "markdown" {
+		return strings.TrimSpace(content), nil
+	}
+
+	doc := richtext.Parse(content)
+	return richtext.PlainText(doc), doc
+}
+
+// validateAttachments checks that the message type matches its content and attachments
+// Returns the attachments to link to the message
+func (s *ChatService) validateAttachments(req *models.SendMessageRequest, senderID uuid.UUID) ([]*models.Attachment, error) {
+	// Drop duplicate IDs so each attachment is checked once
+	seen := make(map[uuid.UUID]bool)
+	attachmentIDs := make([]uuid.UUID, 0, len(req.AttachmentIDs))
+	for _, id := range req.AttachmentIDs {
+		if !seen[id] {
+			seen[id] = true
+			attachmentIDs = append(attachmentIDs, id)
+		}
+	}
+
+	// Typed and snippet messages describe everything in their payload or code
+	if req.Payload != nil || req.MessageType == "snippet" {
+		if len(attachmentIDs) > 0 {
+			return nil, utils.ErrAttachmentNotAllowed
+		}
+		return nil, nil
+	}
+
+	if len(attachmentIDs) == 0 {
+		if req.MessageType != "text" {
+			return nil, utils.ErrAttachmentRequired
+		}
+		if strings.TrimSpace(req.Content) == "" {
+			return nil, utils.ErrContentRequired
+		}
+		return nil, nil
+	}
+
+	attachments, err := s.attachmentRepo.GetAttachmentsByIDs(attachmentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	if len(attachments) != len(attachmentIDs) {
+		return nil, utils.ErrAttachmentInvalid
+	}
+
+	for _, attachment := range attachments {
+		if attachment.ConversationID != req.ConversationID || attachment.UploaderID != senderID || attachment.MessageID != nil {
+			return nil, utils.ErrAttachmentInvalid
+		}
+		if req.MessageType == "image" && !attachment.IsImage() {
+			return nil, utils.ErrAttachmentNotImage
+		}
+	}
+
+	if req.MessageType == "voice" {
+		if err := s.validateVoice(req, attachments); err != nil {
+			return nil, err
+		}
+	}
+
+	return attachments, nil
+}
+
+// validateVoice checks that a voice message is a single recording without caption, within the maximum length
+func (s *ChatService) validateVoice(req *models.SendMessageRequest, attachments []*models.Attachment) error {
+	if len(attachments) != 1 || !attachments[0].IsVoice() {
+		return utils.ErrAttachmentNotVoice
+	}
+	if strings.TrimSpace(req.Content) != "" {
+		return utils.ErrTypedMessageContent
+	}
+
+	duration := time.Duration(*attachments[0].DurationMs) * time.Millisecond
+	if duration <= 0 {
+		return utils.ErrInvalidAudio
+	}
+	if duration > s.maxVoiceDuration {
+		return fmt.Errorf("%w: the maximum is %s", utils.ErrVoiceTooLong, s.maxVoiceDuration)
+	}
+	return nil
+}
+
+// voiceMessageText describes a voice message in plain text, like "🎤 Voice message (1:05)"
+func voiceMessageText(recording *models.Attachment) string {
+	seconds := (*recording.DurationMs + 500) / 1000
+	return fmt.Sprintf("🎤 Voice message (%d:%02d)", seconds/60, seconds%60)
 }
 
 // GetMessagesByConversationID gets messages for a conversation
@@ -275,49 +726,981 @@ func (s *ChatService) GetMessagesByConversationID(conversationID, userID uuid.UU
 
 	responses := make([]*models.MessageResponse, 0, len(messages))
 	for _, msg := range messages {
-		response := &models.MessageResponse{
-			ID:             msg.ID,
-			ConversationID: msg.ConversationID,
-			SenderID:       msg.SenderID,
-			Content:        msg.Content,
-			MessageType:    msg.MessageType,
-			IsRead:         msg.IsRead,
-			CreatedAt:      msg.CreatedAt,
-			UpdatedAt:      msg.UpdatedAt,
-			SenderName:     msg.SenderName,
-		}
+		responses = append(responses, newMessageResponse(msg))
+	}
 
-		responses = append(responses, response)
+	if err := s.enrichMessages(responses, userID); err != nil {
+		return nil, err
 	}
 
 	return responses, nil
 }
 
-// MarkMessageAsRead marks a message as read
-func (s *ChatService) MarkMessageAsRead(messageID, userID uuid.UUID) error {
-	message, err := s.messageRepo.GetMessageByID(messageID)
+// enrichMessages loads the reactions, attachments, mentions, pin, poll, snippet, delivery and bookmark state of all messages
+// with one query each and embeds them in the responses
+func (s *ChatService) enrichMessages(responses []*models.MessageResponse, userID uuid.UUID) error {
+	messageIDs := make([]uuid.UUID, 0, len(responses))
+	for _, response := range responses {
+		messageIDs = append(messageIDs, response.ID)
+	}
+
+	summaries, err := s.reactionRepo.GetReactionSummaries(messageIDs, userID)
 	if err != nil {
-		return fmt.Errorf("failed to get message: %w", err)
+		return fmt.Errorf("failed to get reactions: %w", err)
 	}
 
-	// Check if user is participant in the conversation
-	isParticipant, err := s.participantRepo.IsParticipant(message.ConversationID, userID)
+	attachments, err := s.attachmentRepo.GetAttachmentsByMessageIDs(messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	mentions, err := s.mentionRepo.GetMentionsByMessageIDs(messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get mentions: %w", err)
+	}
+
+	pins, err := s.pinRepo.GetPinsByMessageIDs(messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get pins: %w", err)
+	}
+
+	polls, err := s.pollRepo.GetPollsByMessageIDs(messageIDs, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get polls: %w", err)
+	}
+
+	// Message lists only show the head of snippets
+	var snippetIDs []uuid.UUID
+	for _, response := range responses {
+		if response.MessageType == "snippet" {
+			snippetIDs = append(snippetIDs, response.ID)
+		}
+	}
+
+	snippets, err := s.snippetRepo.GetSnippetsByMessageIDs(snippetIDs, snippetPreviewChars)
+	if err != nil {
+		return fmt.Errorf("failed to get snippets: %w", err)
+	}
+
+	// Delivery states are shown to the sender only
+	var sentIDs []uuid.UUID
+	for _, response := range responses {
+		if response.SenderID == userID && response.MessageType != "system" {
+			sentIDs = append(sentIDs, response.ID)
+		}
+	}
+
+	deliveries, err := s.deliveryRepo.GetDeliveryStates(sentIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get delivery states: %w", err)
+	}
+
+	bookmarks, err := s.bookmarkRepo.GetBookmarksByMessageIDs(userID, messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get bookmarks: %w", err)
+	}
+
+	for _, response := range responses {
+		response.Reactions = summaries[response.ID]
+		response.Mentions = mentions[response.ID]
+		response.Poll = polls[response.ID]
+		if snippet, ok := snippets[response.ID]; ok {
+			response.Snippet = newSnippetPreview(response.ConversationID, snippet)
+		}
+		response.Delivery = deliveries[response.ID]
+		if pin, ok := pins[response.ID]; ok {
+			response.IsPinned = true
+			response.PinnedBy = &pin.PinnedBy
+			response.PinnedAt = &pin.PinnedAt
+		}
+		if bookmark, ok := bookmarks[response.ID]; ok {
+			response.IsBookmarked = true
+			response.BookmarkNote = bookmark.Note
+		}
+		response.Attachments = attachments[response.ID]
+		for _, attachment := range response.Attachments {
+			setAttachmentURLs(attachment)
+		}
+	}
+
+	return nil
+}
+
+// getConversationMessage gets a message of a conversation the user participates in
+func (s *ChatService) getConversationMessage(conversationID, messageID, userID uuid.UUID) (*models.Message, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to check participant status: %w", err)
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
 	}
 
 	if !isParticipant {
-		return errors.New("user is not a participant in this conversation")
+		return nil, utils.ErrNotParticipant
 	}
 
-	err = s.messageRepo.MarkMessageAsRead(messageID)
+	message, err := s.messageRepo.GetMessageByID(messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	if message.ConversationID != conversationID {
+		return nil, utils.ErrMessageNotFound
+	}
+
+	return message, nil
+}
+
+// GetMessage gets a single message of a conversation, with the same state as in message lists
+func (s *ChatService) GetMessage(conversationID, messageID, userID uuid.UUID) (*models.MessageResponse, error) {
+	message, err := s.getConversationMessage(conversationID, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []*models.MessageResponse{newMessageResponse(message)}
+	if err := s.enrichMessages(responses, userID); err != nil {
+		return nil, err
+	}
+
+	return responses[0], nil
+}
+
+// GetThread gets a thread root message and a page of its replies
+// Passing the ID of a reply returns the thread it belongs to
+func (s *ChatService) GetThread(conversationID, messageID, userID uuid.UUID, limit, offset int) (*models.ThreadResponse, error) {
+	root, err := s.getConversationMessage(conversationID, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.ThreadRootID != nil {
+		root, err = s.messageRepo.GetMessageByID(*root.ThreadRootID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get thread root: %w", err)
+		}
+	}
+
+	replies, err := s.messageRepo.GetThreadReplies(root.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread replies: %w", err)
+	}
+
+	response := &models.ThreadResponse{
+		Root:    newMessageResponse(root),
+		Replies: make([]*models.MessageResponse, 0, len(replies)),
+	}
+	for _, reply := range replies {
+		response.Replies = append(response.Replies, newMessageResponse(reply))
+	}
+
+	if err := s.enrichMessages(append([]*models.MessageResponse{response.Root}, response.Replies...), userID); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// SetThreadFollowing follows or unfollows the thread of a message
+func (s *ChatService) SetThreadFollowing(conversationID, messageID, userID uuid.UUID, isFollowing bool) error {
+	message, err := s.getConversationMessage(conversationID, messageID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to mark message as read: %w", err)
+		return err
+	}
+
+	rootID := message.ID
+	if message.ThreadRootID != nil {
+		rootID = *message.ThreadRootID
+	}
+
+	if err := s.threadRepo.SetFollowing(rootID, userID, isFollowing); err != nil {
+		return fmt.Errorf("failed to update thread follow state: %w", err)
 	}
 
 	return nil
 }
 
+// AddReaction adds an emoji reaction to a message
+// Returns the updated summary for the emoji and whether a new reaction was stored
+func (s *ChatService) AddReaction(conversationID, messageID, userID uuid.UUID, emoji string) (*models.ReactionSummary, bool, error) {
+	if err := utils.ValidateReaction(emoji); err != nil {
+		return nil, false, err
+	}
+
+	if _, err := s.getConversationMessage(conversationID, messageID, userID); err != nil {
+		return nil, false, err
+	}
+
+	reaction := &models.Reaction{
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji,
+	}
+
+	added, err := s.reactionRepo.AddReaction(reaction)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	count, err := s.reactionRepo.CountReactions(messageID, emoji)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to count reactions: %w", err)
+	}
+
+	return &models.ReactionSummary{Emoji: emoji, Count: count, ReactedByMe: true}, added, nil
+}
+
+// RemoveReaction removes the user's emoji reaction from a message
+// Returns the updated summary for the emoji
+func (s *ChatService) RemoveReaction(conversationID, messageID, userID uuid.UUID, emoji string) (*models.ReactionSummary, error) {
+	if _, err := s.getConversationMessage(conversationID, messageID, userID); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.reactionRepo.RemoveReaction(messageID, userID, emoji)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	if !removed {
+		return nil, utils.ErrReactionNotFound
+	}
+
+	count, err := s.reactionRepo.CountReactions(messageID, emoji)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+
+	return &models.ReactionSummary{Emoji: emoji, Count: count, ReactedByMe: false}, nil
+}
+
+// GetThreadFollowerIDs gets the followers of a thread, excluding the given users
+func (s *ChatService) GetThreadFollowerIDs(threadRootID uuid.UUID, excludeUserIDs ...uuid.UUID) ([]uuid.UUID, error) {
+	followerIDs, err := s.threadRepo.GetFollowerIDs(threadRootID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread followers: %w", err)
+	}
+
+	excluded := make(map[uuid.UUID]bool, len(excludeUserIDs))
+	for _, id := range excludeUserIDs {
+		excluded[id] = true
+	}
+
+	result := make([]uuid.UUID, 0, len(followerIDs))
+	for _, id := range followerIDs {
+		if !excluded[id] {
+			result = append(result, id)
+		}
+	}
+
+	return result, nil
+}
+
+// GetMentionedUserIDs gets the participants mentioned by a message, excluding its sender
+// @everyone expands to all participants and @here to the participants who are online
+func (s *ChatService) GetMentionedUserIDs(message *models.MessageResponse) ([]uuid.UUID, error) {
+	if len(message.Mentions) == 0 {
+		return nil, nil
+	}
+
+	everyone, here := false, false
+	mentioned := make(map[uuid.UUID]bool)
+	for _, mention := range message.Mentions {
+		switch mention.Type {
+		case mentionEveryone:
+			everyone = true
+		case mentionHere:
+			here = true
+		default:
+			mentioned[*mention.UserID] = true
+		}
+	}
+
+	participants, err := s.participantRepo.GetParticipantsByConversationID(message.ConversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participants: %w", err)
+	}
+
+	var userIDs []uuid.UUID
+	for _, participant := range participants {
+		if participant.UserID == message.SenderID {
+			continue
+		}
+
+		isOnline := participant.User != nil && participant.User.IsOnline
+		if everyone || mentioned[participant.UserID] || (here && isOnline) {
+			userIDs = append(userIDs, participant.UserID)
+		}
+	}
+
+	return userIDs, nil
+}
+
+// GetMentions gets the messages mentioning a user across their conversations, newest first
+func (s *ChatService) GetMentions(userID uuid.UUID, limit, offset int) ([]*models.MessageResponse, error) {
+	messages, err := s.mentionRepo.GetMentioningMessages(userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mentions: %w", err)
+	}
+
+	responses := make([]*models.MessageResponse, 0, len(messages))
+	for _, msg := range messages {
+		responses = append(responses, newMessageResponse(msg))
+	}
+
+	if err := s.enrichMessages(responses, userID); err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+// SetConversationMuted mutes or unmutes a conversation for a participant
+func (s *ChatService) SetConversationMuted(conversationID, userID uuid.UUID, isMuted bool) error {
+	found, err := s.participantRepo.SetMuted(conversationID, userID, isMuted)
+	if err != nil {
+		return fmt.Errorf("failed to update mute state: %w", err)
+	}
+
+	if !found {
+		return utils.ErrNotParticipant
+	}
+
+	return nil
+}
+
+// requireConversationAdmin checks that a user may manage a conversation
+// Any participant manages a direct conversation, groups are managed by their admins
+func (s *ChatService) requireConversationAdmin(conversationID, userID uuid.UUID) error {
+	participant, err := s.participantRepo.GetParticipant(conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.ErrNotParticipant
+		}
+		return fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	if conversation.Type == "group" && !participant.IsAdmin {
+		return utils.ErrNotConversationAdmin
+	}
+
+	return nil
+}
+
+// createSystemMessage posts a system message recording an action of a user in a conversation
+// The content describes the event in English, the event itself is stored for clients to localize
+func (s *ChatService) createSystemMessage(conversationID uuid.UUID, actor *models.User, event *models.SystemEvent, content string) (*models.MessageResponse, error) {
+	vietnamLoc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+	now := time.Now().In(vietnamLoc)
+
+	expiresAt, err := s.messageExpiry(conversationID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Actor = models.NewSystemEventUser(actor)
+	message := &models.Message{
+		ConversationID: conversationID,
+		SenderID:       actor.ID,
+		Content:        content,
+		MessageType:    "system",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ExpiresAt:      expiresAt,
+		SystemEvent:    event,
+	}
+
+	if _, err := s.messageRepo.CreateMessage(message); err != nil {
+		return nil, fmt.Errorf("failed to create system message: %w", err)
+	}
+
+	message.SenderName = actor.DisplayName
+
+	return newMessageResponse(message), nil
+}
+
+// messageExpiry computes the expiry of a message sent now in a conversation with disappearing messages
+// Returns nil if messages of the conversation do not expire
+func (s *ChatService) messageExpiry(conversationID uuid.UUID, now time.Time) (*time.Time, error) {
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	return expiryAfter(now, conversation.MessageTTLSeconds), nil
+}
+
+// expiryAfter returns the time a TTL in seconds runs out, nil if there is no TTL
+func expiryAfter(now time.Time, ttlSeconds *int) *time.Time {
+	if ttlSeconds == nil {
+		return nil
+	}
+
+	expiresAt := now.Add(time.Duration(*ttlSeconds) * time.Second)
+	return &expiresAt
+}
+
+// SetMessageTTL changes the disappearing messages setting of a conversation, 0 turns it off
+// Only messages sent afterwards are affected. Returns the system message announcing the change,
+// which is nil if it could not be created
+func (s *ChatService) SetMessageTTL(conversationID, userID uuid.UUID, ttlSeconds int) (*models.MessageResponse, error) {
+	if err := s.requireConversationAdmin(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	var ttl *int
+	if ttlSeconds > 0 {
+		ttl = &ttlSeconds
+	}
+
+	if err := s.conversationRepo.UpdateMessageTTL(conversationID, ttl); err != nil {
+		return nil, fmt.Errorf("failed to update message TTL: %w", err)
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for message TTL system message: %v", userID, err)
+		return nil, nil
+	}
+
+	content := fmt.Sprintf("%s turned off disappearing messages", actor.DisplayName)
+	if ttl != nil {
+		content = fmt.Sprintf("%s set disappearing messages to %s", actor.DisplayName, formatTTL(ttlSeconds))
+	}
+
+	event := &models.SystemEvent{Event: models.SystemEventMessageTTLChanged}
+	if conversation.MessageTTLSeconds != nil {
+		event.OldValue = *conversation.MessageTTLSeconds
+	}
+	if ttl != nil {
+		event.NewValue = *ttl
+	}
+
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording message TTL change of conversation %s: %v", conversationID, err)
+	}
+
+	return systemMessage, nil
+}
+
+// formatTTL formats a TTL in seconds with the largest unit that divides it, e.g. "1 day" or "90 minutes"
+func formatTTL(ttlSeconds int) string {
+	units := []struct {
+		name    string
+		seconds int
+	}{
+		{"week", 7 * 24 * 60 * 60},
+		{"day", 24 * 60 * 60},
+		{"hour", 60 * 60},
+		{"minute", 60},
+	}
+
+	for _, unit := range units {
+		if ttlSeconds%unit.seconds == 0 {
+			count := ttlSeconds / unit.seconds
+			if count == 1 {
+				return "1 " + unit.name
+			}
+			return fmt.Sprintf("%d %ss", count, unit.name)
+		}
+	}
+
+	if ttlSeconds == 1 {
+		return "1 second"
+	}
+	return fmt.Sprintf("%d seconds", ttlSeconds)
+}
+
+// PurgeExpiredMessages hard-deletes up to limit expired messages with their thread replies
+// Returns the deleted messages and their attachments, whose files are not deleted yet
+func (s *ChatService) PurgeExpiredMessages(limit int) ([]*models.Message, []*models.Attachment, error) {
+	messages, attachments, err := s.messageRepo.PurgeExpiredMessages(limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to purge expired messages: %w", err)
+	}
+
+	return messages, attachments, nil
+}
+
+// PinMessage pins a message of a conversation
+// Returns the pin and the system message recording it, which is nil if it could not be created
+func (s *ChatService) PinMessage(conversationID, messageID, userID uuid.UUID) (*models.PinnedMessage, *models.MessageResponse, error) {
+	message, err := s.getConversationMessage(conversationID, messageID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.requireConversationAdmin(conversationID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	pin := &models.PinnedMessage{
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		PinnedBy:       userID,
+	}
+
+	result, err := s.pinRepo.PinMessage(pin, s.maxPinsPerConversation)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pin message: %w", err)
+	}
+
+	switch result {
+	case repository.PinAlreadyExists:
+		return nil, nil, utils.ErrAlreadyPinned
+	case repository.PinLimitReached:
+		return nil, nil, utils.ErrPinLimitReached
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for pin system message: %v", userID, err)
+		return pin, nil, nil
+	}
+
+	content := fmt.Sprintf("%s pinned a message: %s", actor.DisplayName, truncateText(message.Content, notificationPreviewLength))
+	event := &models.SystemEvent{Event: models.SystemEventMessagePinned, MessageID: &message.ID}
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording pin of message %s: %v", messageID, err)
+	}
+
+	return pin, systemMessage, nil
+}
+
+// UnpinMessage unpins a message of a conversation
+// Returns the system message recording it, which is nil if it could not be created
+func (s *ChatService) UnpinMessage(conversationID, messageID, userID uuid.UUID) (*models.MessageResponse, error) {
+	message, err := s.getConversationMessage(conversationID, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.requireConversationAdmin(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.pinRepo.UnpinMessage(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpin message: %w", err)
+	}
+
+	if !removed {
+		return nil, utils.ErrNotPinned
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for unpin system message: %v", userID, err)
+		return nil, nil
+	}
+
+	content := fmt.Sprintf("%s unpinned a message: %s", actor.DisplayName, truncateText(message.Content, notificationPreviewLength))
+	event := &models.SystemEvent{Event: models.SystemEventMessageUnpinned, MessageID: &message.ID}
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording unpin of message %s: %v", messageID, err)
+	}
+
+	return systemMessage, nil
+}
+
+// GetPinnedMessages gets the pinned messages of a conversation, most recently pinned first
+func (s *ChatService) GetPinnedMessages(conversationID, userID uuid.UUID) ([]*models.MessageResponse, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	messages, err := s.pinRepo.GetPinnedMessages(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned messages: %w", err)
+	}
+
+	responses := make([]*models.MessageResponse, 0, len(messages))
+	for _, msg := range messages {
+		responses = append(responses, newMessageResponse(msg))
+	}
+
+	if err := s.enrichMessages(responses, userID); err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+// ForwardMessages forwards messages to other conversations
+// The user must participate in the conversation of every message and in every target.
+// Forwarded messages keep the original sender and timestamp, forwarding a forwarded message
+// keeps the attribution to the first sender. Attachments are shared with the originals.
+// Copies go through moderation like new messages, and each target gets all of them or none.
+func (s *ChatService) ForwardMessages(req *models.ForwardMessagesRequest, userID uuid.UUID) ([]*models.MessageResponse, error) {
+	// Check access to every source and target before creating anything
+	sources := make([]*models.Message, 0, len(req.MessageIDs))
+	seenMessages := make(map[uuid.UUID]bool)
+	checkedConversations := make(map[uuid.UUID]bool)
+
+	checkParticipant := func(conversationID uuid.UUID) error {
+		if checkedConversations[conversationID] {
+			return nil
+		}
+
+		isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to check participant status: %w", err)
+		}
+		if !isParticipant {
+			return utils.ErrNotParticipant
+		}
+
+		checkedConversations[conversationID] = true
+		return nil
+	}
+
+	for _, messageID := range req.MessageIDs {
+		if seenMessages[messageID] {
+			continue
+		}
+		seenMessages[messageID] = true
+
+		message, err := s.messageRepo.GetMessageByID(messageID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, utils.ErrMessageNotFound
+			}
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+
+		if err := checkParticipant(message.ConversationID); err != nil {
+			return nil, err
+		}
+
+		if message.MessageType == "system" || message.MessageType == "poll" {
+			return nil, utils.ErrMessageNotForwardable
+		}
+
+		sources = append(sources, message)
+	}
+
+	targets := make([]*models.Conversation, 0, len(req.ConversationIDs))
+	seenTargets := make(map[uuid.UUID]bool)
+	for _, conversationID := range req.ConversationIDs {
+		if seenTargets[conversationID] {
+			continue
+		}
+		seenTargets[conversationID] = true
+
+		if err := checkParticipant(conversationID); err != nil {
+			return nil, err
+		}
+
+		conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversation: %w", err)
+		}
+		targets = append(targets, conversation)
+	}
+
+	// Keep the original order of the messages
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].CreatedAt.Before(sources[j].CreatedAt)
+	})
+
+	// Snippet code is moderated like the code of a new snippet
+	codes := make(map[uuid.UUID]string)
+	for _, source := range sources {
+		if source.MessageType != "snippet" {
+			continue
+		}
+
+		snippet, err := s.snippetRepo.GetSnippet(source.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get snippet: %w", err)
+		}
+		if snippet != nil {
+			codes[source.ID] = snippet.Code
+		}
+	}
+
+	sender, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender info: %w", err)
+	}
+
+	vietnamLoc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+
+	// Moderate every copy before creating anything, rules may differ between the targets
+	forwardsByTarget := make([][]*repository.ForwardedMessage, 0, len(targets))
+	flagged := make(map[*models.Message]*forwardModeration)
+	for _, conversation := range targets {
+		forwards := make([]*repository.ForwardedMessage, 0, len(sources))
+		for _, source := range sources {
+			message, moderated, err := s.forwardedMessage(source, codes[source.ID], conversation, userID, time.Now().In(vietnamLoc))
+			if err != nil {
+				return nil, err
+			}
+			if len(moderated.flags) > 0 || len(moderated.codeFlags) > 0 {
+				flagged[message] = moderated
+			}
+
+			forwards = append(forwards, &repository.ForwardedMessage{SourceID: source.ID, Message: message})
+		}
+		forwardsByTarget = append(forwardsByTarget, forwards)
+	}
+
+	responses := make([]*models.MessageResponse, 0, len(sources)*len(targets))
+	for _, forwards := range forwardsByTarget {
+		// Each target gets all of its copies or none of them
+		if err := s.messageRepo.CreateForwardedMessages(forwards); err != nil {
+			return nil, fmt.Errorf("failed to forward messages: %w", err)
+		}
+
+		for _, forward := range forwards {
+			message := forward.Message
+			message.SenderName = sender.DisplayName
+
+			if moderated := flagged[message]; moderated != nil {
+				if len(moderated.flags) > 0 {
+					if err := s.moderationService.RecordFlags(message, moderated.content, moderated.flags); err != nil {
+						log.Printf("Error queueing flagged message %s for review: %v", message.ID, err)
+					}
+				}
+				if len(moderated.codeFlags) > 0 {
+					if err := s.moderationService.RecordFlags(message, moderated.code, moderated.codeFlags); err != nil {
+						log.Printf("Error queueing flagged snippet %s for review: %v", message.ID, err)
+					}
+				}
+			}
+
+			for _, attachment := range forward.Attachments {
+				setAttachmentURLs(attachment)
+			}
+
+			response := newMessageResponse(message)
+			response.Attachments = forward.Attachments
+			if forward.Snippet != nil {
+				response.Snippet = newSnippetPreview(message.ConversationID, forward.Snippet)
+			}
+			responses = append(responses, response)
+		}
+	}
+
+	return responses, nil
+}
+
+// forwardModeration is what moderation flagged in a forwarded copy, recorded once the copy is created
+type forwardModeration struct {
+	content   string // As forwarded, before redactions
+	flags     []moderation.Flag
+	code      string
+	codeFlags []moderation.Flag
+}
+
+// forwardedMessage builds the copy of a message forwarded to a conversation
+// The copy goes through moderation like a new message, the code of a snippet is given apart
+func (s *ChatService) forwardedMessage(source *models.Message, code string, conversation *models.Conversation, userID uuid.UUID, now time.Time) (*models.Message, *forwardModeration, error) {
+	moderated := s.moderationService.Moderate(conversation.ID, userID, source.Content)
+	if moderated.Rejected {
+		if moderated.Reason == "" {
+			return nil, nil, utils.ErrMessageRejected
+		}
+		return nil, nil, fmt.Errorf("%w: %s", utils.ErrMessageRejected, moderated.Reason)
+	}
+	// The fallback of a payload cannot be redacted without the payload disagreeing with it
+	if source.Payload != nil && moderated.Content != source.Content {
+		return nil, nil, fmt.Errorf("%w: the payload contains blocked content", utils.ErrMessageRejected)
+	}
+
+	result := &forwardModeration{content: source.Content, flags: moderated.Flags, code: code}
+
+	// Code cannot be redacted without breaking it either
+	if code != "" {
+		moderatedCode := s.moderationService.Moderate(conversation.ID, userID, code)
+		if moderatedCode.Rejected || moderatedCode.Redacted {
+			return nil, nil, fmt.Errorf("%w: the snippet contains blocked content", utils.ErrMessageRejected)
+		}
+		result.codeFlags = moderatedCode.Flags
+	}
+
+	message := &models.Message{
+		ConversationID:          conversation.ID,
+		SenderID:                userID,
+		Content:                 moderated.Content,
+		RichContent:             source.RichContent,
+		MessageType:             source.MessageType,
+		Payload:                 source.Payload,
+		CreatedAt:               now,
+		UpdatedAt:               now,
+		ForwardedFromMessageID:  &source.ID,
+		ForwardedFromSenderID:   &source.SenderID,
+		ForwardedFromSenderName: &source.SenderName,
+		ForwardedFromCreatedAt:  &source.CreatedAt,
+		ExpiresAt:               expiryAfter(now, conversation.MessageTTLSeconds),
+	}
+
+	// Formatting cannot be redacted, the masked plain text is forwarded instead
+	if moderated.Content != source.Content {
+		message.RichContent = nil
+	}
+
+	if source.ForwardedFromCreatedAt != nil {
+		message.ForwardedFromMessageID = source.ForwardedFromMessageID
+		message.ForwardedFromSenderID = source.ForwardedFromSenderID
+		message.ForwardedFromSenderName = source.ForwardedFromSenderName
+		message.ForwardedFromCreatedAt = source.ForwardedFromCreatedAt
+	}
+
+	return message, result, nil
+}
+
+// MarkMessageAsRead marks a message as read and moves the user's read cursor to it
+// Returns the delivery updates to push to the senders of the messages the cursor passed
+func (s *ChatService) MarkMessageAsRead(messageID, userID uuid.UUID) ([]*models.DeliveryUpdate, error) {
+	message, err := s.messageRepo.GetMessageByID(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	// Check if user is participant in the conversation
+	isParticipant, err := s.participantRepo.IsParticipant(message.ConversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, errors.New("user is not a participant in this conversation")
+	}
+
+	err = s.messageRepo.MarkMessageAsRead(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark message as read: %w", err)
+	}
+
+	// The read cursor passes every earlier message of the conversation
+	previous, moved, err := s.deliveryRepo.MarkRead(message.ConversationID, userID, message.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move read cursor: %w", err)
+	}
+
+	if !moved {
+		return nil, nil
+	}
+
+	return s.deliveryUpdates(message.ConversationID, userID, previous, message.CreatedAt)
+}
+
+// SearchMessages searches the message history of the conversations the user participates in
+// The cursor is the next_cursor value of a previous page
+func (s *ChatService) SearchMessages(filter *models.MessageSearchFilter, cursor string) (*models.MessageSearchResponse, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query == "" {
+		return nil, utils.ErrSearchQueryRequired
+	}
+
+	if filter.MessageType != "" && !s.isMessageType(filter.MessageType) {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnknownMessageType, filter.MessageType)
+	}
+
+	if cursor != "" {
+		createdAt, id, err := decodeMessageCursor(cursor)
+		if err != nil {
+			return nil, utils.ErrInvalidCursor
+		}
+		filter.BeforeCreatedAt = &createdAt
+		filter.BeforeID = &id
+	}
+
+	// Fetch one extra hit to know if there is a next page
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	hits, err := s.messageRepo.SearchMessages(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	response := &models.MessageSearchResponse{
+		Results: make([]*models.MessageSearchResult, 0, len(hits)),
+	}
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+		last := hits[len(hits)-1].Message
+		response.NextCursor = encodeMessageCursor(last.CreatedAt, last.ID)
+	}
+
+	messages := make([]*models.MessageResponse, 0, len(hits))
+	for _, hit := range hits {
+		message := newMessageResponse(hit.Message)
+		messages = append(messages, message)
+		response.Results = append(response.Results, &models.MessageSearchResult{
+			Message: message,
+			Snippet: highlightSnippet(hit.Headline),
+		})
+	}
+
+	if err := s.enrichMessages(messages, filter.UserID); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// highlightSnippet HTML escapes a search headline and turns the highlight markers into <mark> tags
+func highlightSnippet(headline string) string {
+	snippet := html.EscapeString(headline)
+	snippet = strings.ReplaceAll(snippet, repository.HighlightStart, "<mark>")
+	snippet = strings.ReplaceAll(snippet, repository.HighlightStop, "</mark>")
+	return snippet
+}
+
+// encodeMessageCursor encodes a message position into an opaque pagination cursor
+func encodeMessageCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeMessageCursor decodes a pagination cursor created by encodeMessageCursor
+func decodeMessageCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	return createdAt, id, nil
+}
+
 // UpdateUserOnlineStatus updates user's online status
 func (s *ChatService) UpdateUserOnlineStatus(userID uuid.UUID, isOnline bool) error {
 	err := s.userRepo.UpdateOnlineStatus(userID, isOnline)
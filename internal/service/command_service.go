@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"goswift/internal/commands"
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// slashCommandsRefreshInterval bounds how long commands registered on another server instance take to be available
+const slashCommandsRefreshInterval = 30 * time.Second
+
+// CommandService runs slash commands and manages the third-party ones
+type CommandService struct {
+	commandRepo      *repository.CommandRepository
+	participantRepo  *repository.ParticipantRepository
+	conversationRepo *repository.ConversationRepository
+	userRepo         *repository.UserRepository
+	builtIns         *commands.Registry
+	adminIDs         map[uuid.UUID]bool
+	timeout          time.Duration
+
+	mu              sync.Mutex
+	external        map[string]*commands.Command
+	externalLoaded  time.Time
+	externalChanged bool
+}
+
+// NewCommandService creates a new command service
+// Workspace admins register third-party commands, which must answer within timeout
+func NewCommandService(
+	commandRepo *repository.CommandRepository,
+	participantRepo *repository.ParticipantRepository,
+	conversationRepo *repository.ConversationRepository,
+	userRepo *repository.UserRepository,
+	adminIDs []string,
+	timeout time.Duration,
+) *CommandService {
+	s := &CommandService{
+		commandRepo:      commandRepo,
+		participantRepo:  participantRepo,
+		conversationRepo: conversationRepo,
+		userRepo:         userRepo,
+		builtIns:         commands.NewRegistry(),
+		adminIDs:         parseUserIDs(adminIDs),
+		timeout:          timeout,
+	}
+
+	s.Register(&commands.Command{
+		Name:        "help",
+		Description: "List the available commands",
+		Handler: func(ctx context.Context, call *commands.Call) (*commands.Response, error) {
+			return commands.Ephemeral("%s", commands.Help(s.allCommands())), nil
+		},
+	})
+
+	return s
+}
+
+// Register adds a built-in command, it panics on invalid or duplicate names as those are programming errors
+func (s *CommandService) Register(cmd *commands.Command) {
+	if err := s.builtIns.Register(cmd); err != nil {
+		panic(fmt.Sprintf("failed to register command /%s: %v", cmd.Name, err))
+	}
+}
+
+// externalCommands returns the cached third-party commands, reloading them when they changed or are stale
+// If the commands cannot be loaded, the previously loaded ones stay available
+func (s *CommandService) externalCommands() map[string]*commands.Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.externalChanged || time.Since(s.externalLoaded) >= slashCommandsRefreshInterval {
+		external, err := s.loadExternalCommands()
+		if err != nil {
+			log.Printf("Error loading slash commands: %v", err)
+		} else {
+			s.external = external
+			s.externalChanged = false
+		}
+		// Failed loads are retried after the interval, not on every command
+		s.externalLoaded = time.Now()
+	}
+
+	return s.external
+}
+
+// loadExternalCommands creates a command calling the URL of each stored command
+func (s *CommandService) loadExternalCommands() (map[string]*commands.Command, error) {
+	stored, err := s.commandRepo.GetCommands()
+	if err != nil {
+		return nil, err
+	}
+
+	external := make(map[string]*commands.Command, len(stored))
+	for _, command := range stored {
+		external[command.Name] = &commands.Command{
+			Name:        command.Name,
+			Usage:       command.Usage,
+			Description: command.Description,
+			Permission:  commands.PermissionMember,
+			MaxArgs:     commands.Unlimited,
+			External:    true,
+			Handler:     commands.NewHTTPHandler(command.URL, command.Secret, s.timeout),
+		}
+	}
+
+	return external, nil
+}
+
+// invalidateCommands makes the next command reload the third-party commands
+func (s *CommandService) invalidateCommands() {
+	s.mu.Lock()
+	s.externalChanged = true
+	s.mu.Unlock()
+}
+
+// lookup gets a command by name, built-in commands take precedence
+func (s *CommandService) lookup(name string) (*commands.Command, bool) {
+	if cmd, ok := s.builtIns.Lookup(name); ok {
+		return cmd, true
+	}
+	cmd, ok := s.externalCommands()[name]
+	return cmd, ok
+}
+
+// allCommands returns the built-in and third-party commands ordered by name
+func (s *CommandService) allCommands() []*commands.Command {
+	registry := commands.NewRegistry()
+	for _, cmd := range s.builtIns.Commands() {
+		_ = registry.Register(cmd)
+	}
+	for _, cmd := range s.externalCommands() {
+		// Third-party commands named like a built-in one are hidden by it
+		_ = registry.Register(cmd)
+	}
+	return registry.Commands()
+}
+
+// requirePermission checks that a user can run a command in a conversation
+func (s *CommandService) requirePermission(cmd *commands.Command, conversationID, userID uuid.UUID) error {
+	participant, err := s.participantRepo.GetParticipant(conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.ErrNotParticipant
+		}
+		return fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	switch cmd.Permission {
+	case commands.PermissionWorkspaceAdmin:
+		if !s.adminIDs[userID] {
+			return utils.ErrNotWorkspaceAdmin
+		}
+	case commands.PermissionConversationAdmin:
+		conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+		if err != nil {
+			return fmt.Errorf("failed to get conversation: %w", err)
+		}
+		if conversation.Type == "group" && !participant.IsAdmin {
+			return utils.ErrNotConversationAdmin
+		}
+	}
+
+	return nil
+}
+
+// Execute runs the command in message content sent by a user to a conversation
+// Returns the name of the command with its reply, which is nil when the command has nothing to reply
+func (s *CommandService) Execute(ctx context.Context, conversationID, userID uuid.UUID, content string, replyToID *uuid.UUID) (string, *commands.Response, error) {
+	name, text, ok := commands.Parse(content)
+	if !ok {
+		return "", nil, utils.ErrUnknownCommand
+	}
+
+	cmd, ok := s.lookup(name)
+	if !ok {
+		if suggestion := commands.Suggest(name, s.allCommands()); suggestion != "" {
+			return name, nil, fmt.Errorf("%w /%s: did you mean /%s? Send /help to list the commands", utils.ErrUnknownCommand, name, suggestion)
+		}
+		return name, nil, fmt.Errorf("%w /%s: send /help to list the commands", utils.ErrUnknownCommand, name)
+	}
+
+	if err := s.requirePermission(cmd, conversationID, userID); err != nil {
+		return name, nil, err
+	}
+
+	args, err := commands.SplitArgs(text)
+	if err != nil {
+		return name, nil, fmt.Errorf("%w: %v", utils.ErrInvalidCommandUsage, err)
+	}
+
+	call := &commands.Call{
+		ConversationID: conversationID,
+		UserID:         userID,
+		Name:           name,
+		Text:           text,
+		Args:           args,
+		ReplyToID:      replyToID,
+	}
+	if err := cmd.CheckArgs(call); err != nil {
+		return name, nil, fmt.Errorf("%w: %v", utils.ErrInvalidCommandUsage, err)
+	}
+
+	if user, err := s.userRepo.GetUserByID(userID); err == nil {
+		call.UserName = user.DisplayName
+	}
+
+	response, err := cmd.Handler(ctx, call)
+	if err != nil {
+		if cmd.External {
+			log.Printf("Slash command /%s failed: %v", name, err)
+			return name, nil, fmt.Errorf("%w: /%s did not answer", utils.ErrCommandFailed, name)
+		}
+		return name, nil, err
+	}
+
+	return name, response, nil
+}
+
+// ListCommands lists the commands users can run
+func (s *CommandService) ListCommands() []*models.CommandInfo {
+	all := s.allCommands()
+
+	infos := make([]*models.CommandInfo, 0, len(all))
+	for _, cmd := range all {
+		infos = append(infos, &models.CommandInfo{
+			Name:        cmd.Name,
+			Usage:       cmd.Usage,
+			Description: cmd.Description,
+			Permission:  cmd.Permission,
+			BuiltIn:     !cmd.External,
+		})
+	}
+	return infos
+}
+
+// CreateCommand registers a third-party command, only workspace admins can register commands
+// The returned command holds the secret signing the requests to its URL
+func (s *CommandService) CreateCommand(userID uuid.UUID, req *models.CreateSlashCommandRequest) (*models.SlashCommand, error) {
+	if !s.adminIDs[userID] {
+		return nil, utils.ErrNotWorkspaceAdmin
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(req.Name), "/"))
+	if !commands.ValidName(name) {
+		return nil, utils.ErrInvalidCommandName
+	}
+	if _, ok := s.builtIns.Lookup(name); ok {
+		return nil, utils.ErrCommandExists
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	command := &models.SlashCommand{
+		Name:        name,
+		URL:         req.URL,
+		Usage:       strings.TrimSpace(req.Usage),
+		Description: strings.TrimSpace(req.Description),
+		Secret:      hex.EncodeToString(secret),
+		CreatedBy:   &userID,
+	}
+
+	created, err := s.commandRepo.CreateCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create command: %w", err)
+	}
+	if !created {
+		return nil, utils.ErrCommandExists
+	}
+
+	s.invalidateCommands()
+	return command, nil
+}
+
+// DeleteCommand deletes a third-party command, only workspace admins can delete commands
+func (s *CommandService) DeleteCommand(userID uuid.UUID, name string) error {
+	if !s.adminIDs[userID] {
+		return utils.ErrNotWorkspaceAdmin
+	}
+
+	deleted, err := s.commandRepo.DeleteCommand(strings.ToLower(strings.TrimPrefix(name, "/")))
+	if err != nil {
+		return fmt.Errorf("failed to delete command: %w", err)
+	}
+	if !deleted {
+		return utils.ErrCommandNotFound
+	}
+
+	s.invalidateCommands()
+	return nil
+}
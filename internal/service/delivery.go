@@ -0,0 +1,111 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// deliveryUpdateLimit caps the messages reported in the delivery updates of one cursor move,
+// senders refetch older messages to see their state
+const deliveryUpdateLimit = 200
+
+// MarkMessageDelivered records that a socket of the user received a message, and with it every
+// earlier message of the conversation
+// Returns the delivery updates to push to the senders of the messages
+func (s *ChatService) MarkMessageDelivered(messageID, userID uuid.UUID) ([]*models.DeliveryUpdate, error) {
+	message, err := s.messageRepo.GetMessageByID(messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	isParticipant, err := s.participantRepo.IsParticipant(message.ConversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	// The sender's own sockets receive the message too
+	if message.SenderID == userID {
+		return nil, nil
+	}
+
+	previous, moved, err := s.deliveryRepo.MarkDelivered(message.ConversationID, userID, message.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark message as delivered: %w", err)
+	}
+
+	if !moved {
+		return nil, nil
+	}
+
+	return s.deliveryUpdates(message.ConversationID, userID, previous, message.CreatedAt)
+}
+
+// deliveryUpdates builds the delivery updates of the messages a recipient's cursor passed, one per sender
+func (s *ChatService) deliveryUpdates(conversationID, recipientID uuid.UUID, after, upTo time.Time) ([]*models.DeliveryUpdate, error) {
+	passed, err := s.deliveryRepo.GetMessagesPassed(conversationID, recipientID, after, upTo, deliveryUpdateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	var messageIDs []uuid.UUID
+	for _, ids := range passed {
+		messageIDs = append(messageIDs, ids...)
+	}
+
+	states, err := s.deliveryRepo.GetDeliveryStates(messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery states: %w", err)
+	}
+
+	updates := make([]*models.DeliveryUpdate, 0, len(passed))
+	for senderID, ids := range passed {
+		update := &models.DeliveryUpdate{
+			ConversationID: conversationID,
+			SenderID:       senderID,
+			RecipientID:    recipientID,
+			Messages:       make(map[uuid.UUID]*models.DeliveryState, len(ids)),
+		}
+		for _, id := range ids {
+			if state, ok := states[id]; ok {
+				update.Messages[id] = state
+			}
+		}
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+// GetMessageReceipts gets the delivery state of a message for each of its recipients
+// Only the sender of the message can see them
+func (s *ChatService) GetMessageReceipts(conversationID, messageID, userID uuid.UUID) ([]*models.MessageReceipt, error) {
+	message, err := s.getConversationMessage(conversationID, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.SenderID != userID {
+		return nil, utils.ErrNotMessageSender
+	}
+
+	receipts, err := s.deliveryRepo.GetReceipts(message.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipts: %w", err)
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// SaveDraft saves the draft of a user in a conversation, empty content clears it
+// The draft is only saved if it is more recent than the stored one, otherwise the stored
+// draft is returned and saved is false. Times in the future are taken as now, so a device
+// with a fast clock cannot override every later edit.
+func (s *ChatService) SaveDraft(conversationID, userID uuid.UUID, req *models.SaveDraftRequest) (*models.MessageDraft, bool, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, false, utils.ErrNotParticipant
+	}
+
+	updatedAt := time.Now()
+	if req.UpdatedAt != nil && req.UpdatedAt.Before(updatedAt) {
+		updatedAt = *req.UpdatedAt
+	}
+
+	draft := &models.MessageDraft{
+		UserID:         userID,
+		ConversationID: conversationID,
+		Content:        req.Content,
+		Format:         messageFormat(req.Format),
+		UpdatedAt:      updatedAt,
+	}
+	if strings.TrimSpace(draft.Content) == "" {
+		draft.Content = ""
+		draft.Format = "plain"
+	}
+
+	saved, err := s.draftRepo.SaveDraft(draft)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save draft: %w", err)
+	}
+
+	if !saved {
+		current, err := s.draftRepo.GetDraft(userID, conversationID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get draft: %w", err)
+		}
+		return current, false, nil
+	}
+
+	return draft, true, nil
+}
+
+// GetDraft gets the draft of a user in a conversation
+func (s *ChatService) GetDraft(conversationID, userID uuid.UUID) (*models.MessageDraft, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	draft, err := s.draftRepo.GetDraft(userID, conversationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrDraftNotFound
+		}
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	// Cleared drafts are kept to order later saves, but there is nothing to restore
+	if draft.Content == "" {
+		return nil, utils.ErrDraftNotFound
+	}
+
+	return draft, nil
+}
+
+// ClearDraft clears the draft of a user in a conversation
+// Returns the cleared draft, or nil if there was no draft to clear
+func (s *ChatService) ClearDraft(conversationID, userID uuid.UUID) (*models.MessageDraft, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	now := time.Now()
+	cleared, err := s.draftRepo.ClearDraft(userID, conversationID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear draft: %w", err)
+	}
+
+	if !cleared {
+		return nil, nil
+	}
+
+	return &models.MessageDraft{
+		UserID:         userID,
+		ConversationID: conversationID,
+		Format:         "plain",
+		UpdatedAt:      now,
+	}, nil
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"goswift/internal/export"
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/internal/storage"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// exportBatchSize is the number of messages loaded at a time while writing an export
+const exportBatchSize = 500
+
+// unsafeFileNameChars are replaced in the conversation name used for export file names
+var unsafeFileNameChars = regexp.MustCompile(`[^\pL\pN._-]+`)
+
+// ExportService produces conversation transcripts for download
+type ExportService struct {
+	exportRepo       *repository.ExportRepository
+	conversationRepo *repository.ConversationRepository
+	participantRepo  *repository.ParticipantRepository
+	messageRepo      *repository.MessageRepository
+	attachmentRepo   *repository.AttachmentRepository
+	snippetRepo      *repository.SnippetRepository
+	storage          storage.Storage
+
+	adminIDs        map[uuid.UUID]bool
+	syncMaxMessages int
+	retention       time.Duration
+}
+
+// NewExportService creates a new export service
+// Conversations with up to syncMaxMessages messages are exported during the request, larger ones by the export worker.
+// Admins can export any conversation, export files are deleted after the retention period
+func NewExportService(
+	exportRepo *repository.ExportRepository,
+	conversationRepo *repository.ConversationRepository,
+	participantRepo *repository.ParticipantRepository,
+	messageRepo *repository.MessageRepository,
+	attachmentRepo *repository.AttachmentRepository,
+	snippetRepo *repository.SnippetRepository,
+	fileStorage storage.Storage,
+	adminIDs []string,
+	syncMaxMessages int,
+	retention time.Duration,
+) *ExportService {
+	return &ExportService{
+		exportRepo:       exportRepo,
+		conversationRepo: conversationRepo,
+		participantRepo:  participantRepo,
+		messageRepo:      messageRepo,
+		attachmentRepo:   attachmentRepo,
+		snippetRepo:      snippetRepo,
+		storage:          fileStorage,
+
+		adminIDs:        parseUserIDs(adminIDs),
+		syncMaxMessages: syncMaxMessages,
+		retention:       retention,
+	}
+}
+
+// parseUserIDs parses configured user IDs, skipping invalid ones
+func parseUserIDs(ids []string) map[uuid.UUID]bool {
+	userIDs := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			log.Printf("Ignoring invalid user ID %q in configuration", id)
+			continue
+		}
+		userIDs[userID] = true
+	}
+	return userIDs
+}
+
+// requireExportAccess checks that a user participates in a conversation or is a workspace admin
+func (s *ExportService) requireExportAccess(conversationID, userID uuid.UUID) error {
+	if s.adminIDs[userID] {
+		return nil
+	}
+
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check participant status: %w", err)
+	}
+	if !isParticipant {
+		return utils.ErrNotParticipant
+	}
+
+	return nil
+}
+
+// setDownloadURL sets the download URL of completed exports
+func setDownloadURL(exp *models.ConversationExport) {
+	if exp.Status == "completed" && exp.StorageKey != nil {
+		exp.DownloadURL = "/api/v1/exports/" + exp.ID.String() + "/download"
+	}
+}
+
+// RequestExport exports a conversation
+// Small conversations are exported right away and the export is returned completed,
+// larger ones are returned pending and exported in the background
+func (s *ExportService) RequestExport(ctx context.Context, conversationID, userID uuid.UUID, format string) (*models.ConversationExport, error) {
+	if err := s.requireExportAccess(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.conversationRepo.GetConversationByID(conversationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrConversationNotFound
+		}
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	count, err := s.messageRepo.CountMessages(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	exp := &models.ConversationExport{
+		ConversationID: conversationID,
+		RequestedBy:    userID,
+		Format:         format,
+		Status:         "pending",
+	}
+	if count <= s.syncMaxMessages {
+		exp.Status = "running"
+	}
+
+	if err := s.exportRepo.CreateExport(exp); err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	if exp.Status == "running" {
+		s.RunExport(ctx, exp)
+	}
+
+	return exp, nil
+}
+
+// GetExport gets an export requested by the user
+func (s *ExportService) GetExport(exportID, userID uuid.UUID) (*models.ConversationExport, error) {
+	exp, err := s.exportRepo.GetExportByID(exportID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	// Other users, even participants, do not learn about the exports of someone else
+	if exp.RequestedBy != userID && !s.adminIDs[userID] {
+		return nil, utils.ErrExportNotFound
+	}
+
+	setDownloadURL(exp)
+	return exp, nil
+}
+
+// OpenExport opens the file of a completed export for download
+// The requester must still have access to the conversation
+func (s *ExportService) OpenExport(ctx context.Context, exportID, userID uuid.UUID) (*models.ConversationExport, io.ReadCloser, error) {
+	exp, err := s.GetExport(exportID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.requireExportAccess(exp.ConversationID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	if exp.Status != "completed" {
+		return nil, nil, utils.ErrExportNotReady
+	}
+	if exp.StorageKey == nil {
+		return nil, nil, utils.ErrExportExpired
+	}
+
+	reader, err := s.storage.Get(ctx, *exp.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil, utils.ErrExportExpired
+		}
+		return nil, nil, fmt.Errorf("failed to open export: %w", err)
+	}
+
+	return exp, reader, nil
+}
+
+// ClaimExports claims pending exports for the export worker
+// Exports left running longer than staleAfter by a stopped instance are claimed again
+func (s *ExportService) ClaimExports(limit int, staleAfter time.Duration) ([]*models.ConversationExport, error) {
+	return s.exportRepo.ClaimExports(limit, time.Now().Add(-staleAfter))
+}
+
+// RunExport writes a claimed export to storage and records the outcome on it
+// Failures are recorded on the export, they are not retried
+func (s *ExportService) RunExport(ctx context.Context, exp *models.ConversationExport) {
+	if err := s.writeExport(ctx, exp); err != nil {
+		log.Printf("Error exporting conversation %s: %v", exp.ConversationID, err)
+
+		reason := "export could not be produced"
+		exp.Status = "failed"
+		exp.Error = &reason
+		if err := s.exportRepo.MarkExportFailed(exp.ID, reason); err != nil {
+			log.Printf("Error marking export %s as failed: %v", exp.ID, err)
+		}
+		return
+	}
+
+	if err := s.exportRepo.MarkExportCompleted(exp); err != nil {
+		log.Printf("Error marking export %s as completed: %v", exp.ID, err)
+	}
+	setDownloadURL(exp)
+}
+
+// writeExport writes the transcript to a temporary file, then stores it
+func (s *ExportService) writeExport(ctx context.Context, exp *models.ConversationExport) error {
+	conversation, err := s.conversationRepo.GetConversationByID(exp.ConversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "goswift-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	count, err := s.writeTranscript(tmp, conversation, exp)
+	if err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to measure export: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind export: %w", err)
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.%s", exp.ConversationID, exp.ID, exp.Format)
+	if err := s.storage.Put(ctx, key, tmp, size, export.ContentType(exp.Format)); err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+
+	name := strings.Trim(unsafeFileNameChars.ReplaceAllString(conversation.Name, "-"), "-")
+	if name == "" {
+		name = "conversation"
+	}
+	fileName := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102-150405"), exp.Format)
+
+	now := time.Now()
+	expiresAt := now.Add(s.retention)
+	exp.Status = "completed"
+	exp.StorageKey = &key
+	exp.FileName = &fileName
+	exp.SizeBytes = &size
+	exp.MessageCount = &count
+	exp.CompletedAt = &now
+	exp.ExpiresAt = &expiresAt
+
+	return nil
+}
+
+// writeTranscript writes the participants and every message of a conversation
+// Returns the number of messages written
+func (s *ExportService) writeTranscript(w io.Writer, conversation *models.Conversation, exp *models.ConversationExport) (int, error) {
+	writer, err := export.NewWriter(exp.Format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	participants, err := s.participantRepo.GetParticipantsByConversationID(conversation.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get participants: %w", err)
+	}
+
+	header := &export.Header{
+		Conversation: export.Conversation{
+			ID:        conversation.ID,
+			Name:      conversation.Name,
+			Type:      conversation.Type,
+			CreatedBy: conversation.CreatedBy,
+			CreatedAt: conversation.CreatedAt,
+		},
+		Participants: make([]export.Participant, 0, len(participants)),
+		ExportedAt:   time.Now(),
+		ExportedBy:   exp.RequestedBy,
+	}
+	for _, p := range participants {
+		participant := export.Participant{UserID: p.UserID, IsAdmin: p.IsAdmin, JoinedAt: p.JoinedAt}
+		if p.User != nil {
+			participant.DisplayName = p.User.DisplayName
+			participant.Email = p.User.Email
+		}
+		header.Participants = append(header.Participants, participant)
+	}
+
+	if err := writer.Begin(header); err != nil {
+		return 0, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	count := 0
+	var afterCreatedAt *time.Time
+	var afterID uuid.UUID
+	for {
+		messages, err := s.messageRepo.GetConversationHistory(conversation.ID, afterCreatedAt, afterID, exportBatchSize)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get messages: %w", err)
+		}
+
+		messageIDs := make([]uuid.UUID, 0, len(messages))
+		var snippetIDs []uuid.UUID
+		for _, message := range messages {
+			messageIDs = append(messageIDs, message.ID)
+			if message.MessageType == "snippet" {
+				snippetIDs = append(snippetIDs, message.ID)
+			}
+		}
+		attachments, err := s.attachmentRepo.GetAttachmentsByMessageIDs(messageIDs)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get attachments: %w", err)
+		}
+		snippets, err := s.snippetRepo.GetSnippetsByMessageIDs(snippetIDs, 0)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get snippets: %w", err)
+		}
+
+		for _, message := range messages {
+			if err := writer.WriteMessage(newExportMessage(message, attachments[message.ID], snippets[message.ID])); err != nil {
+				return 0, fmt.Errorf("failed to write export: %w", err)
+			}
+		}
+		count += len(messages)
+
+		if len(messages) < exportBatchSize {
+			break
+		}
+		last := messages[len(messages)-1]
+		afterCreatedAt = &last.CreatedAt
+		afterID = last.ID
+	}
+
+	if err := writer.End(); err != nil {
+		return 0, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	return count, nil
+}
+
+// newExportMessage converts a message with its attachments and snippet to their exported form
+func newExportMessage(message *models.Message, attachments []*models.Attachment, snippet *models.Snippet) *export.Message {
+	exported := &export.Message{
+		ID:           message.ID,
+		SenderID:     message.SenderID,
+		SenderName:   message.SenderName,
+		MessageType:  message.MessageType,
+		Content:      message.Content,
+		CreatedAt:    message.CreatedAt,
+		EditedAt:     message.EditedAt,
+		ReplyToID:    message.ReplyToID,
+		ThreadRootID: message.ThreadRootID,
+		SystemEvent:  message.SystemEvent,
+		Payload:      message.Payload,
+	}
+
+	if message.ForwardedFromCreatedAt != nil {
+		exported.ForwardedFrom = &export.ForwardedFrom{CreatedAt: *message.ForwardedFromCreatedAt}
+		if message.ForwardedFromSenderName != nil {
+			exported.ForwardedFrom.SenderName = *message.ForwardedFromSenderName
+		}
+	}
+
+	if snippet != nil {
+		exported.Snippet = &export.Snippet{Language: snippet.Language, Filename: snippet.Filename, Code: snippet.Code}
+	}
+
+	for _, attachment := range attachments {
+		exported.Attachments = append(exported.Attachments, export.Attachment{
+			ID:          attachment.ID,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			SizeBytes:   attachment.SizeBytes,
+			Width:       attachment.Width,
+			Height:      attachment.Height,
+			DurationMs:  attachment.DurationMs,
+		})
+	}
+
+	return exported
+}
+
+// DeleteExpiredExports deletes the files of exports past their retention period
+func (s *ExportService) DeleteExpiredExports(ctx context.Context, limit int) (int, error) {
+	keys, err := s.exportRepo.ExpireExports(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting export file %s: %v", key, err)
+		}
+	}
+
+	return len(keys), nil
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/internal/slackimport"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// slackImportBatchSize is the maximum number of messages inserted per transaction
+const slackImportBatchSize = 500
+
+// importedSlackSubtypes are the message subtypes imported, others are joins, topic changes, bot messages...
+var importedSlackSubtypes = map[string]bool{
+	"":                 true,
+	"thread_broadcast": true, // Thread reply also sent to the channel
+	"me_message":       true,
+	"file_share":       true,
+}
+
+// ImportService imports chat history from other chat applications
+type ImportService struct {
+	slackImportRepo *repository.SlackImportRepository
+	adminIDs        map[uuid.UUID]bool
+	maxArchiveSize  int64
+}
+
+// NewImportService creates a new import service
+// Only workspace admins can import through the API, with archives up to maxArchiveSize bytes
+func NewImportService(slackImportRepo *repository.SlackImportRepository, adminIDs []string, maxArchiveSize int64) *ImportService {
+	return &ImportService{
+		slackImportRepo: slackImportRepo,
+		adminIDs:        parseUserIDs(adminIDs),
+		maxArchiveSize:  maxArchiveSize,
+	}
+}
+
+// MaxArchiveSize returns the maximum size in bytes of archives uploaded to the API
+func (s *ImportService) MaxArchiveSize() int64 {
+	return s.maxArchiveSize
+}
+
+// ImportSlackArchive imports a Slack export archive on behalf of a user, who must be a workspace admin
+func (s *ImportService) ImportSlackArchive(userID uuid.UUID, r io.ReaderAt, size int64) (*models.SlackImportReport, error) {
+	if !s.adminIDs[userID] {
+		return nil, utils.ErrNotWorkspaceAdmin
+	}
+	if size > s.maxArchiveSize {
+		return nil, utils.ErrFileTooLarge
+	}
+
+	return s.ImportSlack(r, size)
+}
+
+// ImportSlack imports the channels of a Slack export archive with their members and messages
+// Slack users are mapped to GoSwift users by email, messages of unmapped users are skipped.
+// Channels and messages imported by a previous run are not imported again, so an import can be
+// re-run after it failed or after accounts were created for unmapped users
+func (s *ImportService) ImportSlack(r io.ReaderAt, size int64) (*models.SlackImportReport, error) {
+	archive, err := slackimport.Open(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]*slackimport.User, len(archive.Users))
+	emails := make([]string, 0, len(archive.Users))
+	for _, user := range archive.Users {
+		users[user.ID] = user
+		if user.Profile.Email != "" {
+			emails = append(emails, user.Profile.Email)
+		}
+	}
+
+	userIDsByEmail, err := s.slackImportRepo.GetUserIDsByEmails(emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map users: %w", err)
+	}
+
+	userIDs := make(map[string]uuid.UUID, len(userIDsByEmail))
+	for _, user := range archive.Users {
+		if id, ok := userIDsByEmail[strings.ToLower(user.Profile.Email)]; ok && user.Profile.Email != "" {
+			userIDs[user.ID] = id
+		}
+	}
+
+	imp := &slackImport{
+		repo:     s.slackImportRepo,
+		archive:  archive,
+		users:    users,
+		userIDs:  userIDs,
+		report:   &models.SlackImportReport{},
+		unmapped: make(map[string]bool),
+	}
+
+	for _, channel := range archive.Channels {
+		if err := imp.importChannel(channel); err != nil {
+			return nil, fmt.Errorf("failed to import channel %s: %w", channel.ID, err)
+		}
+	}
+
+	imp.report.UnmappedUsers = make([]string, 0, len(imp.unmapped))
+	for user := range imp.unmapped {
+		imp.report.UnmappedUsers = append(imp.report.UnmappedUsers, user)
+	}
+	sort.Strings(imp.report.UnmappedUsers)
+
+	return imp.report, nil
+}
+
+// slackImport is the state of a running Slack import
+type slackImport struct {
+	repo     *repository.SlackImportRepository
+	archive  *slackimport.Archive
+	users    map[string]*slackimport.User // By Slack user ID
+	userIDs  map[string]uuid.UUID         // GoSwift user IDs by Slack user ID
+	report   *models.SlackImportReport
+	unmapped map[string]bool
+}
+
+// importChannel imports a channel, its members and its messages not imported yet
+func (imp *slackImport) importChannel(channel *slackimport.Channel) error {
+	var members []uuid.UUID
+	for _, member := range channel.Members {
+		if id, ok := imp.userIDs[member]; ok {
+			members = append(members, id)
+		}
+	}
+
+	conversationID, err := imp.repo.GetImportedConversationID(channel.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if len(members) == 0 {
+			imp.report.ConversationsSkipped++
+			return nil
+		}
+
+		conversation := imp.newConversation(channel, members)
+		if err := imp.repo.CreateImportedConversation(conversation, channel.ID); err != nil {
+			return fmt.Errorf("failed to create conversation: %w", err)
+		}
+		conversationID = conversation.ID
+		imp.report.ConversationsCreated++
+		imp.report.ParticipantsAdded++
+	} else if err != nil {
+		return fmt.Errorf("failed to get imported conversation: %w", err)
+	}
+
+	added, err := imp.repo.AddParticipants(conversationID, members)
+	if err != nil {
+		return fmt.Errorf("failed to add participants: %w", err)
+	}
+	imp.report.ParticipantsAdded += added
+
+	imported, err := imp.repo.GetImportedMessages(channel.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get imported messages: %w", err)
+	}
+
+	for _, day := range imp.archive.Days(channel) {
+		messages, err := imp.archive.ReadDay(day)
+		if err != nil {
+			return err
+		}
+
+		batch := make([]*repository.ImportedMessage, 0, len(messages))
+		for _, message := range messages {
+			m := imp.newMessage(conversationID, message, imported)
+			if m == nil {
+				continue
+			}
+			batch = append(batch, m)
+
+			if len(batch) == slackImportBatchSize {
+				if err := imp.importMessages(channel, batch, imported); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+
+		if err := imp.importMessages(channel, batch, imported); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Imported Slack channel %s (%s)", channel.ID, channel.Name)
+	return nil
+}
+
+// importMessages inserts a batch of messages of a channel
+func (imp *slackImport) importMessages(channel *slackimport.Channel, batch []*repository.ImportedMessage, imported map[string]uuid.UUID) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	reactions, err := imp.repo.ImportMessages(channel.ID, batch, imported)
+	if err != nil {
+		return fmt.Errorf("failed to import messages: %w", err)
+	}
+
+	imp.report.MessagesImported += len(batch)
+	imp.report.ReactionsImported += reactions
+	return nil
+}
+
+// newConversation creates the conversation of a channel
+// The channel creator is the conversation creator if they are a member, the first member otherwise
+func (imp *slackImport) newConversation(channel *slackimport.Channel, members []uuid.UUID) *models.Conversation {
+	createdBy := members[0]
+	if id, ok := imp.userIDs[channel.Creator]; ok {
+		for _, member := range members {
+			if member == id {
+				createdBy = id
+				break
+			}
+		}
+	}
+
+	conversation := &models.Conversation{
+		Name:      channel.Name,
+		Type:      "group",
+		CreatedBy: createdBy,
+	}
+	if channel.Created > 0 {
+		conversation.CreatedAt = time.Unix(channel.Created, 0)
+	}
+
+	// Direct messages have no name and group ones are named after their members' handles
+	if channel.Kind == slackimport.KindDM || channel.Kind == slackimport.KindMPIM {
+		names := make([]string, 0, len(channel.Members))
+		for _, member := range channel.Members {
+			if user, ok := imp.users[member]; ok {
+				names = append(names, user.DisplayName())
+			}
+		}
+		conversation.Name = strings.Join(names, ", ")
+	}
+
+	// Conversation names are limited to 100 characters
+	if name := []rune(conversation.Name); len(name) > 100 {
+		conversation.Name = string(name[:99]) + "…"
+	}
+	if channel.Kind == slackimport.KindDM {
+		conversation.Type = "direct"
+	}
+
+	return conversation
+}
+
+// newMessage converts a Slack message to the message to import
+// Returns nil for messages that are not imported: already imported ones, messages of unmapped
+// users and events like channel joins
+func (imp *slackImport) newMessage(conversationID uuid.UUID, message *slackimport.Message, imported map[string]uuid.UUID) *repository.ImportedMessage {
+	if message.Type != "message" || !importedSlackSubtypes[message.Subtype] {
+		return nil
+	}
+
+	if _, ok := imported[message.TS]; ok {
+		imp.report.MessagesExisting++
+		return nil
+	}
+
+	senderID, ok := imp.userIDs[message.User]
+	if !ok {
+		imp.report.MessagesUnmapped++
+		if message.User != "" {
+			imp.unmapped[imp.userLabel(message.User)] = true
+		}
+		return nil
+	}
+
+	createdAt, err := slackimport.ParseTimestamp(message.TS)
+	if err != nil {
+		log.Printf("Skipping Slack message with %v", err)
+		return nil
+	}
+
+	// Exports only link to shared files, their names are kept in the content
+	lines := []string{slackimport.FormatText(message.Text, imp.users)}
+	for _, file := range message.Files {
+		name := file.Name
+		if name == "" {
+			name = file.Title
+		}
+		if name != "" {
+			lines = append(lines, "📎 "+name)
+		}
+	}
+	content := strings.TrimSpace(strings.Join(lines, "\n"))
+	if content == "" {
+		return nil
+	}
+
+	m := &repository.ImportedMessage{
+		Message: &models.Message{
+			ConversationID: conversationID,
+			SenderID:       senderID,
+			Content:        content,
+			MessageType:    "text",
+			IsRead:         true,
+			CreatedAt:      createdAt,
+			UpdatedAt:      createdAt,
+		},
+		TS:        message.TS,
+		Reactions: make(map[string][]uuid.UUID),
+	}
+
+	if message.IsReply() {
+		m.ThreadTS = message.ThreadTS
+	}
+
+	if message.Edited != nil {
+		if editedAt, err := slackimport.ParseTimestamp(message.Edited.TS); err == nil {
+			m.Message.EditedAt = &editedAt
+			m.Message.UpdatedAt = editedAt
+		}
+	}
+
+	for _, reaction := range message.Reactions {
+		emoji := slackimport.ReactionEmoji(reaction.Name)
+		if utils.ValidateReaction(emoji) != nil {
+			continue
+		}
+		for _, user := range reaction.Users {
+			if id, ok := imp.userIDs[user]; ok {
+				m.Reactions[emoji] = append(m.Reactions[emoji], id)
+			}
+		}
+	}
+
+	return m
+}
+
+// userLabel identifies a Slack user in the report, by email if known
+func (imp *slackImport) userLabel(slackUserID string) string {
+	user, ok := imp.users[slackUserID]
+	if !ok {
+		return slackUserID
+	}
+	if user.Profile.Email != "" {
+		return user.Profile.Email
+	}
+	return user.DisplayName()
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"goswift/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Special mentions addressing several participants at once
+const (
+	mentionEveryone = "everyone" // All participants
+	mentionHere     = "here"     // Participants who are online
+)
+
+// mentionToken is an @mention found in message content, before it is resolved to a user
+type mentionToken struct {
+	Handle string // Lowercased, without the @
+	Offset int    // In characters
+	Length int    // In characters, including the @
+}
+
+// parseMentions finds the @handle mentions in message content
+// An @ preceded by a handle character, as in an email address, does not start a mention
+func parseMentions(content string) []mentionToken {
+	runes := []rune(content)
+
+	var tokens []mentionToken
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isHandleRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHandleRune(runes[end]) {
+			end++
+		}
+
+		// Trailing dots and dashes end the sentence rather than the handle
+		for end > i+1 && (runes[end-1] == '.' || runes[end-1] == '-') {
+			end--
+		}
+
+		if end == i+1 {
+			continue
+		}
+
+		tokens = append(tokens, mentionToken{
+			Handle: strings.ToLower(string(runes[i+1 : end])),
+			Offset: i,
+			Length: end - i,
+		})
+		i = end - 1
+	}
+
+	return tokens
+}
+
+// isHandleRune reports whether a character can be part of a mention handle
+func isHandleRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '.' || r == '_' || r == '-'
+}
+
+// participantHandles returns the handles a participant can be mentioned with:
+// the local part of their email and their display name without spaces
+func participantHandles(user *models.User) []string {
+	handles := make([]string, 0, 2)
+
+	if at := strings.LastIndex(user.Email, "@"); at > 0 {
+		handles = append(handles, strings.ToLower(user.Email[:at]))
+	}
+
+	displayName := strings.ToLower(strings.Join(strings.Fields(user.DisplayName), ""))
+	if displayName != "" {
+		handles = append(handles, displayName)
+	}
+
+	return handles
+}
+
+// resolveMentions resolves the @mentions of message content against the conversation participants
+// Handles matching no participant, or several, are left as plain text
+func (s *ChatService) resolveMentions(conversationID uuid.UUID, content string) ([]models.MessageMention, error) {
+	tokens := parseMentions(content)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	participants, err := s.participantRepo.GetParticipantsByConversationID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// uuid.Nil marks handles shared by several participants
+	userIDs := make(map[string]uuid.UUID)
+	for _, participant := range participants {
+		if participant.User == nil {
+			continue
+		}
+		for _, handle := range participantHandles(participant.User) {
+			if existing, ok := userIDs[handle]; ok && existing != participant.UserID {
+				userIDs[handle] = uuid.Nil
+				continue
+			}
+			userIDs[handle] = participant.UserID
+		}
+	}
+
+	mentions := make([]models.MessageMention, 0, len(tokens))
+	for _, token := range tokens {
+		mention := models.MessageMention{
+			ConversationID: conversationID,
+			Offset:         token.Offset,
+			Length:         token.Length,
+		}
+
+		switch token.Handle {
+		case mentionEveryone, mentionHere:
+			mention.Type = token.Handle
+		default:
+			userID, ok := userIDs[token.Handle]
+			if !ok || userID == uuid.Nil {
+				continue
+			}
+			mention.Type = "user"
+			mention.UserID = &userID
+		}
+
+		mentions = append(mentions, mention)
+	}
+
+	return mentions, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"goswift/internal/models"
+	"goswift/pkg/utils"
+)
+
+// builtinMessageTypes are the message types users can send without a payload
+var builtinMessageTypes = map[string]bool{
+	"text":    true,
+	"image":   true,
+	"file":    true,
+	"voice":   true,
+	"snippet": true,
+}
+
+// isMessageType reports whether messages of a type can exist, built-in or with a registered payload kind
+func (s *ChatService) isMessageType(messageType string) bool {
+	if builtinMessageTypes[messageType] || messageType == "system" || messageType == "poll" {
+		return true
+	}
+
+	_, ok := s.messageKinds.Lookup(messageType)
+	return ok
+}
+
+// hasPayload reports whether a request carries a payload, an explicit null counts as none
+func hasPayload(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && !bytes.Equal(raw, []byte("null"))
+}
+
+// decodePayload validates the payload of a typed message against its kind
+// The request content is replaced by the plain-text fallback of the payload, which is what gets
+// moderated, searched and shown in previews. Returns the normalized payload, nil for built-in types
+func (s *ChatService) decodePayload(req *models.SendMessageRequest) (json.RawMessage, error) {
+	if builtinMessageTypes[req.MessageType] {
+		if hasPayload(req.Payload) {
+			return nil, utils.ErrPayloadNotAllowed
+		}
+		req.Payload = nil
+		return nil, nil
+	}
+
+	kind, ok := s.messageKinds.Lookup(req.MessageType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnknownMessageType, req.MessageType)
+	}
+	if req.Content != "" {
+		return nil, utils.ErrTypedMessageContent
+	}
+
+	p, normalized, err := kind.Decode(req.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidPayload, err)
+	}
+
+	req.Payload = normalized
+	req.Content = p.Text()
+	req.Format = "plain"
+	return normalized, nil
+}
+
+// MessageKinds lists the message types with a structured payload that can be sent
+func (s *ChatService) MessageKinds() []*models.MessageKind {
+	kinds := s.messageKinds.Kinds()
+
+	result := make([]*models.MessageKind, 0, len(kinds))
+	for _, kind := range kinds {
+		result = append(result, &models.MessageKind{
+			Name:        kind.Name,
+			Description: kind.Description,
+			Schema:      kind.Schema,
+		})
+	}
+	return result
+}
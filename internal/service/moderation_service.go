@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/moderation"
+	"goswift/internal/repository"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// moderationRulesRefreshInterval bounds how long rules changed on another server instance take to apply
+const moderationRulesRefreshInterval = 30 * time.Second
+
+// ModerationService checks messages before they are saved and manages the rules and review queue
+type ModerationService struct {
+	moderationRepo *repository.ModerationRepository
+	moderatorIDs   map[uuid.UUID]bool
+	classifier     moderation.Hook // External classifier, nil if not configured
+
+	mu           sync.Mutex
+	ruleHooks    []moderation.Hook
+	rulesLoaded  time.Time
+	rulesChanged bool
+}
+
+// NewModerationService creates a new moderation service
+// Moderators manage the rules and review flagged messages, the classifier runs after the rules
+func NewModerationService(moderationRepo *repository.ModerationRepository, moderatorIDs []string, classifier moderation.Hook) *ModerationService {
+	return &ModerationService{
+		moderationRepo: moderationRepo,
+		moderatorIDs:   parseUserIDs(moderatorIDs),
+		classifier:     classifier,
+	}
+}
+
+// requireModerator checks that a user can manage moderation
+func (s *ModerationService) requireModerator(userID uuid.UUID) error {
+	if !s.moderatorIDs[userID] {
+		return utils.ErrNotModerator
+	}
+	return nil
+}
+
+// pipeline builds the hook chain from the cached rules, reloading them when they changed or are stale
+// If the rules cannot be loaded, the previously loaded rules keep applying
+func (s *ModerationService) pipeline() *moderation.Pipeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rulesChanged || time.Since(s.rulesLoaded) >= moderationRulesRefreshInterval {
+		hooks, err := s.loadRuleHooks()
+		if err != nil {
+			log.Printf("Error loading moderation rules: %v", err)
+		} else {
+			s.ruleHooks = hooks
+			s.rulesChanged = false
+		}
+		// Failed loads are retried after the interval, not on every message
+		s.rulesLoaded = time.Now()
+	}
+
+	hooks := append([]moderation.Hook(nil), s.ruleHooks...)
+	if s.classifier != nil {
+		hooks = append(hooks, s.classifier)
+	}
+
+	return moderation.NewPipeline(hooks...)
+}
+
+// loadRuleHooks creates a hook for each stored rule
+func (s *ModerationService) loadRuleHooks() ([]moderation.Hook, error) {
+	rules, err := s.moderationRepo.GetRules()
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := make([]moderation.Hook, 0, len(rules))
+	for _, rule := range rules {
+		hook, err := moderation.NewRuleHook(moderation.Rule{
+			Name:    "rule:" + rule.ID.String(),
+			Kind:    rule.Kind,
+			Pattern: rule.Pattern,
+			Action:  rule.Action,
+			Reason:  rule.Reason,
+		})
+		if err != nil {
+			log.Printf("Skipping invalid moderation rule %s: %v", rule.ID, err)
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, nil
+}
+
+// invalidateRules makes the next message reload the rules
+func (s *ModerationService) invalidateRules() {
+	s.mu.Lock()
+	s.rulesChanged = true
+	s.mu.Unlock()
+}
+
+// Moderate runs a message about to be sent through the rules and the classifier
+// Messages without text are allowed as is
+func (s *ModerationService) Moderate(conversationID, senderID uuid.UUID, content string) *moderation.Result {
+	if strings.TrimSpace(content) == "" {
+		return &moderation.Result{Content: content}
+	}
+
+	return s.pipeline().Run(context.Background(), &moderation.Input{
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Content:        content,
+	})
+}
+
+// RecordFlags adds a saved message to the review queue for each hook that flagged it
+// The content is the message as sent, before redactions
+func (s *ModerationService) RecordFlags(message *models.Message, content string, flags []moderation.Flag) error {
+	records := make([]*models.ModerationFlag, 0, len(flags))
+	for _, flag := range flags {
+		records = append(records, &models.ModerationFlag{
+			MessageID:      &message.ID,
+			ConversationID: message.ConversationID,
+			SenderID:       message.SenderID,
+			Hook:           flag.Hook,
+			Reason:         flag.Reason,
+			Content:        content,
+		})
+	}
+
+	return s.moderationRepo.CreateFlags(records)
+}
+
+// CreateRule adds a moderation rule, applied to the messages sent from now on
+func (s *ModerationService) CreateRule(userID uuid.UUID, req *models.CreateModerationRuleRequest) (*models.ModerationRule, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, err
+	}
+
+	pattern, err := moderation.NormalizePattern(req.Kind, req.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidModerationRule, err)
+	}
+
+	rule := &models.ModerationRule{
+		Kind:      req.Kind,
+		Pattern:   pattern,
+		Action:    req.Action,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: &userID,
+	}
+
+	created, err := s.moderationRepo.CreateRule(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule: %w", err)
+	}
+	if !created {
+		return nil, utils.ErrModerationRuleExists
+	}
+
+	s.invalidateRules()
+	return rule, nil
+}
+
+// GetRules gets all moderation rules
+func (s *ModerationService) GetRules(userID uuid.UUID) ([]*models.ModerationRule, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, err
+	}
+
+	rules, err := s.moderationRepo.GetRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rules: %w", err)
+	}
+
+	if rules == nil {
+		rules = []*models.ModerationRule{}
+	}
+	return rules, nil
+}
+
+// DeleteRule deletes a moderation rule
+func (s *ModerationService) DeleteRule(userID, ruleID uuid.UUID) error {
+	if err := s.requireModerator(userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.moderationRepo.DeleteRule(ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	if !deleted {
+		return utils.ErrModerationRuleNotFound
+	}
+
+	s.invalidateRules()
+	return nil
+}
+
+// GetFlags gets the flagged messages with a status
+func (s *ModerationService) GetFlags(userID uuid.UUID, status string, limit, offset int) ([]*models.ModerationFlag, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, err
+	}
+
+	flags, err := s.moderationRepo.GetFlags(status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flags: %w", err)
+	}
+
+	if flags == nil {
+		flags = []*models.ModerationFlag{}
+	}
+	return flags, nil
+}
+
+// ReviewFlag dismisses a flag or removes the flagged message
+// Removing deletes the message with its thread replies, which are returned with their attachments
+func (s *ModerationService) ReviewFlag(userID, flagID uuid.UUID, decision string) (*models.ModerationFlag, []*models.Message, []*models.Attachment, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var reviewed bool
+	var messages []*models.Message
+	var attachments []*models.Attachment
+	var err error
+	if decision == "remove" {
+		reviewed, messages, attachments, err = s.moderationRepo.RemoveFlaggedMessage(flagID, userID)
+	} else {
+		reviewed, err = s.moderationRepo.DismissFlag(flagID, userID)
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to review flag: %w", err)
+	}
+
+	flag, err := s.moderationRepo.GetFlagByID(flagID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, nil, utils.ErrModerationFlagNotFound
+		}
+		return nil, nil, nil, fmt.Errorf("failed to get flag: %w", err)
+	}
+
+	if !reviewed {
+		return nil, nil, nil, utils.ErrModerationFlagReviewed
+	}
+
+	return flag, messages, attachments, nil
+}
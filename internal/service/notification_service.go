@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// notificationPreviewLength is the maximum number of characters of message content kept in a notification
+const notificationPreviewLength = 100
+
+// NotificationService handles the notification feed
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// NotifyThreadReply creates thread reply notifications for the given followers
+func (s *NotificationService) NotifyThreadReply(reply *models.MessageResponse, followerIDs []uuid.UUID) ([]*models.Notification, error) {
+	notifications := make([]*models.Notification, 0, len(followerIDs))
+	for _, followerID := range followerIDs {
+		notification := &models.Notification{
+			UserID:         followerID,
+			Type:           "thread_reply",
+			ConversationID: &reply.ConversationID,
+			MessageID:      &reply.ID,
+			ActorID:        &reply.SenderID,
+			Content:        fmt.Sprintf("%s replied in a thread: %s", reply.SenderName, truncateText(reply.Content, notificationPreviewLength)),
+		}
+
+		if err := s.notificationRepo.CreateNotification(notification); err != nil {
+			return notifications, fmt.Errorf("failed to create notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// NotifyMention creates mention notifications for the given users
+// Mentions notify even in muted conversations
+func (s *NotificationService) NotifyMention(message *models.MessageResponse, userIDs []uuid.UUID) ([]*models.Notification, error) {
+	notifications := make([]*models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notification := &models.Notification{
+			UserID:         userID,
+			Type:           "mention",
+			ConversationID: &message.ConversationID,
+			MessageID:      &message.ID,
+			ActorID:        &message.SenderID,
+			Content:        fmt.Sprintf("%s mentioned you: %s", message.SenderName, truncateText(message.Content, notificationPreviewLength)),
+		}
+
+		if err := s.notificationRepo.CreateNotification(notification); err != nil {
+			return notifications, fmt.Errorf("failed to create notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// NotifyReminder creates the notification of a due reminder, showing its note or else the message it is about
+func (s *NotificationService) NotifyReminder(reminder *models.Reminder) (*models.Notification, error) {
+	content := "Reminder about this conversation"
+	switch {
+	case reminder.Note != "":
+		content = "Reminder: " + truncateText(reminder.Note, notificationPreviewLength)
+	case reminder.MessagePreview != "":
+		content = "Reminder: " + truncateText(reminder.MessagePreview, notificationPreviewLength)
+	}
+
+	notification := &models.Notification{
+		UserID:         reminder.UserID,
+		Type:           "reminder",
+		ConversationID: &reminder.ConversationID,
+		MessageID:      reminder.MessageID,
+		Content:        content,
+	}
+
+	if err := s.notificationRepo.CreateNotification(notification); err != nil {
+		return nil, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return notification, nil
+}
+
+// GetNotifications gets the notification feed of a user
+func (s *NotificationService) GetNotifications(userID uuid.UUID, limit, offset int) ([]*models.Notification, error) {
+	notifications, err := s.notificationRepo.GetNotificationsByUserID(userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	if notifications == nil {
+		notifications = []*models.Notification{}
+	}
+
+	return notifications, nil
+}
+
+// MarkAsRead marks a notification as read
+func (s *NotificationService) MarkAsRead(notificationID, userID uuid.UUID) error {
+	found, err := s.notificationRepo.MarkNotificationAsRead(notificationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+
+	if !found {
+		return utils.ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// truncateText shortens text to at most max characters
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "..."
+}
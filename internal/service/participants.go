@@ -0,0 +1,420 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"goswift/internal/models"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// maxTopicLength is the maximum length of a conversation topic in characters
+const maxTopicLength = 250
+
+// requireGroupAdmin checks that a conversation is a group and the user one of its admins
+func (s *ChatService) requireGroupAdmin(conversationID, userID uuid.UUID) error {
+	participant, err := s.participantRepo.GetParticipant(conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.ErrNotParticipant
+		}
+		return fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	if conversation.Type != "group" {
+		return utils.ErrGroupOnly
+	}
+
+	if !participant.IsAdmin {
+		return utils.ErrNotConversationAdmin
+	}
+
+	return nil
+}
+
+// getMember gets a member of a conversation and their user
+func (s *ChatService) getMember(conversationID, userID uuid.UUID) (*models.ConversationParticipant, *models.User, error) {
+	participant, err := s.participantRepo.GetParticipant(conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, utils.ErrMemberNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return participant, user, nil
+}
+
+// RenameConversation renames a conversation
+// Only admins can rename a group. Returns the system message recording it, which is nil if the
+// name did not change or the message could not be created
+func (s *ChatService) RenameConversation(conversationID, userID uuid.UUID, name string) (*models.MessageResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, utils.ErrConversationNameRequired
+	}
+
+	if err := s.requireConversationAdmin(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	if conversation.Name == name {
+		return nil, nil
+	}
+
+	oldName := conversation.Name
+	conversation.Name = name
+	if err := s.conversationRepo.UpdateConversation(conversation); err != nil {
+		return nil, fmt.Errorf("failed to rename conversation: %w", err)
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for rename system message: %v", userID, err)
+		return nil, nil
+	}
+
+	event := &models.SystemEvent{
+		Event:    models.SystemEventConversationRenamed,
+		OldValue: oldName,
+		NewValue: name,
+	}
+	content := fmt.Sprintf("%s renamed the conversation to %q", actor.DisplayName, name)
+
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording rename of conversation %s: %v", conversationID, err)
+	}
+
+	return systemMessage, nil
+}
+
+// SetConversationTopic sets the topic of a conversation, an empty topic clears it
+// Any participant can change the topic. Returns the system message recording it, which is nil
+// if the topic did not change or the message could not be created
+func (s *ChatService) SetConversationTopic(conversationID, userID uuid.UUID, topic string) (*models.MessageResponse, error) {
+	topic = strings.TrimSpace(topic)
+	if len([]rune(topic)) > maxTopicLength {
+		return nil, utils.ErrTopicTooLong
+	}
+
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	if conversation.Topic == topic {
+		return nil, nil
+	}
+
+	if err := s.conversationRepo.UpdateTopic(conversationID, topic); err != nil {
+		return nil, fmt.Errorf("failed to set topic: %w", err)
+	}
+
+	event := &models.SystemEvent{
+		Event:    models.SystemEventTopicChanged,
+		OldValue: conversation.Topic,
+		NewValue: topic,
+	}
+	content := fmt.Sprintf("%s set the topic to %q", actor.DisplayName, topic)
+	if topic == "" {
+		content = fmt.Sprintf("%s cleared the topic", actor.DisplayName)
+	}
+
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording topic change of conversation %s: %v", conversationID, err)
+	}
+
+	return systemMessage, nil
+}
+
+// FindUserByHandle finds the user mentioned with @handle, by the local part of their email or
+// their display name without spaces, like mentions. A full email address also finds its user
+func (s *ChatService) FindUserByHandle(handle string) (*models.User, error) {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+	if handle == "" {
+		return nil, utils.ErrUserNotFound
+	}
+
+	users, err := s.userRepo.GetUsersByHandle(handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	switch len(users) {
+	case 0:
+		return nil, utils.ErrUserNotFound
+	case 1:
+		return users[0], nil
+	default:
+		return nil, utils.ErrHandleAmbiguous
+	}
+}
+
+// AddParticipants adds members to a group, users already in it are skipped
+// Only admins can add members. Returns the added users and the system message recording it,
+// which is nil if nobody was added or the message could not be created
+func (s *ChatService) AddParticipants(conversationID, userID uuid.UUID, userIDs []uuid.UUID) ([]*models.User, *models.MessageResponse, error) {
+	if err := s.requireGroupAdmin(conversationID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	// Validate every user before adding anyone
+	seen := make(map[uuid.UUID]bool)
+	var users []*models.User
+	for _, id := range userIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		isParticipant, err := s.participantRepo.IsParticipant(conversationID, id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check participant status: %w", err)
+		}
+		if isParticipant {
+			continue
+		}
+
+		user, err := s.userRepo.GetUserByID(id)
+		if err != nil {
+			return nil, nil, utils.ErrUserNotFound
+		}
+		users = append(users, user)
+	}
+
+	added := make([]*models.User, 0, len(users))
+	targets := make([]models.SystemEventUser, 0, len(users))
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		participant := &models.ConversationParticipant{
+			ConversationID: conversationID,
+			UserID:         user.ID,
+		}
+		if err := s.participantRepo.AddParticipant(participant); err != nil {
+			return nil, nil, fmt.Errorf("failed to add participant %s: %w", user.ID, err)
+		}
+		added = append(added, user)
+		targets = append(targets, models.NewSystemEventUser(user))
+		names = append(names, user.DisplayName)
+	}
+
+	if len(added) == 0 {
+		return added, nil, nil
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for add members system message: %v", userID, err)
+		return added, nil, nil
+	}
+
+	event := &models.SystemEvent{Event: models.SystemEventParticipantsAdded, Targets: targets}
+	content := fmt.Sprintf("%s added %s", actor.DisplayName, strings.Join(names, ", "))
+
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording new members of conversation %s: %v", conversationID, err)
+	}
+
+	return added, systemMessage, nil
+}
+
+// RemoveParticipant removes a member from a group, removing oneself leaves it
+// Admins can remove anyone. The last admin cannot leave while other members remain.
+// Returns the system message recording it, which is nil if it could not be created
+func (s *ChatService) RemoveParticipant(conversationID, userID, targetID uuid.UUID) (*models.MessageResponse, error) {
+	if targetID == userID {
+		return s.leaveConversation(conversationID, userID)
+	}
+
+	if err := s.requireGroupAdmin(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	_, target, err := s.getMember(conversationID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.participantRepo.RemoveParticipant(conversationID, targetID); err != nil {
+		return nil, fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for remove member system message: %v", userID, err)
+		return nil, nil
+	}
+
+	event := &models.SystemEvent{
+		Event:   models.SystemEventParticipantRemoved,
+		Targets: []models.SystemEventUser{models.NewSystemEventUser(target)},
+	}
+	content := fmt.Sprintf("%s removed %s", actor.DisplayName, target.DisplayName)
+
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording removal of %s from conversation %s: %v", targetID, conversationID, err)
+	}
+
+	return systemMessage, nil
+}
+
+// leaveConversation removes a user from a group at their request
+func (s *ChatService) leaveConversation(conversationID, userID uuid.UUID) (*models.MessageResponse, error) {
+	participant, err := s.participantRepo.GetParticipant(conversationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrNotParticipant
+		}
+		return nil, fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	if conversation.Type != "group" {
+		return nil, utils.ErrGroupOnly
+	}
+
+	if participant.IsAdmin {
+		if err := s.requireOtherAdmin(conversationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.participantRepo.RemoveParticipant(conversationID, userID); err != nil {
+		return nil, fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for leave system message: %v", userID, err)
+		return nil, nil
+	}
+
+	event := &models.SystemEvent{Event: models.SystemEventParticipantLeft}
+	content := fmt.Sprintf("%s left the group", actor.DisplayName)
+
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording %s leaving conversation %s: %v", userID, conversationID, err)
+	}
+
+	return systemMessage, nil
+}
+
+// requireOtherAdmin checks that an admin may give up their role: another admin remains,
+// or they are the last member
+func (s *ChatService) requireOtherAdmin(conversationID uuid.UUID) error {
+	admins, err := s.participantRepo.CountAdmins(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	if admins > 1 {
+		return nil
+	}
+
+	participants, err := s.participantRepo.GetParticipantsByConversationID(conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to get participants: %w", err)
+	}
+	if len(participants) > 1 {
+		return utils.ErrLastAdmin
+	}
+
+	return nil
+}
+
+// SetParticipantAdmin grants or revokes the admin role of a group member
+// Only admins can change roles. Returns the system message recording it, which is nil if the
+// role did not change or the message could not be created
+func (s *ChatService) SetParticipantAdmin(conversationID, userID, targetID uuid.UUID, isAdmin bool) (*models.MessageResponse, error) {
+	if err := s.requireGroupAdmin(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	participant, target, err := s.getMember(conversationID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if participant.IsAdmin == isAdmin {
+		return nil, nil
+	}
+
+	if !isAdmin && targetID == userID {
+		if err := s.requireOtherAdmin(conversationID); err != nil {
+			return nil, err
+		}
+	}
+
+	changed, err := s.participantRepo.SetAdmin(conversationID, targetID, isAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update admin role: %w", err)
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	actor, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting user %s for admin change system message: %v", userID, err)
+		return nil, nil
+	}
+
+	event := &models.SystemEvent{
+		Event:   models.SystemEventAdminGranted,
+		Targets: []models.SystemEventUser{models.NewSystemEventUser(target)},
+	}
+	content := fmt.Sprintf("%s made %s an admin", actor.DisplayName, target.DisplayName)
+	if !isAdmin {
+		event.Event = models.SystemEventAdminRevoked
+		content = fmt.Sprintf("%s removed %s as admin", actor.DisplayName, target.DisplayName)
+	}
+
+	systemMessage, err := s.createSystemMessage(conversationID, actor, event, content)
+	if err != nil {
+		log.Printf("Error recording admin change of %s in conversation %s: %v", targetID, conversationID, err)
+	}
+
+	return systemMessage, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// CreatePoll posts a poll message in a group conversation
+func (s *ChatService) CreatePoll(conversationID, userID uuid.UUID, req *models.CreatePollRequest) (*models.MessageResponse, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	conversation, err := s.conversationRepo.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	if conversation.Type != "group" {
+		return nil, utils.ErrPollRequiresGroup
+	}
+
+	if req.ClosesAt != nil && !req.ClosesAt.After(time.Now()) {
+		return nil, utils.ErrPollClosesInPast
+	}
+
+	// Options are compared case-insensitively, so voters can tell them apart
+	seen := make(map[string]bool)
+	options := make([]*models.PollOption, 0, len(req.Options))
+	for _, text := range req.Options {
+		text = strings.TrimSpace(text)
+		key := strings.ToLower(text)
+		if text == "" || seen[key] {
+			return nil, utils.ErrPollOptionsNotUnique
+		}
+		seen[key] = true
+		options = append(options, &models.PollOption{Text: text})
+	}
+
+	sender, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender info: %w", err)
+	}
+
+	vietnamLoc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+	now := time.Now().In(vietnamLoc)
+
+	question := strings.TrimSpace(req.Question)
+	message := &models.Message{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Content:        question, // Used for search, previews and notifications
+		MessageType:    "poll",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ExpiresAt:      expiryAfter(now, conversation.MessageTTLSeconds),
+	}
+
+	poll := &models.Poll{
+		ConversationID: conversationID,
+		Question:       question,
+		AllowsMultiple: req.AllowsMultiple,
+		IsAnonymous:    req.IsAnonymous,
+		ClosesAt:       req.ClosesAt,
+		CreatedBy:      userID,
+		Options:        options,
+		MyVotes:        []uuid.UUID{},
+	}
+
+	if err := s.pollRepo.CreatePoll(message, poll); err != nil {
+		return nil, fmt.Errorf("failed to create poll: %w", err)
+	}
+
+	message.SenderName = sender.DisplayName
+
+	response := newMessageResponse(message)
+	response.Poll = poll
+
+	return response, nil
+}
+
+// getConversationPoll gets a poll of a conversation the user participates in, with its options and the user's votes
+func (s *ChatService) getConversationPoll(conversationID, pollID, userID uuid.UUID) (*models.Poll, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	poll, err := s.pollRepo.GetPollByID(pollID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrPollNotFound
+		}
+		return nil, fmt.Errorf("failed to get poll: %w", err)
+	}
+
+	if poll.ConversationID != conversationID {
+		return nil, utils.ErrPollNotFound
+	}
+
+	return s.loadPoll(poll.MessageID, userID)
+}
+
+// loadPoll loads the current state of the poll of a message as seen by a user
+func (s *ChatService) loadPoll(messageID, userID uuid.UUID) (*models.Poll, error) {
+	polls, err := s.pollRepo.GetPollsByMessageIDs([]uuid.UUID{messageID}, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poll: %w", err)
+	}
+
+	poll, ok := polls[messageID]
+	if !ok {
+		return nil, utils.ErrPollNotFound
+	}
+
+	return poll, nil
+}
+
+// GetPoll gets a poll with its current results
+func (s *ChatService) GetPoll(conversationID, pollID, userID uuid.UUID) (*models.Poll, error) {
+	return s.getConversationPoll(conversationID, pollID, userID)
+}
+
+// VotePoll replaces the votes of a user in a poll, an empty list of options retracts them
+// Returns the updated poll
+func (s *ChatService) VotePoll(conversationID, pollID, userID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, error) {
+	poll, err := s.getConversationPoll(conversationID, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop duplicate IDs so each option counts once
+	seen := make(map[uuid.UUID]bool)
+	uniqueIDs := make([]uuid.UUID, 0, len(optionIDs))
+	for _, id := range optionIDs {
+		if !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	if !poll.AllowsMultiple && len(uniqueIDs) > 1 {
+		return nil, utils.ErrPollSingleChoice
+	}
+
+	result, err := s.pollRepo.Vote(poll.ID, userID, uniqueIDs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrPollNotFound
+		}
+		return nil, fmt.Errorf("failed to vote: %w", err)
+	}
+
+	switch result {
+	case repository.VotePollClosed:
+		return nil, utils.ErrPollClosed
+	case repository.VoteInvalidOption:
+		return nil, utils.ErrPollOptionInvalid
+	}
+
+	return s.loadPoll(poll.MessageID, userID)
+}
+
+// ClosePoll closes a poll before its closing time
+// Only the creator of the poll and conversation admins can close it
+func (s *ChatService) ClosePoll(conversationID, pollID, userID uuid.UUID) (*models.Poll, error) {
+	poll, err := s.getConversationPoll(conversationID, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if poll.CreatedBy != userID {
+		if err := s.requireConversationAdmin(conversationID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	closed, err := s.pollRepo.ClosePoll(poll.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close poll: %w", err)
+	}
+
+	if !closed {
+		return nil, utils.ErrPollClosed
+	}
+
+	return s.loadPoll(poll.MessageID, userID)
+}
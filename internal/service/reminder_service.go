@@ -0,0 +1,389 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/internal/timeparse"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxRemindAhead is how far in the future a reminder can be set
+	maxRemindAhead = 365 * 24 * time.Hour
+
+	// defaultSnooze is how long a reminder is snoozed without a time
+	defaultSnooze = 15 * time.Minute
+
+	// maxReminderNoteLength is the maximum number of characters of a reminder note
+	maxReminderNoteLength = 500
+)
+
+// ReminderService handles reminders users set about messages
+type ReminderService struct {
+	reminderRepo        *repository.ReminderRepository
+	participantRepo     *repository.ParticipantRepository
+	messageRepo         *repository.MessageRepository
+	notificationService *NotificationService
+	defaultLocation     *time.Location
+	locations           sync.Map // Loaded locations by name
+}
+
+// NewReminderService creates a new reminder service
+// Times are read in defaultTimezone for users who never set a timezone, UTC if it is invalid
+func NewReminderService(
+	reminderRepo *repository.ReminderRepository,
+	participantRepo *repository.ParticipantRepository,
+	messageRepo *repository.MessageRepository,
+	notificationService *NotificationService,
+	defaultTimezone string,
+) *ReminderService {
+	s := &ReminderService{
+		reminderRepo:        reminderRepo,
+		participantRepo:     participantRepo,
+		messageRepo:         messageRepo,
+		notificationService: notificationService,
+		defaultLocation:     time.UTC,
+	}
+
+	if loc, err := s.loadLocation(defaultTimezone); err != nil {
+		log.Printf("Invalid default timezone %q, using UTC: %v", defaultTimezone, err)
+	} else {
+		s.defaultLocation = loc
+	}
+
+	return s
+}
+
+// loadLocation loads a timezone by its IANA name
+func (s *ReminderService) loadLocation(name string) (*time.Location, error) {
+	if loc, ok := s.locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	// An empty name would be UTC and "Local" the zone of the server
+	if name == "" || name == "Local" {
+		return nil, utils.ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, utils.ErrInvalidTimezone
+	}
+
+	s.locations.Store(name, loc)
+	return loc, nil
+}
+
+// userLocation gets the timezone a user set for reminders, the default one if they never did
+func (s *ReminderService) userLocation(userID uuid.UUID) (*time.Location, error) {
+	timezone, _, err := s.reminderRepo.GetReminderTimezone(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s.defaultLocation, nil
+		}
+		return nil, fmt.Errorf("failed to get reminder settings: %w", err)
+	}
+
+	loc, err := s.loadLocation(timezone)
+	if err != nil {
+		return s.defaultLocation, nil
+	}
+	return loc, nil
+}
+
+// reminderLocation gets the timezone a reminder was set in
+func (s *ReminderService) reminderLocation(reminder *models.Reminder) *time.Location {
+	if loc, err := s.loadLocation(reminder.Timezone); err == nil {
+		return loc
+	}
+	return s.defaultLocation
+}
+
+// localize shows the time of reminders in the timezone they were set in
+func (s *ReminderService) localize(reminders ...*models.Reminder) {
+	for _, reminder := range reminders {
+		reminder.RemindAt = reminder.RemindAt.In(s.reminderLocation(reminder))
+	}
+}
+
+// reminderTime reads the time of a reminder in the given timezone, either a time typed like "tomorrow 9am"
+// or an exact time. Returns the note typed after the time
+func reminderTime(when string, remindAt *time.Time, loc *time.Location) (time.Time, string, error) {
+	now := time.Now()
+
+	var t time.Time
+	var note string
+	switch {
+	case remindAt != nil:
+		t = *remindAt
+	case strings.TrimSpace(when) != "":
+		var err error
+		t, note, err = timeparse.Parse(when, now.In(loc))
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("%w: %v", utils.ErrInvalidReminderTime, err)
+		}
+	default:
+		return time.Time{}, "", utils.ErrReminderTimeRequired
+	}
+
+	if !t.After(now) {
+		return time.Time{}, "", utils.ErrReminderTimeInPast
+	}
+	if t.After(now.Add(maxRemindAhead)) {
+		return time.Time{}, "", utils.ErrReminderTimeTooFar
+	}
+
+	return t.In(loc), note, nil
+}
+
+// CreateReminder sets a reminder for a user about a message of a conversation, or about the conversation without a message
+// A timezone given with the request becomes the timezone of the user for later reminders
+func (s *ReminderService) CreateReminder(conversationID uuid.UUID, messageID *uuid.UUID, userID uuid.UUID, req *models.CreateReminderRequest) (*models.Reminder, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	var preview string
+	if messageID != nil {
+		message, err := s.messageRepo.GetMessageByID(*messageID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, utils.ErrMessageNotFound
+			}
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+
+		if message.ConversationID != conversationID {
+			return nil, utils.ErrMessageNotFound
+		}
+		preview = message.Content
+	}
+
+	var loc *time.Location
+	if req.Timezone != "" {
+		if loc, err = s.loadLocation(req.Timezone); err != nil {
+			return nil, err
+		}
+	} else if loc, err = s.userLocation(userID); err != nil {
+		return nil, err
+	}
+
+	remindAt, note, err := reminderTime(req.When, req.RemindAt, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(req.Note) != "" {
+		note = req.Note
+	}
+	note = strings.TrimSpace(note)
+	if runes := []rune(note); len(runes) > maxReminderNoteLength {
+		note = string(runes[:maxReminderNoteLength])
+	}
+
+	if req.Timezone != "" {
+		if _, err := s.reminderRepo.SetReminderTimezone(userID, loc.String()); err != nil {
+			return nil, fmt.Errorf("failed to save timezone: %w", err)
+		}
+	}
+
+	reminder := &models.Reminder{
+		UserID:         userID,
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		MessagePreview: preview,
+		Note:           note,
+		RemindAt:       remindAt,
+		Timezone:       loc.String(),
+	}
+
+	if err := s.reminderRepo.CreateReminder(reminder); err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	return reminder, nil
+}
+
+// GetReminders gets the reminders of a user, upcoming ones soonest first or delivered ones latest first
+func (s *ReminderService) GetReminders(userID uuid.UUID, status string, limit, offset int) ([]*models.Reminder, error) {
+	// Reminders being delivered are still upcoming for the user
+	statuses := []string{"pending", "delivering"}
+	if status == "delivered" {
+		statuses = []string{"delivered"}
+	}
+
+	reminders, err := s.reminderRepo.GetRemindersByUser(userID, statuses, status == "delivered", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	if reminders == nil {
+		reminders = []*models.Reminder{}
+	}
+
+	s.localize(reminders...)
+	return reminders, nil
+}
+
+// GetReminder gets a reminder of a user
+func (s *ReminderService) GetReminder(id, userID uuid.UUID) (*models.Reminder, error) {
+	reminder, err := s.reminderRepo.GetReminderByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrReminderNotFound
+		}
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	// Reminders are private to their user
+	if reminder.UserID != userID {
+		return nil, utils.ErrReminderNotFound
+	}
+
+	s.localize(reminder)
+	return reminder, nil
+}
+
+// SnoozeReminder sets a later time for a reminder, in 15 minutes without a time
+// Delivered reminders become pending again, so they can be snoozed from their notification
+func (s *ReminderService) SnoozeReminder(id, userID uuid.UUID, req *models.SnoozeReminderRequest) (*models.Reminder, error) {
+	reminder, err := s.GetReminder(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := s.reminderLocation(reminder)
+	remindAt := time.Now().Add(defaultSnooze).In(loc)
+	if req.RemindAt != nil || strings.TrimSpace(req.When) != "" {
+		// A note typed after the time is ignored, the reminder keeps its note
+		if remindAt, _, err = reminderTime(req.When, req.RemindAt, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	snoozed, err := s.reminderRepo.SnoozeReminder(id, userID, remindAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snooze reminder: %w", err)
+	}
+
+	// The reminder was cancelled in the meantime
+	if !snoozed {
+		return nil, utils.ErrReminderNotFound
+	}
+
+	reminder.RemindAt = remindAt
+	reminder.Status = "pending"
+	reminder.DeliveredAt = nil
+	reminder.NotificationID = nil
+	reminder.UpdatedAt = time.Now()
+	return reminder, nil
+}
+
+// CancelReminder deletes a reminder of a user
+func (s *ReminderService) CancelReminder(id, userID uuid.UUID) error {
+	deleted, err := s.reminderRepo.DeleteReminder(id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel reminder: %w", err)
+	}
+
+	if !deleted {
+		return utils.ErrReminderNotFound
+	}
+
+	return nil
+}
+
+// GetSettings gets the reminder settings of a user
+func (s *ReminderService) GetSettings(userID uuid.UUID) (*models.ReminderSettings, error) {
+	timezone, updatedAt, err := s.reminderRepo.GetReminderTimezone(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.ReminderSettings{Timezone: s.defaultLocation.String(), IsDefault: true}, nil
+		}
+		return nil, fmt.Errorf("failed to get reminder settings: %w", err)
+	}
+
+	return &models.ReminderSettings{Timezone: timezone, UpdatedAt: &updatedAt}, nil
+}
+
+// UpdateSettings sets the timezone times of new reminders are read in
+// Existing reminders keep the time and timezone they were set with
+func (s *ReminderService) UpdateSettings(userID uuid.UUID, req *models.UpdateReminderSettingsRequest) (*models.ReminderSettings, error) {
+	loc, err := s.loadLocation(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt, err := s.reminderRepo.SetReminderTimezone(userID, loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reminder settings: %w", err)
+	}
+
+	return &models.ReminderSettings{Timezone: loc.String(), UpdatedAt: &updatedAt}, nil
+}
+
+// ClaimDueReminders claims up to limit due reminders for delivery
+func (s *ReminderService) ClaimDueReminders(limit int) ([]*models.Reminder, error) {
+	claimed, err := s.reminderRepo.ClaimDueReminders(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+
+	s.localize(claimed...)
+	return claimed, nil
+}
+
+// ReleaseStaleClaims makes the reminders that stayed claimed longer than timeout pending again
+func (s *ReminderService) ReleaseStaleClaims(timeout time.Duration) (int64, error) {
+	released, err := s.reminderRepo.ReleaseStaleClaims(time.Now().Add(-timeout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to release stale reminders: %w", err)
+	}
+
+	return released, nil
+}
+
+// Deliver adds the notification of a claimed reminder to the feed of its user
+// Reminders of users who left the conversation are deleted instead, returning a nil notification
+func (s *ReminderService) Deliver(reminder *models.Reminder) (*models.Notification, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(reminder.ConversationID, reminder.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		if _, err := s.reminderRepo.DeleteReminder(reminder.ID, reminder.UserID); err != nil {
+			return nil, fmt.Errorf("failed to delete reminder: %w", err)
+		}
+		return nil, nil
+	}
+
+	notification, err := s.notificationService.NotifyReminder(reminder)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reminderRepo.MarkReminderDelivered(reminder.ID, notification.ID); err != nil {
+		return notification, fmt.Errorf("failed to mark reminder as delivered: %w", err)
+	}
+
+	now := time.Now()
+	reminder.Status = "delivered"
+	reminder.DeliveredAt = &now
+	reminder.NotificationID = &notification.ID
+	return notification, nil
+}
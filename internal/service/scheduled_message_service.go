@@ -0,0 +1,224 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"goswift/internal/models"
+	"goswift/internal/repository"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// maxScheduleAhead is how far in the future a message can be scheduled
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// ScheduledMessageService handles messages scheduled to be sent later
+type ScheduledMessageService struct {
+	scheduledMessageRepo *repository.ScheduledMessageRepository
+	participantRepo      *repository.ParticipantRepository
+}
+
+// NewScheduledMessageService creates a new scheduled message service
+func NewScheduledMessageService(
+	scheduledMessageRepo *repository.ScheduledMessageRepository,
+	participantRepo *repository.ParticipantRepository,
+) *ScheduledMessageService {
+	return &ScheduledMessageService{
+		scheduledMessageRepo: scheduledMessageRepo,
+		participantRepo:      participantRepo,
+	}
+}
+
+// validateScheduleRequest checks the time and content of a schedule request
+// Attachments and reply targets are checked when the message is sent
+func validateScheduleRequest(req *models.ScheduleMessageRequest) error {
+	now := time.Now()
+	if !req.ScheduledAt.After(now) {
+		return utils.ErrScheduledAtInPast
+	}
+	if req.ScheduledAt.After(now.Add(maxScheduleAhead)) {
+		return utils.ErrScheduledAtTooFar
+	}
+
+	if req.MessageType == "text" && strings.TrimSpace(req.Content) == "" {
+		return utils.ErrContentRequired
+	}
+	if req.MessageType != "text" && len(req.AttachmentIDs) == 0 {
+		return utils.ErrAttachmentRequired
+	}
+
+	return nil
+}
+
+// ScheduleMessage schedules a message to be posted in a conversation at a future time
+func (s *ScheduledMessageService) ScheduleMessage(conversationID, senderID uuid.UUID, req *models.ScheduleMessageRequest) (*models.ScheduledMessage, error) {
+	isParticipant, err := s.participantRepo.IsParticipant(conversationID, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check participant status: %w", err)
+	}
+
+	if !isParticipant {
+		return nil, utils.ErrNotParticipant
+	}
+
+	if err := validateScheduleRequest(req); err != nil {
+		return nil, err
+	}
+
+	scheduled := &models.ScheduledMessage{
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Content:        strings.TrimSpace(req.Content),
+		Format:         messageFormat(req.Format),
+		MessageType:    req.MessageType,
+		ReplyToID:      req.ReplyToID,
+		AttachmentIDs:  req.AttachmentIDs,
+		ScheduledAt:    req.ScheduledAt,
+	}
+	if scheduled.AttachmentIDs == nil {
+		scheduled.AttachmentIDs = []uuid.UUID{}
+	}
+
+	if err := s.scheduledMessageRepo.CreateScheduledMessage(scheduled); err != nil {
+		return nil, fmt.Errorf("failed to schedule message: %w", err)
+	}
+
+	return scheduled, nil
+}
+
+// GetScheduledMessages gets the scheduled messages of a sender with a given status, soonest first
+func (s *ScheduledMessageService) GetScheduledMessages(senderID uuid.UUID, status string, limit, offset int) ([]*models.ScheduledMessage, error) {
+	scheduledMessages, err := s.scheduledMessageRepo.GetScheduledMessagesBySender(senderID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled messages: %w", err)
+	}
+
+	if scheduledMessages == nil {
+		scheduledMessages = []*models.ScheduledMessage{}
+	}
+
+	return scheduledMessages, nil
+}
+
+// GetScheduledMessage gets a scheduled message of a sender
+func (s *ScheduledMessageService) GetScheduledMessage(id, senderID uuid.UUID) (*models.ScheduledMessage, error) {
+	scheduled, err := s.scheduledMessageRepo.GetScheduledMessageByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrScheduledMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get scheduled message: %w", err)
+	}
+
+	// Schedules are private to their sender
+	if scheduled.SenderID != senderID {
+		return nil, utils.ErrScheduledMessageNotFound
+	}
+
+	return scheduled, nil
+}
+
+// UpdateScheduledMessage replaces the content and time of a pending scheduled message
+func (s *ScheduledMessageService) UpdateScheduledMessage(id, senderID uuid.UUID, req *models.ScheduleMessageRequest) (*models.ScheduledMessage, error) {
+	scheduled, err := s.GetScheduledMessage(id, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduled.Status != "pending" {
+		return nil, utils.ErrScheduledMessageNotPending
+	}
+
+	if err := validateScheduleRequest(req); err != nil {
+		return nil, err
+	}
+
+	scheduled.Content = strings.TrimSpace(req.Content)
+	scheduled.Format = messageFormat(req.Format)
+	scheduled.MessageType = req.MessageType
+	scheduled.ReplyToID = req.ReplyToID
+	scheduled.AttachmentIDs = req.AttachmentIDs
+	scheduled.ScheduledAt = req.ScheduledAt
+	if scheduled.AttachmentIDs == nil {
+		scheduled.AttachmentIDs = []uuid.UUID{}
+	}
+
+	updated, err := s.scheduledMessageRepo.UpdatePendingScheduledMessage(scheduled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update scheduled message: %w", err)
+	}
+
+	// The dispatcher claimed the message in the meantime
+	if !updated {
+		return nil, utils.ErrScheduledMessageNotPending
+	}
+
+	return scheduled, nil
+}
+
+// CancelScheduledMessage deletes a pending or failed scheduled message
+func (s *ScheduledMessageService) CancelScheduledMessage(id, senderID uuid.UUID) error {
+	scheduled, err := s.GetScheduledMessage(id, senderID)
+	if err != nil {
+		return err
+	}
+
+	if scheduled.Status != "pending" && scheduled.Status != "failed" {
+		return utils.ErrScheduledMessageNotPending
+	}
+
+	deleted, err := s.scheduledMessageRepo.DeleteScheduledMessage(id, senderID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled message: %w", err)
+	}
+
+	if !deleted {
+		return utils.ErrScheduledMessageNotPending
+	}
+
+	return nil
+}
+
+// ClaimDueMessages claims up to limit due scheduled messages for sending
+func (s *ScheduledMessageService) ClaimDueMessages(limit int) ([]*models.ScheduledMessage, error) {
+	claimed, err := s.scheduledMessageRepo.ClaimDueScheduledMessages(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim scheduled messages: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// MarkSent records the message posted for a claimed scheduled message
+func (s *ScheduledMessageService) MarkSent(id, messageID uuid.UUID) error {
+	if err := s.scheduledMessageRepo.MarkScheduledMessageSent(id, messageID); err != nil {
+		return fmt.Errorf("failed to mark scheduled message as sent: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records why a claimed scheduled message could not be posted
+func (s *ScheduledMessageService) MarkFailed(id uuid.UUID, reason string) error {
+	if err := s.scheduledMessageRepo.MarkScheduledMessageFailed(id, reason); err != nil {
+		return fmt.Errorf("failed to mark scheduled message as failed: %w", err)
+	}
+
+	return nil
+}
+
+// FailStaleClaims fails the messages that stayed claimed longer than timeout
+// Returns the failed messages so their senders can be told
+func (s *ScheduledMessageService) FailStaleClaims(timeout time.Duration) ([]*models.ScheduledMessage, error) {
+	failed, err := s.scheduledMessageRepo.FailStaleClaims(time.Now().Add(-timeout), "sending was interrupted, the message may not have been posted")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail stale scheduled messages: %w", err)
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"goswift/internal/highlight"
+	"goswift/internal/models"
+	"goswift/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// snippetPreviewLines is the number of lines shown in the collapsed preview of a snippet
+	snippetPreviewLines = 8
+
+	// snippetPreviewChars is the number of characters of code loaded for previews in message lists
+	snippetPreviewChars = 2000
+)
+
+// prepareSnippet validates the snippet of a snippet message and normalizes its code
+// The request content is replaced by a description of the snippet, which is what gets shown in
+// notifications, previews and search. Returns nil for other message types
+func (s *ChatService) prepareSnippet(req *models.SendMessageRequest) (*models.Snippet, error) {
+	if req.MessageType != "snippet" {
+		if req.Snippet != nil {
+			return nil, utils.ErrSnippetNotAllowed
+		}
+		return nil, nil
+	}
+
+	if req.Snippet == nil || strings.TrimSpace(req.Snippet.Code) == "" {
+		return nil, utils.ErrSnippetRequired
+	}
+	if strings.TrimSpace(req.Content) != "" {
+		return nil, utils.ErrTypedMessageContent
+	}
+
+	// PostgreSQL text cannot hold NUL characters
+	code := req.Snippet.Code
+	if !utf8.ValidString(code) || strings.ContainsRune(code, 0) {
+		return nil, utils.ErrSnippetNotText
+	}
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	if len(code) > s.maxSnippetSize {
+		return nil, fmt.Errorf("%w: the maximum is %d KB", utils.ErrSnippetTooLarge, s.maxSnippetSize/1024)
+	}
+
+	var fileName string
+	if strings.TrimSpace(req.Snippet.Filename) != "" {
+		fileName = sanitizeFileName(req.Snippet.Filename)
+	}
+
+	lang := highlight.Detect(fileName)
+	if req.Snippet.Language != "" {
+		var ok bool
+		if lang, ok = highlight.Lookup(req.Snippet.Language); !ok {
+			return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedLanguage, req.Snippet.Language)
+		}
+	}
+
+	snippet := &models.Snippet{
+		Language:  lang.Name,
+		Filename:  fileName,
+		Code:      code,
+		SizeBytes: len(code),
+		LineCount: strings.Count(strings.TrimSuffix(code, "\n"), "\n") + 1,
+	}
+
+	req.Content = snippetText(snippet, lang)
+	req.Format = "plain"
+	return snippet, nil
+}
+
+// snippetText describes a snippet in plain text, like "📄 main.go (Go, 42 lines)"
+func snippetText(snippet *models.Snippet, lang *highlight.Language) string {
+	lines := fmt.Sprintf("%d lines", snippet.LineCount)
+	if snippet.LineCount == 1 {
+		lines = "1 line"
+	}
+
+	if snippet.Filename == "" {
+		return fmt.Sprintf("📄 %s snippet (%s)", lang.Title, lines)
+	}
+	return fmt.Sprintf("📄 %s (%s, %s)", snippet.Filename, lang.Title, lines)
+}
+
+// snippetLanguage gets the language of a stored snippet, plain text if it is no longer supported
+func snippetLanguage(snippet *models.Snippet) *highlight.Language {
+	if lang, ok := highlight.Lookup(snippet.Language); ok {
+		return lang
+	}
+	return highlight.PlainText
+}
+
+// snippetRawURL returns the download URL of the code of a snippet message
+func snippetRawURL(conversationID, messageID uuid.UUID) string {
+	return "/api/v1/conversations/" + conversationID.String() + "/messages/" + messageID.String() + "/snippet/raw"
+}
+
+// newSnippetPreview highlights the first lines of a snippet
+// The code may only hold the head of the snippet, as loaded for message lists
+func newSnippetPreview(conversationID uuid.UUID, snippet *models.Snippet) *models.SnippetPreview {
+	lang := snippetLanguage(snippet)
+	tokens, truncated := highlight.Head(highlight.Tokenize(lang, snippet.Code), snippetPreviewLines)
+
+	return &models.SnippetPreview{
+		Language:      lang.Name,
+		LanguageTitle: lang.Title,
+		Filename:      snippet.Filename,
+		SizeBytes:     snippet.SizeBytes,
+		LineCount:     snippet.LineCount,
+		PreviewHTML:   highlight.HTML(tokens),
+		Truncated:     truncated || len(snippet.Code) < snippet.SizeBytes,
+		RawURL:        snippetRawURL(conversationID, snippet.MessageID),
+	}
+}
+
+// GetSnippetCode gets the snippet of a message of a conversation the user participates in, used for downloads
+func (s *ChatService) GetSnippetCode(conversationID, messageID, userID uuid.UUID) (*models.Snippet, error) {
+	if _, err := s.getConversationMessage(conversationID, messageID, userID); err != nil {
+		return nil, err
+	}
+
+	snippet, err := s.snippetRepo.GetSnippet(messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrSnippetNotFound
+		}
+		return nil, fmt.Errorf("failed to get snippet: %w", err)
+	}
+
+	return snippet, nil
+}
+
+// GetSnippet gets the full highlighted code of a snippet message
+// Tokens are only included if requested, for clients rendering the code natively
+func (s *ChatService) GetSnippet(conversationID, messageID, userID uuid.UUID, withTokens bool) (*models.SnippetResponse, error) {
+	snippet, err := s.GetSnippetCode(conversationID, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := highlight.Tokenize(snippetLanguage(snippet), snippet.Code)
+
+	response := &models.SnippetResponse{
+		SnippetPreview: *newSnippetPreview(conversationID, snippet),
+		MessageID:      messageID,
+		Code:           snippet.Code,
+		HTML:           highlight.HTML(tokens),
+	}
+	if withTokens {
+		response.Tokens = tokens
+	}
+	return response, nil
+}
+
+// SnippetLanguages lists the languages snippets can be highlighted in
+func (s *ChatService) SnippetLanguages() []*models.SnippetLanguage {
+	languages := highlight.Languages()
+
+	result := make([]*models.SnippetLanguage, 0, len(languages))
+	for _, lang := range languages {
+		result = append(result, &models.SnippetLanguage{
+			Name:    lang.Name,
+			Title:   lang.Title,
+			Aliases: lang.Aliases,
+		})
+	}
+	return result
+}
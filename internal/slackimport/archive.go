@@ -0,0 +1,273 @@
+// Package slackimport reads Slack workspace export archives
+// An archive is a ZIP with users.json, the channel lists (channels.json, groups.json, dms.json, mpims.json)
+// and a folder per channel holding one JSON file of messages per day
+package slackimport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Channel kinds, from the list the channel is declared in
+const (
+	KindChannel = "channel" // Public channel, channels.json
+	KindGroup   = "group"   // Private channel, groups.json
+	KindDM      = "dm"      // Direct message, dms.json
+	KindMPIM    = "mpim"    // Multi-person direct message, mpims.json
+)
+
+// channelLists maps the channel list files to the kind of their channels
+var channelLists = []struct {
+	file string
+	kind string
+}{
+	{"channels.json", KindChannel},
+	{"groups.json", KindGroup},
+	{"dms.json", KindDM},
+	{"mpims.json", KindMPIM},
+}
+
+// maxMemberSize bounds the uncompressed size of each JSON file read from an archive
+// Exports of large workspaces stay far below it, highly compressed files above it are likely ZIP bombs
+const maxMemberSize = 256 << 20
+
+// ErrInvalidArchive is returned when the archive is not a Slack export
+var ErrInvalidArchive = errors.New("not a Slack export archive")
+
+// User is a member of the exported workspace
+type User struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	Profile  struct {
+		Email       string `json:"email"`
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+// DisplayName returns the name shown for the user in Slack
+func (u *User) DisplayName() string {
+	for _, name := range []string{u.Profile.DisplayName, u.Profile.RealName, u.RealName, u.Name} {
+		if name != "" {
+			return name
+		}
+	}
+	return u.ID
+}
+
+// Channel is a conversation of the exported workspace
+type Channel struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"` // Empty for direct messages
+	Creator string   `json:"creator"`
+	Created int64    `json:"created"`
+	Members []string `json:"members"`
+
+	Kind string `json:"-"`
+}
+
+// dir returns the folder holding the messages of the channel
+func (c *Channel) dir() string {
+	if c.Kind == KindDM {
+		return c.ID
+	}
+	return c.Name
+}
+
+// Message is a message of a channel
+type Message struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	User     string `json:"user"`
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+	Edited   *struct {
+		User string `json:"user"`
+		TS   string `json:"ts"`
+	} `json:"edited"`
+	Reactions []Reaction `json:"reactions"`
+	Files     []File     `json:"files"`
+}
+
+// IsReply reports whether the message is a reply in the thread of another message
+func (m *Message) IsReply() bool {
+	return m.ThreadTS != "" && m.ThreadTS != m.TS
+}
+
+// Reaction is an emoji reaction to a message
+type Reaction struct {
+	Name  string   `json:"name"` // Emoji shortcode without colons, like thumbsup or +1::skin-tone-2
+	Users []string `json:"users"`
+}
+
+// File is a file shared in a message, exports only hold links to the files
+type File struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+// Archive is an opened Slack export
+type Archive struct {
+	Users    []*User
+	Channels []*Channel
+
+	files map[string]*zip.File
+}
+
+// Open reads the users and channels of a Slack export archive
+func Open(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	archive := &Archive{files: make(map[string]*zip.File, len(zr.File))}
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean("/"+file.Name), "/")
+		archive.files[name] = file
+	}
+	// Archives zipped from the export folder nest everything in a top-level directory
+	archive.stripRootDir()
+
+	if _, ok := archive.files["users.json"]; !ok {
+		return nil, fmt.Errorf("%w: users.json is missing", ErrInvalidArchive)
+	}
+	if err := archive.readJSON("users.json", &archive.Users); err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, list := range channelLists {
+		if _, ok := archive.files[list.file]; !ok {
+			continue
+		}
+		found = true
+
+		var channels []*Channel
+		if err := archive.readJSON(list.file, &channels); err != nil {
+			return nil, err
+		}
+		for _, channel := range channels {
+			channel.Kind = list.kind
+		}
+		archive.Channels = append(archive.Channels, channels...)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: channels.json is missing", ErrInvalidArchive)
+	}
+
+	return archive, nil
+}
+
+// stripRootDir drops the top-level directory of archives where every file is inside one
+func (a *Archive) stripRootDir() {
+	if _, ok := a.files["users.json"]; ok {
+		return
+	}
+
+	for name := range a.files {
+		root, _, found := strings.Cut(name, "/")
+		if !found {
+			return
+		}
+		if _, ok := a.files[root+"/users.json"]; !ok {
+			return
+		}
+
+		files := make(map[string]*zip.File, len(a.files))
+		for name, file := range a.files {
+			files[strings.TrimPrefix(name, root+"/")] = file
+		}
+		a.files = files
+		return
+	}
+}
+
+// readJSON decodes a file of the archive
+func (a *Archive) readJSON(name string, v interface{}) error {
+	file, ok := a.files[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is missing", ErrInvalidArchive, name)
+	}
+
+	if file.UncompressedSize64 > maxMemberSize {
+		return fmt.Errorf("%w: %s is too large", ErrInvalidArchive, name)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+	defer rc.Close()
+
+	// The size in the header is not trusted, the file is cut at the limit whatever it claims
+	if err := json.NewDecoder(io.LimitReader(rc, maxMemberSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+	return nil
+}
+
+// Days returns the names of the daily message files of a channel in chronological order
+func (a *Archive) Days(channel *Channel) []string {
+	prefix := channel.dir() + "/"
+
+	var days []string
+	for name := range a.files {
+		if rest, ok := strings.CutPrefix(name, prefix); ok && !strings.Contains(rest, "/") && strings.HasSuffix(rest, ".json") {
+			days = append(days, name)
+		}
+	}
+
+	// Files are named YYYY-MM-DD.json, so the name order is the date order
+	sort.Strings(days)
+	return days
+}
+
+// ReadDay reads the messages of a daily message file, ordered by timestamp
+func (a *Archive) ReadDay(name string) ([]*Message, error) {
+	var messages []*Message
+	if err := a.readJSON(name, &messages); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		ti, _ := ParseTimestamp(messages[i].TS)
+		tj, _ := ParseTimestamp(messages[j].TS)
+		return ti.Before(tj)
+	})
+	return messages, nil
+}
+
+// ParseTimestamp converts a Slack timestamp like 1503435956.000247 to a time
+func ParseTimestamp(ts string) (time.Time, error) {
+	secs, frac, _ := strings.Cut(ts, ".")
+
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", ts)
+	}
+
+	var usec int64
+	if frac != "" {
+		// The fraction is in microseconds, pad or cut it to 6 digits
+		frac = (frac + "000000")[:6]
+		if usec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", ts)
+		}
+	}
+
+	return time.Unix(sec, usec*1000).UTC(), nil
+}
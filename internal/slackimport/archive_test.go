@@ -0,0 +1,375 @@
+package slackimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// member is a file of a test archive, names ending with / are directories
+type member struct {
+	name string
+	body string
+}
+
+// buildZip builds a ZIP archive in memory
+func buildZip(t *testing.T, members ...member) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatalf("adding %s: %v", m.name, err)
+		}
+		if _, err := w.Write([]byte(m.body)); err != nil {
+			t.Fatalf("writing %s: %v", m.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("closing archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func openZip(t *testing.T, data []byte) (*Archive, error) {
+	t.Helper()
+	return Open(bytes.NewReader(data), int64(len(data)))
+}
+
+// oversizedZip builds an archive whose member claims to be larger than the limit, without holding that much data
+func oversizedZip(t *testing.T, name string, members ...member) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatalf("adding %s: %v", m.name, err)
+		}
+		w.Write([]byte(m.body))
+	}
+
+	body := []byte("[]")
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(body),
+		CompressedSize64:   uint64(len(body)),
+		UncompressedSize64: maxMemberSize + 1,
+	})
+	if err != nil {
+		t.Fatalf("adding %s: %v", name, err)
+	}
+	w.Write(body)
+
+	if err := zw.Close(); err != nil {
+		t.Fatalf("closing archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+const (
+	testUsers    = `[{"id":"U1","name":"ann","profile":{"display_name":"Ann"}},{"id":"U2","name":"bob"}]`
+	testChannels = `[{"id":"C1","name":"general","members":["U1","U2"]}]`
+)
+
+// channelSummary is the name or ID and kind of a channel
+func channelSummary(channels []*Channel) []string {
+	var summary []string
+	for _, channel := range channels {
+		summary = append(summary, channel.Kind+":"+channel.ID+":"+channel.Name)
+	}
+	return summary
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		users    int
+		channels []string
+		days     []string // Of the first channel
+		err      string   // Part of the error message, empty if the archive is valid
+	}{
+		{
+			name: "export",
+			data: buildZip(t,
+				member{"users.json", testUsers},
+				member{"channels.json", testChannels},
+				member{"general/2026-01-02.json", `[]`},
+				member{"general/2026-01-01.json", `[]`},
+			),
+			users:    2,
+			channels: []string{"channel:C1:general"},
+			days:     []string{"general/2026-01-01.json", "general/2026-01-02.json"},
+		},
+		{
+			name: "every channel list",
+			data: buildZip(t,
+				member{"users.json", testUsers},
+				member{"channels.json", testChannels},
+				member{"groups.json", `[{"id":"G1","name":"secret"}]`},
+				member{"dms.json", `[{"id":"D1","members":["U1","U2"]}]`},
+				member{"mpims.json", `[{"id":"M1","name":"mpdm-ann--bob-1"}]`},
+				member{"general/2026-01-01.json", `[]`},
+			),
+			users:    2,
+			channels: []string{"channel:C1:general", "group:G1:secret", "dm:D1:", "mpim:M1:mpdm-ann--bob-1"},
+			days:     []string{"general/2026-01-01.json"},
+		},
+		{
+			name: "without public channels",
+			data: buildZip(t,
+				member{"users.json", testUsers},
+				member{"dms.json", `[{"id":"D1","members":["U1","U2"]}]`},
+				member{"D1/2026-01-01.json", `[]`},
+			),
+			users:    2,
+			channels: []string{"dm:D1:"},
+			days:     []string{"D1/2026-01-01.json"},
+		},
+		{
+			name: "nested in a root directory",
+			data: buildZip(t,
+				member{"export/", ""},
+				member{"export/users.json", testUsers},
+				member{"export/channels.json", testChannels},
+				member{"export/general/", ""},
+				member{"export/general/2026-01-01.json", `[]`},
+			),
+			users:    2,
+			channels: []string{"channel:C1:general"},
+			days:     []string{"general/2026-01-01.json"},
+		},
+		{
+			name: "path entries are cleaned",
+			data: buildZip(t,
+				member{"../users.json", testUsers},
+				member{"./channels.json", testChannels},
+				member{"/general/../general/2026-01-01.json", `[]`},
+				member{"general/../../../../2026-01-02.json", `[]`},
+				member{"general//2026-01-03.json", `[]`},
+			),
+			users:    2,
+			channels: []string{"channel:C1:general"},
+			days:     []string{"general/2026-01-01.json", "general/2026-01-03.json"},
+		},
+		{
+			name: "only files in the channel folder are days",
+			data: buildZip(t,
+				member{"users.json", testUsers},
+				member{"channels.json", testChannels},
+				member{"general/2026-01-01.json", `[]`},
+				member{"general/notes.txt", ``},
+				member{"general/old/2025-01-01.json", `[]`},
+				member{"general-archive/2026-01-02.json", `[]`},
+			),
+			users:    2,
+			channels: []string{"channel:C1:general"},
+			days:     []string{"general/2026-01-01.json"},
+		},
+		{
+			name: "files in different root directories",
+			data: buildZip(t,
+				member{"a/users.json", testUsers},
+				member{"b/channels.json", testChannels},
+			),
+			err: "is missing",
+		},
+		{
+			name: "missing users",
+			data: buildZip(t, member{"channels.json", testChannels}),
+			err:  "users.json is missing",
+		},
+		{
+			name: "missing channel lists",
+			data: buildZip(t, member{"users.json", testUsers}),
+			err:  "channels.json is missing",
+		},
+		{
+			name: "users directory",
+			data: buildZip(t, member{"users.json/", ""}, member{"channels.json", testChannels}),
+			err:  "users.json is missing",
+		},
+		{
+			name: "malformed users",
+			data: buildZip(t, member{"users.json", `[{"id":`}, member{"channels.json", testChannels}),
+			err:  "users.json",
+		},
+		{
+			name: "truncated channels",
+			data: buildZip(t, member{"users.json", testUsers}, member{"channels.json", `[{"id":"C1","name":"gen`}),
+			err:  "channels.json",
+		},
+		{
+			name: "channels object instead of a list",
+			data: buildZip(t, member{"users.json", testUsers}, member{"channels.json", `{"id":"C1","name":"general"}`}),
+			err:  "channels.json",
+		},
+		{
+			name: "channel with a wrong field type",
+			data: buildZip(t, member{"users.json", testUsers}, member{"groups.json", `[{"id":"G1","created":"yesterday"}]`}),
+			err:  "groups.json",
+		},
+		{
+			name: "oversized users",
+			data: oversizedZip(t, "users.json", member{"channels.json", testChannels}),
+			err:  "users.json is too large",
+		},
+		{
+			name: "oversized channels",
+			data: oversizedZip(t, "channels.json", member{"users.json", testUsers}),
+			err:  "channels.json is too large",
+		},
+		{
+			name: "not a ZIP",
+			data: []byte(`{"users":[]}`),
+			err:  "zip",
+		},
+		{
+			name: "empty ZIP",
+			data: buildZip(t),
+			err:  "users.json is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := openZip(t, tt.data)
+			if tt.err != "" {
+				if !errors.Is(err, ErrInvalidArchive) || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Open() error = %v, want %v mentioning %q", err, ErrInvalidArchive, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+
+			if len(archive.Users) != tt.users {
+				t.Errorf("got %d users, want %d", len(archive.Users), tt.users)
+			}
+			if got := channelSummary(archive.Channels); !reflect.DeepEqual(got, tt.channels) {
+				t.Errorf("channels = %q, want %q", got, tt.channels)
+			}
+			if got := archive.Days(archive.Channels[0]); !reflect.DeepEqual(got, tt.days) {
+				t.Errorf("Days() = %q, want %q", got, tt.days)
+			}
+		})
+	}
+}
+
+func TestReadDay(t *testing.T) {
+	data := buildZip(t,
+		member{"users.json", testUsers},
+		member{"channels.json", testChannels},
+		member{"general/2026-01-01.json", `[
+			{"type":"message","user":"U2","text":"second","ts":"1767225600.000200"},
+			{"type":"message","user":"U1","text":"first","ts":"1767225600.000100","thread_ts":"1767225600.000100"},
+			{"type":"message","user":"U2","text":"reply","ts":"1767225700.000000","thread_ts":"1767225600.000100",
+			 "reactions":[{"name":"+1::skin-tone-2","users":["U1"]}]}
+		]`},
+		member{"general/2026-01-02.json", `[{"type":"message","text":`},
+		member{"general/2026-01-03.json", `{"type":"message"}`},
+	)
+	archive, err := openZip(t, data)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	messages, err := archive.ReadDay("general/2026-01-01.json")
+	if err != nil {
+		t.Fatalf("ReadDay() error = %v", err)
+	}
+
+	var texts []string
+	for _, message := range messages {
+		texts = append(texts, message.Text)
+	}
+	if want := []string{"first", "second", "reply"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("messages = %q, want %q", texts, want)
+	}
+	if messages[0].IsReply() || messages[1].IsReply() || !messages[2].IsReply() {
+		t.Error("only the last message should be a reply")
+	}
+	if got := messages[2].Reactions; len(got) != 1 || ReactionEmoji(got[0].Name) != ":+1:" {
+		t.Errorf("reactions = %+v", got)
+	}
+
+	for _, name := range []string{"general/2026-01-02.json", "general/2026-01-03.json", "general/2026-01-04.json"} {
+		if _, err := archive.ReadDay(name); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("ReadDay(%s) error = %v, want %v", name, err, ErrInvalidArchive)
+		}
+	}
+
+	oversized, err := openZip(t, oversizedZip(t, "general/2026-01-01.json",
+		member{"users.json", testUsers},
+		member{"channels.json", testChannels},
+	))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := oversized.ReadDay("general/2026-01-01.json"); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("ReadDay() of an oversized day error = %v, want %v", err, ErrInvalidArchive)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		ts   string
+		want time.Time
+		ok   bool
+	}{
+		{"1503435956.000247", time.Date(2017, 8, 22, 21, 5, 56, 247000, time.UTC), true},
+		{"1503435956", time.Date(2017, 8, 22, 21, 5, 56, 0, time.UTC), true},
+		{"1503435956.5", time.Date(2017, 8, 22, 21, 5, 56, 500000000, time.UTC), true},
+		{"1503435956.1234567", time.Date(2017, 8, 22, 21, 5, 56, 123456000, time.UTC), true},
+		{"", time.Time{}, false},
+		{"abc.000001", time.Time{}, false},
+		{"1503435956.abc", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.ts)
+		if (err == nil) != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v, want %v", tt.ts, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	users := map[string]*User{"U1": {ID: "U1", Name: "ann", RealName: "Ann Lee"}}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hi <@U1>", "hi @Ann Lee"},
+		{"hi <@U1|annie>", "hi @annie"},
+		{"hi <@U9>", "hi @U9"},
+		{"see <#C1|general>", "see #general"},
+		{"see <#C1>", "see #C1"},
+		{"<!here> lunch", "@here lunch"},
+		{"<!subteam^S1|@devs> review", "@devs review"},
+		{"<https://example.com>", "https://example.com"},
+		{"<https://example.com|the docs>", "the docs (https://example.com)"},
+		{"<mailto:ann@example.com|ann@example.com>", "ann@example.com"},
+		{"a &lt;b&gt; &amp;&amp; c", "a <b> && c"},
+		{"&amp;lt;", "&lt;"},
+		{"  spaced  ", "spaced"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatText(tt.text, users); got != tt.want {
+			t.Errorf("FormatText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
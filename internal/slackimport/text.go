@@ -0,0 +1,58 @@
+package slackimport
+
+import (
+	"regexp"
+	"strings"
+)
+
+// entityPattern matches the <...> entities of Slack message text: mentions, channel links and URLs
+var entityPattern = regexp.MustCompile(`<([^<>]+)>`)
+
+// entityUnescaper decodes the only HTML entities Slack escapes in message text
+var entityUnescaper = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+// FormatText converts Slack message text to plain text
+// User mentions are replaced with the user's name, channel links with #channel and links with their label and URL
+func FormatText(text string, users map[string]*User) string {
+	text = entityPattern.ReplaceAllStringFunc(text, func(entity string) string {
+		target, label, _ := strings.Cut(entity[1:len(entity)-1], "|")
+
+		switch {
+		case strings.HasPrefix(target, "@"):
+			if label != "" {
+				return "@" + label
+			}
+			if user, ok := users[target[1:]]; ok {
+				return "@" + user.DisplayName()
+			}
+			return "@" + target[1:]
+		case strings.HasPrefix(target, "#"):
+			if label != "" {
+				return "#" + label
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			// Special mentions like <!here> or <!subteam^ID|@team>
+			if label != "" {
+				return label
+			}
+			name, _, _ := strings.Cut(target[1:], "^")
+			return "@" + name
+		default:
+			target = strings.TrimPrefix(target, "mailto:")
+			if label == "" || label == target {
+				return target
+			}
+			return label + " (" + target + ")"
+		}
+	})
+
+	return strings.TrimSpace(entityUnescaper.Replace(text))
+}
+
+// ReactionEmoji converts a Slack reaction name to a :shortcode:
+// Skin tone variants like +1::skin-tone-2 are reduced to the base emoji
+func ReactionEmoji(name string) string {
+	base, _, _ := strings.Cut(name, "::")
+	return ":" + base + ":"
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"goswift/pkg/utils"
+)
+
+// ErrObjectNotFound is returned when a stored object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage is a backend for storing uploaded files
+type Storage interface {
+	// Put stores the content of r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens the object stored under key, the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object stored under key, deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage creates the storage backend selected by the STORAGE_DRIVER setting
+func NewStorage(config *utils.Config) (Storage, error) {
+	switch config.StorageDriver {
+	case "local":
+		return NewLocalStorage(config.StorageLocalPath)
+	case "s3":
+		return NewS3Storage(config)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.StorageDriver)
+	}
+}
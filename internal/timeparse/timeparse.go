@@ -0,0 +1,346 @@
+// Package timeparse reads the times people type in reminders, like "in 2 hours", "tomorrow 9am" or "friday at 14:30"
+// Times are resolved in the location of the reference time, so day-based times follow the user's timezone
+package timeparse
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHour is the time of day of reminders given a day without a time
+const DefaultHour = 9
+
+// maxAmount bounds the numbers of durations, far enough for any reminder
+const maxAmount = 100000
+
+// ErrNoTime is returned when the text does not start with a time
+var ErrNoTime = errors.New(`could not understand the time, try "in 2 hours", "tomorrow 9am" or "monday at 14:30"`)
+
+var (
+	// durationPattern matches compact durations like 2h, 90m or 1h30m
+	durationPattern = regexp.MustCompile(`^(?:\d+[wdhm])+$`)
+	durationPart    = regexp.MustCompile(`(\d+)([wdhm])`)
+
+	// clockPattern matches times of day like 9am, 9:30 pm, 14:00 or 9h30
+	clockPattern = regexp.MustCompile(`^(\d{1,2})(?:[:h](\d{2}))?(am|pm)?$`)
+
+	// datePattern matches ISO dates, optionally with a time as in 2026-10-20T09:00
+	datePattern = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})(?:t(\d{1,2}:\d{2}))?$`)
+)
+
+// durationUnits are the words and letters of duration units
+var durationUnits = map[string]string{
+	"m": "m", "min": "m", "mins": "m", "minute": "m", "minutes": "m",
+	"h": "h", "hr": "h", "hrs": "h", "hour": "h", "hours": "h",
+	"d": "d", "day": "d", "days": "d",
+	"w": "w", "week": "w", "weeks": "w",
+}
+
+// weekdays are the names of the days of the week
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// noteMarkers introduce the note after the time, as in "in 2 hours to call Bob"
+var noteMarkers = map[string]bool{"about": true, "to": true, "that": true}
+
+// word is a word of the parsed text with its position
+type word struct {
+	text  string // Lowercase
+	start int
+}
+
+// parser reads words from the start of the text
+type parser struct {
+	text  string
+	words []word
+	pos   int
+}
+
+// Parse reads a time at the start of text, relative to now and in its location
+// A leading "me" is skipped, as in "me in 2 hours". Returns the time and the rest of the text,
+// without a leading "about", "to" or "that", which is the note of the reminder
+func Parse(text string, now time.Time) (time.Time, string, error) {
+	p := &parser{text: text, words: splitWords(text)}
+	p.skip("me")
+
+	t, ok := p.parseTime(now)
+	if !ok {
+		return time.Time{}, "", ErrNoTime
+	}
+
+	if p.pos < len(p.words) && noteMarkers[p.words[p.pos].text] {
+		p.pos++
+	}
+	return t, p.rest(), nil
+}
+
+// splitWords splits text on whitespace, keeping the position of each word
+func splitWords(text string) []word {
+	var words []word
+	start := -1
+	for i, r := range text + " " {
+		isSpace := r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		switch {
+		case isSpace && start >= 0:
+			words = append(words, word{text: strings.ToLower(text[start:i]), start: start})
+			start = -1
+		case !isSpace && start < 0:
+			start = i
+		}
+	}
+	return words
+}
+
+// peek returns the current word, empty at the end of the text
+func (p *parser) peek() string {
+	if p.pos < len(p.words) {
+		return p.words[p.pos].text
+	}
+	return ""
+}
+
+// skip moves past the current word if it is one of the given words
+func (p *parser) skip(words ...string) bool {
+	current := p.peek()
+	for _, w := range words {
+		if current == w {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// rest returns the text after the parsed words as typed
+func (p *parser) rest() string {
+	if p.pos >= len(p.words) {
+		return ""
+	}
+	return strings.TrimSpace(p.text[p.words[p.pos].start:])
+}
+
+// parseTime reads a relative duration, a day with an optional time of day, or a time of day
+func (p *parser) parseTime(now time.Time) (time.Time, bool) {
+	start := p.pos
+
+	switch current := p.peek(); {
+	case current == "in":
+		p.pos++
+		if t, ok := p.parseDuration(now); ok {
+			return t, true
+		}
+
+	case current == "today" || current == "tomorrow" || current == "tonight":
+		p.pos++
+		day := now
+		if current == "tomorrow" {
+			day = now.AddDate(0, 0, 1)
+		}
+		hour, minute := DefaultHour, 0
+		if current == "tonight" {
+			hour = 20
+		}
+		if h, m, ok := p.parseClock(); ok {
+			hour, minute = h, m
+		}
+		return atTime(day, hour, minute), true
+
+	case datePattern.MatchString(current):
+		p.pos++
+		match := datePattern.FindStringSubmatch(current)
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		dayOfMonth, _ := strconv.Atoi(match[3])
+		if month < 1 || month > 12 || dayOfMonth < 1 || dayOfMonth > 31 {
+			break
+		}
+		day := time.Date(year, time.Month(month), dayOfMonth, 0, 0, 0, 0, now.Location())
+		if day.Day() != dayOfMonth {
+			break
+		}
+
+		hour, minute := DefaultHour, 0
+		if match[4] != "" {
+			h, m, ok := clock(match[4])
+			if !ok {
+				break
+			}
+			hour, minute = h, m
+		} else if h, m, ok := p.parseClock(); ok {
+			hour, minute = h, m
+		}
+		return atTime(day, hour, minute), true
+
+	default:
+		p.skip("on")
+		next := p.skip("next")
+		if weekday, ok := weekdays[p.peek()]; ok {
+			p.pos++
+			days := (int(weekday) - int(now.Weekday()) + 7) % 7
+			if days == 0 || next {
+				days += 7
+			}
+			hour, minute := DefaultHour, 0
+			if h, m, ok := p.parseClock(); ok {
+				hour, minute = h, m
+			}
+			return atTime(now.AddDate(0, 0, days), hour, minute), true
+		}
+		if next {
+			break
+		}
+
+		// A time of day alone is the next time the clock shows it
+		if h, m, ok := p.parseClock(); ok {
+			t := atTime(now, h, m)
+			if !t.After(now) {
+				t = atTime(now.AddDate(0, 0, 1), h, m)
+			}
+			return t, true
+		}
+	}
+
+	p.pos = start
+	return time.Time{}, false
+}
+
+// parseDuration reads durations like "2 hours", "an hour", "1 hour 30 minutes" or "1h30m"
+func (p *parser) parseDuration(now time.Time) (time.Time, bool) {
+	t := now
+	end := -1 // Position after the last duration, an "and" after it belongs to the note
+
+	for p.pos < len(p.words) {
+		current := p.peek()
+
+		if durationPattern.MatchString(current) {
+			for _, part := range durationPart.FindAllStringSubmatch(current, -1) {
+				amount, err := strconv.Atoi(part[1])
+				if err != nil || amount > maxAmount {
+					return time.Time{}, false
+				}
+				t = addDuration(t, amount, part[2])
+			}
+			p.pos++
+			end = p.pos
+			p.skip("and")
+			continue
+		}
+
+		amount, err := strconv.Atoi(current)
+		if current == "a" || current == "an" {
+			amount, err = 1, nil
+		}
+		if err != nil || amount > maxAmount || p.pos+1 >= len(p.words) {
+			break
+		}
+		unit, ok := durationUnits[p.words[p.pos+1].text]
+		if !ok {
+			break
+		}
+
+		t = addDuration(t, amount, unit)
+		p.pos += 2
+		end = p.pos
+		p.skip("and")
+	}
+
+	if end < 0 {
+		return time.Time{}, false
+	}
+	p.pos = end
+	return t, t.After(now)
+}
+
+// parseClock reads a time of day, optionally after "at", like "9am", "9 am", "14:30", "noon" or "midnight"
+func (p *parser) parseClock() (int, int, bool) {
+	start := p.pos
+	p.skip("at")
+
+	switch current := p.peek(); current {
+	case "noon":
+		p.pos++
+		return 12, 0, true
+	case "midnight":
+		p.pos++
+		return 0, 0, true
+	case "":
+	default:
+		// "9 am" is written as two words
+		if next := p.pos + 1; next < len(p.words) && (p.words[next].text == "am" || p.words[next].text == "pm") {
+			if hour, minute, ok := clock(current + p.words[next].text); ok {
+				p.pos += 2
+				return hour, minute, true
+			}
+		}
+		if hour, minute, ok := clock(current); ok {
+			p.pos++
+			return hour, minute, true
+		}
+	}
+
+	p.pos = start
+	return 0, 0, false
+}
+
+// clock parses a time of day word
+// Bare numbers without minutes are only read as hours with am or pm, so "in 2 days" is not a time
+func clock(text string) (int, int, bool) {
+	match := clockPattern.FindStringSubmatch(text)
+	if match == nil || (match[2] == "" && match[3] == "") {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	if minute > 59 {
+		return 0, 0, false
+	}
+
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	default:
+		if hour > 23 {
+			return 0, 0, false
+		}
+	}
+	return hour, minute, true
+}
+
+// atTime returns the given time of day on the day of t, in its location
+func atTime(t time.Time, hour, minute int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+}
+
+// addDuration adds an amount of a unit to t, days and weeks keep the time of day across daylight saving changes
+func addDuration(t time.Time, amount int, unit string) time.Time {
+	switch unit {
+	case "w":
+		return t.AddDate(0, 0, 7*amount)
+	case "d":
+		return t.AddDate(0, 0, amount)
+	case "h":
+		return t.Add(time.Duration(amount) * time.Hour)
+	default:
+		return t.Add(time.Duration(amount) * time.Minute)
+	}
+}
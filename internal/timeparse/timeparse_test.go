@@ -0,0 +1,164 @@
+package timeparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // The tests do not depend on the zoneinfo of the machine
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("loading %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	loc := loadLocation(t, "Asia/Ho_Chi_Minh")
+	// A Monday afternoon
+	now := time.Date(2026, 10, 19, 15, 4, 5, 0, loc)
+	at := func(month time.Month, day, hour, minute, second int) time.Time {
+		return time.Date(2026, month, day, hour, minute, second, 0, loc)
+	}
+
+	tests := []struct {
+		text string
+		want time.Time
+		rest string
+	}{
+		// Relative durations keep the seconds of now
+		{text: "in 2h", want: at(10, 19, 17, 4, 5)},
+		{text: "in 90m", want: at(10, 19, 16, 34, 5)},
+		{text: "in 1h30m", want: at(10, 19, 16, 34, 5)},
+		{text: "in 2 hours", want: at(10, 19, 17, 4, 5)},
+		{text: "In 2 Hours", want: at(10, 19, 17, 4, 5)},
+		{text: "in an hour and 30 minutes", want: at(10, 19, 16, 34, 5)},
+		{text: "in 1 hour 15 mins", want: at(10, 19, 16, 19, 5)},
+		{text: "in 3 days", want: at(10, 22, 15, 4, 5)},
+		{text: "in a week", want: at(10, 26, 15, 4, 5)},
+		{text: "me in 5 minutes", want: at(10, 19, 15, 9, 5)},
+		{text: "in 2 hours to call Bob", want: at(10, 19, 17, 4, 5), rest: "call Bob"},
+		{text: "in 2h and then lunch", want: at(10, 19, 17, 4, 5), rest: "and then lunch"},
+
+		// Days default to 9am
+		{text: "tomorrow 9am", want: at(10, 20, 9, 0, 0)},
+		{text: "tomorrow", want: at(10, 20, 9, 0, 0)},
+		{text: "tomorrow at 9 pm check the deploy", want: at(10, 20, 21, 0, 0), rest: "check the deploy"},
+		{text: "tomorrow 9", want: at(10, 20, 9, 0, 0), rest: "9"},
+		{text: "tomorrow noon", want: at(10, 20, 12, 0, 0)},
+		{text: "tonight", want: at(10, 19, 20, 0, 0)},
+		{text: "tonight at 11pm", want: at(10, 19, 23, 0, 0)},
+		{text: "today at 17:45 about the review", want: at(10, 19, 17, 45, 0), rest: "the review"},
+
+		// Weekdays are the next one, a week ahead on the same day
+		{text: "friday at 14:30", want: at(10, 23, 14, 30, 0)},
+		{text: "on tue 9h30", want: at(10, 20, 9, 30, 0)},
+		{text: "monday", want: at(10, 26, 9, 0, 0)},
+		{text: "next friday", want: at(10, 30, 9, 0, 0)},
+		{text: "next tuesday at noon", want: at(10, 27, 12, 0, 0)},
+
+		// ISO dates
+		{text: "2026-12-25", want: at(12, 25, 9, 0, 0)},
+		{text: "2026-12-25 8am", want: at(12, 25, 8, 0, 0)},
+		{text: "2026-12-25T18:00 dinner", want: at(12, 25, 18, 0, 0), rest: "dinner"},
+
+		// A time of day alone is the next time the clock shows it
+		{text: "16:30", want: at(10, 19, 16, 30, 0)},
+		{text: "at 4:30pm", want: at(10, 19, 16, 30, 0)},
+		{text: "9am", want: at(10, 20, 9, 0, 0)},
+		{text: "15:04", want: at(10, 20, 15, 4, 0)},
+		{text: "noon", want: at(10, 20, 12, 0, 0)},
+		{text: "midnight", want: at(10, 20, 0, 0, 0)},
+		{text: "12am", want: at(10, 20, 0, 0, 0)},
+		{text: "12pm", want: at(10, 20, 12, 0, 0)},
+
+		// Days in the past are returned as such, the caller rejects them
+		{text: "today 9am", want: at(10, 19, 9, 0, 0)},
+		{text: "2025-01-01", want: time.Date(2025, 1, 1, 9, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, rest, err := Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !got.Equal(tt.want) || rest != tt.rest {
+				t.Errorf("Parse() = %v, %q, want %v, %q", got, rest, tt.want, tt.rest)
+			}
+			if got.Location() != loc {
+				t.Errorf("Parse() location = %v, want %v", got.Location(), loc)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 4, 5, 0, loadLocation(t, "Asia/Ho_Chi_Minh"))
+
+	for _, text := range []string{
+		"",
+		"   ",
+		"later",
+		"call Bob",
+		"in",
+		"in a while",
+		"in 2",
+		"in 2 fortnights",
+		"in 0 minutes",
+		"in 0h",
+		"in -2 hours",
+		"in 100001 hours",
+		"in 99999999999999999999h",
+		"next",
+		"next week",
+		"at 9",
+		"9",
+		"25:00",
+		"13pm",
+		"0am",
+		"9:75",
+		"2026-02-30",
+		"2026-13-01",
+		"2026-00-10",
+		"2026-12-25T24:00",
+		"yesterday",
+	} {
+		t.Run(text, func(t *testing.T) {
+			got, rest, err := Parse(text, now)
+			if !errors.Is(err, ErrNoTime) {
+				t.Errorf("Parse() = %v, %q, %v, want %v", got, rest, err, ErrNoTime)
+			}
+		})
+	}
+}
+
+func TestParseAcrossDaylightSaving(t *testing.T) {
+	loc := loadLocation(t, "America/New_York")
+	// The day before clocks go back an hour, on November 1st 2026
+	now := time.Date(2026, 10, 31, 12, 0, 0, 0, loc)
+
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{text: "tomorrow 9am", want: time.Date(2026, 11, 1, 9, 0, 0, 0, loc)},
+		{text: "in 1 day", want: time.Date(2026, 11, 1, 12, 0, 0, 0, loc)},
+		{text: "in 24h", want: time.Date(2026, 11, 1, 11, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, _, err := Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,31 +1,62 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"time"
 
+	"goswift/internal/commands"
+	"goswift/internal/models"
 	"goswift/internal/service"
+	"goswift/pkg/jwt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 )
 
+// MessagePublisher broadcasts saved messages and sends the notifications they cause
+type MessagePublisher interface {
+	PublishMessage(message *models.MessageResponse)
+	PublishDeliveryUpdates(updates []*models.DeliveryUpdate)
+}
+
+// CommandRunner runs the slash commands of messages sent over WebSocket
+type CommandRunner interface {
+	RunCommand(ctx context.Context, req *models.SendMessageRequest, userID uuid.UUID) (*models.CommandResult, error)
+}
+
 // Handler handles WebSocket connections
 type Handler struct {
 	manager     *Manager
 	chatService *service.ChatService
+	jwtManager  *jwt.JWTManager
+	publisher   MessagePublisher
+	commands    CommandRunner
 }
 
 // NewHandler creates a new WebSocket handler
-func NewHandler(manager *Manager, chatService *service.ChatService) *Handler {
+func NewHandler(manager *Manager, chatService *service.ChatService, jwtManager *jwt.JWTManager) *Handler {
 	return &Handler{
 		manager:     manager,
 		chatService: chatService,
+		jwtManager:  jwtManager,
 	}
 }
 
+// SetMessagePublisher sets the publisher of messages sent over WebSocket
+// The publisher is created after the handler, since it broadcasts through it
+func (h *Handler) SetMessagePublisher(publisher MessagePublisher) {
+	h.publisher = publisher
+}
+
+// SetCommandRunner sets the runner of slash commands sent over WebSocket
+func (h *Handler) SetCommandRunner(runner CommandRunner) {
+	h.commands = runner
+}
+
 // HandleWebSocket handles incoming WebSocket connections
 func (h *Handler) HandleWebSocket(c *gin.Context) {
 	// Upgrade HTTP connection to WebSocket
@@ -106,6 +137,12 @@ func (h *Handler) handleMessage(client *Client, message *Message) {
 	case "message":
 		// Handle chat message
 		h.handleChatMessage(client, message)
+	case "send_message":
+		// Save and publish a chat message
+		h.handleSendMessage(client, message)
+	case "message_ack":
+		// Record that a message frame reached this device
+		h.handleMessageAck(client, message)
 	case "user_status":
 		// Handle user status updates (online/offline)
 		h.handleUserStatus(client, message)
@@ -119,10 +156,25 @@ func (h *Handler) handleMessage(client *Client, message *Message) {
 
 // handleAuth handles authentication messages
 func (h *Handler) handleAuth(client *Client, message *Message) {
-	// For now, we'll accept any auth message
-	// Later we'll add proper JWT validation
-	client.UserID = message.UserID
-	client.Username = message.Username
+	// The identity is taken from a token in the data, clients stay anonymous until one is verified
+	// so that nothing sent to a user reaches a socket that only claims to be them
+	var token string
+	if data, ok := message.Data.(map[string]interface{}); ok {
+		token, _ = data["token"].(string)
+	}
+	if token == "" || h.jwtManager == nil {
+		h.sendError(client, "", "A token is required to authenticate")
+		return
+	}
+
+	claims, err := h.jwtManager.ValidateToken(token)
+	if err != nil {
+		h.sendError(client, "", "Invalid or expired token")
+		return
+	}
+	client.UserID = claims.UserID
+	client.Username = claims.Username
+	client.Verified = true
 
 	// Update user online status in database
 	if h.chatService != nil && client.UserID != "" {
@@ -176,6 +228,129 @@ func (h *Handler) handleChatMessage(client *Client, message *Message) {
 	log.Printf("Broadcasting message from %s: %s", client.Username, message.Content)
 }
 
+// handleSendMessage saves a message sent over WebSocket, like POST /conversations/:id/messages
+// The client must have authenticated with a token. The data holds a send message request,
+// retries with the same client_msg_id are acknowledged
+// with the original message without saving or broadcasting it again
+func (h *Handler) handleSendMessage(client *Client, message *Message) {
+	var req models.SendMessageRequest
+	if err := decodeData(message.Data, &req); err != nil {
+		h.sendError(client, "", "Invalid message: "+err.Error())
+		return
+	}
+
+	senderID, err := uuid.Parse(client.UserID)
+	if err != nil || !client.Verified || h.chatService == nil {
+		h.sendError(client, req.ClientMsgID, "User not authenticated")
+		return
+	}
+
+	if req.MessageType == "text" {
+		if h.commands != nil && commands.IsCommand(req.Content) {
+			h.handleCommand(client, &req, senderID)
+			return
+		}
+		req.Content = commands.Unescape(req.Content)
+	}
+
+	saved, created, err := h.chatService.SendMessage(&req, senderID)
+	if err != nil {
+		h.sendError(client, req.ClientMsgID, err.Error())
+		return
+	}
+
+	if created && h.publisher != nil {
+		h.publisher.PublishMessage(saved)
+	}
+
+	client.SendMessage(&Message{
+		Type:      "message_sent",
+		UserID:    client.UserID,
+		Username:  client.Username,
+		Timestamp: time.Now().Unix(),
+		Data:      saved,
+	})
+}
+
+// handleCommand runs the slash command of a message and replies with its result
+// Ephemeral replies are also sent to every client of the user as command_response
+func (h *Handler) handleCommand(client *Client, req *models.SendMessageRequest, senderID uuid.UUID) {
+	result, err := h.commands.RunCommand(context.Background(), req, senderID)
+	if err != nil {
+		h.sendError(client, req.ClientMsgID, err.Error())
+		return
+	}
+
+	client.SendMessage(&Message{
+		Type:      "command_result",
+		UserID:    client.UserID,
+		Username:  client.Username,
+		Timestamp: time.Now().Unix(),
+		Data:      result,
+	})
+}
+
+// messageAck is the data of a message_ack frame
+type messageAck struct {
+	MessageID uuid.UUID `json:"message_id" binding:"required"`
+}
+
+// handleMessageAck marks a message received over this socket as delivered to the client's user
+// Acknowledging a message also acknowledges the earlier messages of its conversation
+func (h *Handler) handleMessageAck(client *Client, message *Message) {
+	var ack messageAck
+	if err := decodeData(message.Data, &ack); err != nil {
+		h.sendError(client, "", "Invalid acknowledgement: "+err.Error())
+		return
+	}
+
+	userID, err := uuid.Parse(client.UserID)
+	if err != nil || !client.Verified || h.chatService == nil {
+		h.sendError(client, "", "User not authenticated")
+		return
+	}
+
+	updates, err := h.chatService.MarkMessageDelivered(ack.MessageID, userID)
+	if err != nil {
+		h.sendError(client, "", err.Error())
+		return
+	}
+
+	if len(updates) > 0 && h.publisher != nil {
+		h.publisher.PublishDeliveryUpdates(updates)
+	}
+}
+
+// decodeData decodes and validates the data of a WebSocket message into a request
+func decodeData(data interface{}, req interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(encoded, req); err != nil {
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(req)
+}
+
+// sendError tells a client that its request failed
+func (h *Handler) sendError(client *Client, clientMsgID, reason string) {
+	data := map[string]interface{}{
+		"error": reason,
+	}
+	if clientMsgID != "" {
+		data["client_msg_id"] = clientMsgID
+	}
+
+	client.SendMessage(&Message{
+		Type:      "error",
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+}
+
 // BroadcastMessage broadcasts a saved message to clients in the same conversation
 func (h *Handler) BroadcastMessage(message *Message) {
 	// Extract conversation_id from message data
@@ -211,6 +386,11 @@ func (h *Handler) BroadcastMessage(message *Message) {
 	log.Printf("Broadcasting saved message to conversation %s: %s", conversationID, message.Content)
 }
 
+// SendToUser sends a message to all verified connections of a specific user
+func (h *Handler) SendToUser(userID string, message *Message) {
+	h.manager.SendToUser(userID, message)
+}
+
 // handleUserStatus handles user status updates
 func (h *Handler) handleUserStatus(client *Client, message *Message) {
 	// Just log the status update for now
@@ -12,6 +12,7 @@ type Client struct {
 	ID       string          `json:"id"`
 	UserID   string          `json:"user_id"`
 	Username string          `json:"username"`
+	Verified bool            `json:"-"` // Identity checked with a JWT, required to save messages
 	Conn     *websocket.Conn `json:"-"`
 	Manager  *Manager        `json:"-"`
 	mutex    sync.Mutex      // Protect concurrent writes to this client's connection
@@ -126,12 +127,13 @@ func (m *Manager) BroadcastToParticipants(participantIDs map[string]bool, messag
 }
 
 // SendToUser sends a message to a specific user
+// Only clients whose identity was verified with a JWT receive it, as it may hold private data like drafts
 func (m *Manager) SendToUser(userID string, message *Message) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
 	for _, client := range m.clients {
-		if client.UserID == userID {
+		if client.Verified && client.UserID == userID {
 			client.SendMessage(message)
 		}
 	}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS thread_follows;
+DROP INDEX IF EXISTS idx_messages_thread_root_created_at;
+DROP INDEX IF EXISTS idx_messages_reply_to_id;
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS last_reply_by,
+    DROP COLUMN IF EXISTS last_reply_at,
+    DROP COLUMN IF EXISTS reply_count,
+    DROP COLUMN IF EXISTS thread_root_id,
+    DROP COLUMN IF EXISTS reply_to_id;
@@ -0,0 +1,41 @@
+-- Add thread columns to messages
+ALTER TABLE messages
+    ADD COLUMN reply_to_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    ADD COLUMN thread_root_id UUID REFERENCES messages(id) ON DELETE CASCADE,
+    ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_reply_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN last_reply_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Create indexes for threads
+CREATE INDEX idx_messages_reply_to_id ON messages(reply_to_id);
+CREATE INDEX idx_messages_thread_root_created_at ON messages(thread_root_id, created_at);
+
+-- Create thread follows table
+CREATE TABLE thread_follows (
+    thread_root_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_following BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (thread_root_id, user_id)
+);
+
+-- Create indexes for thread follows
+CREATE INDEX idx_thread_follows_user_id ON thread_follows(user_id);
+
+-- Create notifications table
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE,
+    message_id UUID REFERENCES messages(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    content TEXT NOT NULL DEFAULT '',
+    is_read BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for notifications
+CREATE INDEX idx_notifications_user_created_at ON notifications(user_id, created_at DESC);
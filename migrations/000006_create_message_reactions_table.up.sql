@@ -0,0 +1,14 @@
+-- Create message reactions table
+CREATE TABLE message_reactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji VARCHAR(64) NOT NULL, -- Unicode emoji or custom shortcode like :party_parrot:
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    -- A user can react with the same emoji only once per message
+    UNIQUE(message_id, user_id, emoji)
+);
+
+-- Create indexes for message reactions
+CREATE INDEX idx_message_reactions_message_id ON message_reactions(message_id);
@@ -0,0 +1,7 @@
+-- Add full-text search vector to messages
+-- The 'simple' configuration is used because conversations mix Vietnamese and English
+ALTER TABLE messages
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+
+-- Create GIN index for full-text search
+CREATE INDEX idx_messages_search_vector ON messages USING GIN(search_vector);
@@ -0,0 +1,5 @@
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS thumbnails,
+    DROP COLUMN IF EXISTS blurhash,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width;
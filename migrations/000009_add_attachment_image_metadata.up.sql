@@ -0,0 +1,7 @@
+-- Add image metadata to attachments
+-- Thumbnails holds the generated sizes as [{"size", "width", "height", "content_type"}]
+ALTER TABLE attachments
+    ADD COLUMN width INTEGER,
+    ADD COLUMN height INTEGER,
+    ADD COLUMN blurhash VARCHAR(100),
+    ADD COLUMN thumbnails JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,21 @@
+-- Allow participants to mute a conversation
+-- Muted conversations don't notify about thread replies, mentions still notify
+ALTER TABLE conversation_participants ADD COLUMN is_muted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create message mentions table
+-- One row per mention in the message content, user_id is NULL for @everyone and @here
+CREATE TABLE message_mentions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    mention_type VARCHAR(20) NOT NULL CHECK (mention_type IN ('user', 'everyone', 'here')),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    start_offset INTEGER NOT NULL,
+    length INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for message mentions
+CREATE INDEX idx_message_mentions_message_id ON message_mentions(message_id);
+CREATE INDEX idx_message_mentions_user_id ON message_mentions(user_id);
+CREATE INDEX idx_message_mentions_broadcast ON message_mentions(conversation_id) WHERE user_id IS NULL;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS pinned_messages;
+
+DELETE FROM messages WHERE message_type = 'system';
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_message_type_check;
+ALTER TABLE messages ADD CONSTRAINT messages_message_type_check
+    CHECK (message_type IN ('text', 'image', 'file'));
@@ -0,0 +1,7 @@
+-- Add forwarding attribution to messages
+-- The original sender and timestamp are copied so attribution survives the original message
+ALTER TABLE messages
+    ADD COLUMN forwarded_from_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    ADD COLUMN forwarded_from_sender_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN forwarded_from_sender_name VARCHAR(100),
+    ADD COLUMN forwarded_from_created_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,22 @@
+-- Create scheduled messages table
+-- Due rows are claimed by moving them to 'sending', so each one is posted by a single server instance
+CREATE TABLE scheduled_messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content TEXT NOT NULL DEFAULT '',
+    message_type VARCHAR(20) NOT NULL DEFAULT 'text' CHECK (message_type IN ('text', 'image', 'file')),
+    reply_to_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    attachment_ids UUID[] NOT NULL DEFAULT '{}',
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'failed')),
+    claimed_at TIMESTAMP WITH TIME ZONE,
+    message_id UUID REFERENCES messages(id) ON DELETE SET NULL, -- Posted message once sent
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for scheduled messages
+CREATE INDEX idx_scheduled_messages_due ON scheduled_messages(scheduled_at) WHERE status = 'pending';
+CREATE INDEX idx_scheduled_messages_sender_id ON scheduled_messages(sender_id, scheduled_at);
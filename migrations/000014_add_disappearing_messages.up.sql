@@ -0,0 +1,9 @@
+-- Add disappearing messages
+-- A conversation TTL is applied to messages at send time, expired messages are purged by a background job
+ALTER TABLE conversations
+    ADD COLUMN message_ttl_seconds INTEGER CHECK (message_ttl_seconds > 0);
+
+ALTER TABLE messages
+    ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_messages_expires_at ON messages(expires_at) WHERE expires_at IS NOT NULL;
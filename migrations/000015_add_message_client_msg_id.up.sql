@@ -0,0 +1,6 @@
+-- Add client message IDs for idempotent sending
+-- Retries of a send with the same ID return the original message instead of inserting again
+ALTER TABLE messages
+    ADD COLUMN client_msg_id VARCHAR(255);
+
+CREATE UNIQUE INDEX idx_messages_sender_client_msg_id ON messages(sender_id, client_msg_id) WHERE client_msg_id IS NOT NULL;
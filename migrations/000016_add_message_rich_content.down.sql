@@ -0,0 +1,5 @@
+ALTER TABLE scheduled_messages
+    DROP COLUMN IF EXISTS format;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS rich_content;
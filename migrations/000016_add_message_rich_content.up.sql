@@ -0,0 +1,7 @@
+-- Add rich-text content to messages
+-- Markdown messages store the parsed document, content holds its plain-text rendering
+ALTER TABLE messages
+    ADD COLUMN rich_content JSONB;
+
+ALTER TABLE scheduled_messages
+    ADD COLUMN format VARCHAR(20) NOT NULL DEFAULT 'plain' CHECK (format IN ('plain', 'markdown'));
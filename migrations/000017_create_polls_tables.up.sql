@@ -0,0 +1,44 @@
+-- Allow poll messages
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_message_type_check;
+ALTER TABLE messages ADD CONSTRAINT messages_message_type_check
+    CHECK (message_type IN ('text', 'image', 'file', 'system', 'poll'));
+
+-- Create polls table
+-- Vote counts are stored and only changed while the poll row is locked
+CREATE TABLE polls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_id UUID NOT NULL UNIQUE REFERENCES messages(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    question VARCHAR(300) NOT NULL,
+    allows_multiple BOOLEAN NOT NULL DEFAULT false,
+    is_anonymous BOOLEAN NOT NULL DEFAULT false,
+    voter_count INTEGER NOT NULL DEFAULT 0,
+    closes_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    closed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create poll options table
+CREATE TABLE poll_options (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text VARCHAR(100) NOT NULL,
+    vote_count INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(poll_id, position)
+);
+
+-- Create poll votes table
+CREATE TABLE poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (option_id, user_id)
+);
+
+-- Create indexes for polls
+CREATE INDEX idx_polls_conversation_id ON polls(conversation_id);
+CREATE INDEX idx_poll_votes_poll_user ON poll_votes(poll_id, user_id);
@@ -0,0 +1,13 @@
+-- Create message drafts table
+-- One draft per user and conversation, synced across devices with last-write-wins on updated_at
+-- Cleared drafts keep their row with empty content, so older saves cannot bring them back
+CREATE TABLE message_drafts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    content TEXT NOT NULL DEFAULT '',
+    format VARCHAR(20) NOT NULL DEFAULT 'plain' CHECK (format IN ('plain', 'markdown')),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, conversation_id)
+);
+
+CREATE INDEX idx_message_drafts_conversation_id ON message_drafts(conversation_id);
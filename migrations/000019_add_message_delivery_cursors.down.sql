@@ -0,0 +1,4 @@
+ALTER TABLE conversation_participants
+    DROP COLUMN IF EXISTS receipts_from,
+    DROP COLUMN IF EXISTS last_read_at,
+    DROP COLUMN IF EXISTS last_delivered_at;
@@ -0,0 +1,12 @@
+-- Add per-recipient delivery tracking
+-- Each participant has a delivered and a read cursor: messages of others sent up to the cursor
+-- are delivered to or read by them. Messages sent before receipts_from are not tracked for the
+-- participant, so members who join later do not count as recipients of older messages.
+ALTER TABLE conversation_participants
+    ADD COLUMN last_delivered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ADD COLUMN last_read_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ADD COLUMN receipts_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT '-infinity';
+
+-- Existing messages count as read by existing participants, new participants start tracking when they join
+ALTER TABLE conversation_participants
+    ALTER COLUMN receipts_from SET DEFAULT NOW();
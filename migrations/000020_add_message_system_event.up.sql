@@ -0,0 +1,4 @@
+-- Add structured payloads to system messages
+-- The content keeps an English description, clients localize from the event instead
+ALTER TABLE messages
+    ADD COLUMN system_event JSONB;
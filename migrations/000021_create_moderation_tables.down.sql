@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS moderation_flags;
+DROP TABLE IF EXISTS moderation_rules;
@@ -0,0 +1,31 @@
+-- Create moderation tables
+-- Rules apply to every conversation and are checked before messages are saved
+CREATE TABLE moderation_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('word', 'domain', 'regex')),
+    pattern VARCHAR(500) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('reject', 'redact', 'flag')),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, pattern)
+);
+
+-- Review queue of flagged messages
+-- The content is kept as sent, so moderators can review messages that were redacted or have since expired
+CREATE TABLE moderation_flags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hook VARCHAR(100) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'dismissed', 'removed')),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_moderation_flags_status_created_at ON moderation_flags(status, created_at);
+CREATE INDEX idx_moderation_flags_message_id ON moderation_flags(message_id);
@@ -0,0 +1,4 @@
+-- Record when a message was last edited after sending
+-- updated_at also changes on reads, so it cannot tell edited messages apart
+ALTER TABLE messages
+    ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,23 @@
+-- Create conversation exports table
+-- Pending exports are claimed by moving them to 'running', claims of stopped instances are retried
+CREATE TABLE conversation_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    requested_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('json', 'html', 'csv')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    claimed_at TIMESTAMP WITH TIME ZONE,
+    storage_key VARCHAR(500), -- Export file once completed
+    file_name VARCHAR(255),
+    size_bytes BIGINT,
+    message_count INTEGER,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE -- When the export file is deleted
+);
+
+-- Create indexes for conversation exports
+CREATE INDEX idx_conversation_exports_queue ON conversation_exports(created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_conversation_exports_expires_at ON conversation_exports(expires_at) WHERE storage_key IS NOT NULL;
+CREATE INDEX idx_conversation_exports_requested_by ON conversation_exports(requested_by, created_at);
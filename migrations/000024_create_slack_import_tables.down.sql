@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS slack_imported_messages;
+DROP TABLE IF EXISTS slack_imported_channels;
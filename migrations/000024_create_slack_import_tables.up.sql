@@ -0,0 +1,19 @@
+-- Create Slack import tables
+-- They map imported Slack channels and messages to their copies, so re-running an import skips what was already imported
+CREATE TABLE slack_imported_channels (
+    slack_channel_id VARCHAR(32) PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    imported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Messages are identified by their channel and timestamp in Slack
+-- message_id has no foreign key: messages deleted after the import, e.g. by moderators, are not imported again
+CREATE TABLE slack_imported_messages (
+    slack_channel_id VARCHAR(32) NOT NULL,
+    ts VARCHAR(32) NOT NULL,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL,
+    PRIMARY KEY (slack_channel_id, ts)
+);
+
+CREATE INDEX idx_slack_imported_messages_conversation_id ON slack_imported_messages(conversation_id);
@@ -0,0 +1,3 @@
+-- Add conversation topic, set by members with /topic
+ALTER TABLE conversations
+    ADD COLUMN topic VARCHAR(250) NOT NULL DEFAULT '';
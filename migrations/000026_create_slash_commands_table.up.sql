@@ -0,0 +1,13 @@
+-- Create slash commands table
+-- Third-party commands registered by workspace admins, run by calling their URL
+-- Requests are signed with the secret so endpoints can authenticate them
+CREATE TABLE slash_commands (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(32) NOT NULL UNIQUE,
+    url VARCHAR(2048) NOT NULL,
+    usage VARCHAR(100) NOT NULL DEFAULT '',
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    secret VARCHAR(64) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
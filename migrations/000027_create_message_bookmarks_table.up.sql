@@ -0,0 +1,14 @@
+-- Create message bookmarks table
+-- Bookmarks are deleted with their message, and hidden while the user is not a participant of the conversation
+CREATE TABLE message_bookmarks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, message_id)
+);
+
+CREATE INDEX idx_message_bookmarks_user_created ON message_bookmarks(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_message_bookmarks_message_id ON message_bookmarks(message_id);
@@ -0,0 +1,6 @@
+DELETE FROM messages WHERE message_type NOT IN ('text', 'image', 'file', 'system', 'poll');
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_message_type_check;
+ALTER TABLE messages ADD CONSTRAINT messages_message_type_check
+    CHECK (message_type IN ('text', 'image', 'file', 'system', 'poll'));
+
+ALTER TABLE messages DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,8 @@
+-- Store the structured payload of typed messages, like locations and contact cards
+ALTER TABLE messages ADD COLUMN payload JSONB;
+
+-- Message types are validated by the server, which registers the payload kinds,
+-- so new kinds only need a well-formed name
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_message_type_check;
+ALTER TABLE messages ADD CONSTRAINT messages_message_type_check
+    CHECK (message_type ~ '^[a-z][a-z0-9_]{0,19}$');
@@ -0,0 +1,5 @@
+DELETE FROM messages WHERE message_type = 'voice';
+
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS waveform,
+    DROP COLUMN IF EXISTS duration_ms;
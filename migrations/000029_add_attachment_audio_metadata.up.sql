@@ -0,0 +1,5 @@
+-- Add the metadata of voice recordings to attachments
+-- Waveform holds the loudness of the recording as 64 values from 0 to 100
+ALTER TABLE attachments
+    ADD COLUMN duration_ms INTEGER,
+    ADD COLUMN waveform JSONB;
@@ -0,0 +1,3 @@
+DELETE FROM messages WHERE message_type = 'snippet';
+
+DROP TABLE IF EXISTS message_snippets;
@@ -0,0 +1,11 @@
+-- Create message snippets table
+-- The code of snippet messages is stored apart from the content, which holds a short description
+CREATE TABLE message_snippets (
+    message_id UUID PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    language VARCHAR(30) NOT NULL DEFAULT 'text',
+    filename VARCHAR(255) NOT NULL DEFAULT '',
+    code TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    line_count INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reminder_settings;
+DROP TABLE IF EXISTS reminders;
@@ -0,0 +1,29 @@
+-- Create reminders table
+-- Reminders are private to their user. The timezone the time was typed in is kept,
+-- so the reminder can be shown and snoozed in the time of the user
+CREATE TABLE reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    message_id UUID REFERENCES messages(id) ON DELETE CASCADE, -- Reminders set outside a thread are about the conversation
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    remind_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    timezone VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivering', 'delivered')),
+    claimed_at TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    notification_id UUID REFERENCES notifications(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for reminders
+CREATE INDEX idx_reminders_due ON reminders(remind_at) WHERE status = 'pending';
+CREATE INDEX idx_reminders_user_id ON reminders(user_id, remind_at);
+
+-- Create reminder settings table, holding the timezone reminder times are read in
+CREATE TABLE reminder_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    timezone VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -30,6 +32,48 @@ type Config struct {
 	// JWT
 	JWTSecret        string
 	JWTTokenDuration time.Duration
+
+	// File storage
+	StorageDriver    string // "local" or "s3"
+	StorageLocalPath string
+	S3Endpoint       string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3Bucket         string
+	S3Region         string
+	S3UseSSL         bool
+	MaxUploadSize    int64 // In bytes
+
+	// Chat
+	MaxPinsPerConversation       int
+	ScheduledMessagePollInterval time.Duration
+	MessagePurgeInterval         time.Duration
+	MaxVoiceDuration             time.Duration
+	MaxSnippetSize               int // In bytes
+
+	// Moderation
+	ModeratorUserIDs            []string
+	ModerationWebhookURL        string
+	ModerationWebhookTimeout    time.Duration
+	ModerationWebhookFailClosed bool
+
+	// Workspace administration
+	AdminUserIDs []string
+
+	// Exports
+	ExportSyncMaxMessages int // Larger exports run as background jobs
+	ExportPollInterval    time.Duration
+	ExportRetention       time.Duration
+
+	// Imports
+	ImportMaxArchiveSize int64 // In bytes
+
+	// Slash commands
+	SlashCommandTimeout time.Duration // Third-party commands must answer within it
+
+	// Reminders
+	DefaultTimezone      string // IANA name, used for users who never set a timezone
+	ReminderPollInterval time.Duration
 }
 
 func LoadConfig() *Config {
@@ -58,6 +102,48 @@ func LoadConfig() *Config {
 		// JWT
 		JWTSecret:        getEnv("JWT_SECRET", ""),
 		JWTTokenDuration: time.Hour * 24,
+
+		// File storage
+		StorageDriver:    getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalPath: getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+		S3Endpoint:       getEnv("MINIO_ENDPOINT", "localhost:9000"),
+		S3AccessKey:      getEnv("MINIO_ACCESS_KEY", "minioadmin"),
+		S3SecretKey:      getEnv("MINIO_SECRET_KEY", "minioadmin"),
+		S3Bucket:         getEnv("MINIO_BUCKET_NAME", "goswift-files"),
+		S3Region:         getEnv("MINIO_REGION", ""),
+		S3UseSSL:         getEnvBool("MINIO_USE_SSL", false),
+		MaxUploadSize:    getEnvInt64("MAX_UPLOAD_SIZE_MB", 10) * 1024 * 1024,
+
+		// Chat
+		MaxPinsPerConversation:       getEnvInt("MAX_PINS_PER_CONVERSATION", 50),
+		ScheduledMessagePollInterval: time.Duration(getEnvInt("SCHEDULED_MESSAGE_POLL_SECONDS", 5)) * time.Second,
+		MessagePurgeInterval:         time.Duration(getEnvInt("MESSAGE_PURGE_INTERVAL_SECONDS", 60)) * time.Second,
+		MaxVoiceDuration:             time.Duration(getEnvInt("MAX_VOICE_DURATION_SECONDS", 300)) * time.Second,
+		MaxSnippetSize:               getEnvInt("MAX_SNIPPET_SIZE_KB", 100) * 1024,
+
+		// Moderation
+		ModeratorUserIDs:            getEnvList("MODERATOR_USER_IDS"),
+		ModerationWebhookURL:        getEnv("MODERATION_WEBHOOK_URL", ""),
+		ModerationWebhookTimeout:    time.Duration(getEnvInt("MODERATION_WEBHOOK_TIMEOUT_MS", 2000)) * time.Millisecond,
+		ModerationWebhookFailClosed: getEnvBool("MODERATION_WEBHOOK_FAIL_CLOSED", false),
+
+		// Workspace administration
+		AdminUserIDs: getEnvList("ADMIN_USER_IDS"),
+
+		// Exports
+		ExportSyncMaxMessages: getEnvInt("EXPORT_SYNC_MAX_MESSAGES", 1000),
+		ExportPollInterval:    time.Duration(getEnvInt("EXPORT_POLL_SECONDS", 5)) * time.Second,
+		ExportRetention:       time.Duration(getEnvInt("EXPORT_RETENTION_HOURS", 168)) * time.Hour,
+
+		// Imports
+		ImportMaxArchiveSize: getEnvInt64("IMPORT_MAX_ARCHIVE_SIZE_MB", 1024) * 1024 * 1024,
+
+		// Slash commands
+		SlashCommandTimeout: time.Duration(getEnvInt("SLASH_COMMAND_TIMEOUT_MS", 3000)) * time.Millisecond,
+
+		// Reminders
+		DefaultTimezone:      getEnv("DEFAULT_TIMEZONE", "Asia/Ho_Chi_Minh"),
+		ReminderPollInterval: time.Duration(getEnvInt("REMINDER_POLL_SECONDS", 15)) * time.Second,
 	}
 
 	// Validate required fields for production
@@ -80,6 +166,37 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func (c *Config) GetDBConnectionString() string {
 	return "host=" + c.DBHost +
 		" port=" + c.DBPort +
@@ -26,6 +26,117 @@ var (
 	ErrEmailExists        = errors.New("email already exists")
 	ErrInvalidCredentials = errors.New("invalid email or password")
 
+	// Chat errors
+	ErrNotParticipant        = errors.New("user is not a participant in this conversation")
+	ErrConversationNotFound  = errors.New("conversation not found")
+	ErrMessageNotFound       = errors.New("message not found")
+	ErrReplyTargetMismatch   = errors.New("reply target is not in this conversation")
+	ErrNotificationNotFound  = errors.New("notification not found")
+	ErrInvalidReaction       = errors.New("reaction must be an emoji or a :shortcode:")
+	ErrReactionNotFound      = errors.New("reaction not found")
+	ErrSearchQueryRequired   = errors.New("search query is required")
+	ErrInvalidCursor         = errors.New("invalid cursor")
+	ErrContentRequired       = errors.New("content is required for text messages")
+	ErrNotConversationAdmin  = errors.New("only conversation admins can do this")
+	ErrAlreadyPinned         = errors.New("message is already pinned")
+	ErrNotPinned             = errors.New("message is not pinned")
+	ErrPinLimitReached       = errors.New("conversation has reached the maximum number of pinned messages")
+	ErrMessageNotForwardable = errors.New("system messages and polls cannot be forwarded")
+	ErrClientMsgIDConflict   = errors.New("client message ID was already used in another conversation")
+	ErrNotMessageSender      = errors.New("only the sender of the message can do this")
+	ErrBookmarkNotFound      = errors.New("message is not bookmarked")
+
+	// Membership errors
+	ErrConversationNameRequired = errors.New("conversation name is required")
+	ErrGroupOnly                = errors.New("this action is only available in group conversations")
+	ErrMemberNotFound           = errors.New("user is not a member of this conversation")
+	ErrLastAdmin                = errors.New("the last admin of a group cannot leave or step down while other members remain")
+	ErrTopicTooLong             = errors.New("topic must be at most 250 characters")
+	ErrHandleAmbiguous          = errors.New("several users match this handle, use their email address")
+
+	// Scheduled message errors
+	ErrScheduledMessageNotFound   = errors.New("scheduled message not found")
+	ErrScheduledMessageNotPending = errors.New("scheduled message is no longer pending")
+	ErrScheduledAtInPast          = errors.New("scheduled time must be in the future")
+	ErrScheduledAtTooFar          = errors.New("scheduled time must be within one year")
+
+	// Poll errors
+	ErrPollNotFound         = errors.New("poll not found")
+	ErrPollRequiresGroup    = errors.New("polls can only be posted in group conversations")
+	ErrPollOptionsNotUnique = errors.New("poll options must be unique")
+	ErrPollClosesInPast     = errors.New("poll closing time must be in the future")
+	ErrPollClosed           = errors.New("poll is closed")
+	ErrPollSingleChoice     = errors.New("poll only allows a single choice")
+	ErrPollOptionInvalid    = errors.New("option does not belong to this poll")
+
+	// Draft errors
+	ErrDraftNotFound = errors.New("draft not found")
+	ErrDraftOutdated = errors.New("a more recent draft was saved")
+
+	// Moderation errors
+	ErrMessageRejected        = errors.New("message was rejected by moderation")
+	ErrNotModerator           = errors.New("only moderators can do this")
+	ErrInvalidModerationRule  = errors.New("invalid moderation rule")
+	ErrModerationRuleExists   = errors.New("a rule with this kind and pattern already exists")
+	ErrModerationRuleNotFound = errors.New("moderation rule not found")
+	ErrModerationFlagNotFound = errors.New("flagged message not found")
+	ErrModerationFlagReviewed = errors.New("flagged message was already reviewed")
+
+	// Export errors
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportNotReady = errors.New("export is not completed")
+	ErrExportExpired  = errors.New("export file has expired, request a new export")
+
+	// Slash command errors
+	ErrUnknownCommand      = errors.New("unknown command")
+	ErrInvalidCommandUsage = errors.New("invalid command usage")
+	ErrInvalidCommandName  = errors.New("command names must be 1 to 32 lowercase letters, digits, dashes or underscores")
+	ErrCommandExists       = errors.New("a command with this name already exists")
+	ErrCommandNotFound     = errors.New("command not found")
+	ErrCommandFailed       = errors.New("command failed")
+
+	// Workspace administration errors
+	ErrNotWorkspaceAdmin = errors.New("only workspace admins can do this")
+
+	// Attachment errors
+	ErrAttachmentRequired   = errors.New("image, file and voice messages require at least one attachment")
+	ErrAttachmentNotFound   = errors.New("attachment not found")
+	ErrAttachmentInvalid    = errors.New("attachments must be unsent uploads of the sender in this conversation")
+	ErrAttachmentNotImage   = errors.New("image messages only accept image attachments")
+	ErrAttachmentNotAllowed = errors.New("this message type does not accept attachments")
+	ErrFileTooLarge         = errors.New("file is too large")
+	ErrFileTypeNotAllowed   = errors.New("file type is not allowed")
+	ErrInvalidImage         = errors.New("image is corrupt or in an unsupported format")
+	ErrImageTooLarge        = errors.New("image dimensions are too large")
+	ErrThumbnailNotFound    = errors.New("thumbnail not found")
+	ErrInvalidAudio         = errors.New("audio recording is corrupt")
+
+	// Voice message errors
+	ErrAttachmentNotVoice = errors.New("voice messages require a single Ogg/Opus or M4A/AAC recording")
+	ErrVoiceTooLong       = errors.New("voice message is too long")
+
+	// Snippet errors
+	ErrSnippetRequired     = errors.New("snippet messages require a snippet with code")
+	ErrSnippetNotAllowed   = errors.New("only snippet messages accept a snippet")
+	ErrSnippetTooLarge     = errors.New("snippet is too large")
+	ErrSnippetNotText      = errors.New("snippet code must be valid UTF-8 text")
+	ErrUnsupportedLanguage = errors.New("unsupported snippet language")
+	ErrSnippetNotFound     = errors.New("snippet not found")
+
+	// Reminder errors
+	ErrReminderNotFound     = errors.New("reminder not found")
+	ErrReminderTimeRequired = errors.New("reminders need a time, either when or remind_at")
+	ErrInvalidReminderTime  = errors.New("invalid reminder time")
+	ErrReminderTimeInPast   = errors.New("reminder time must be in the future")
+	ErrReminderTimeTooFar   = errors.New("reminder time must be within one year")
+	ErrInvalidTimezone      = errors.New("invalid timezone, use an IANA name like Asia/Ho_Chi_Minh or Europe/Paris")
+
+	// Message payload errors
+	ErrUnknownMessageType  = errors.New("unknown message type")
+	ErrInvalidPayload      = errors.New("invalid message payload")
+	ErrPayloadNotAllowed   = errors.New("only typed messages accept a payload")
+	ErrTypedMessageContent = errors.New("the content of typed, voice and snippet messages is generated by the server")
+
 	// UUID errors
 	ErrInvalidUUID = errors.New("invalid UUID format")
 )
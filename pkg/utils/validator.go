@@ -120,6 +120,91 @@ func ValidateUUIDFromString(id string) (uuid.UUID, error) {
 	return parsedUUID, nil
 }
 
+// reactionShortcodeRegex matches custom emoji shortcodes like :party_parrot:
+var reactionShortcodeRegex = regexp.MustCompile(`^:[a-z0-9_+\-]{1,50}:$`)
+
+// ValidateReaction validates that a reaction is a unicode emoji sequence or a custom shortcode
+func ValidateReaction(emoji string) error {
+	if emoji == "" || len(emoji) > 64 {
+		return ErrInvalidReaction
+	}
+
+	if reactionShortcodeRegex.MatchString(emoji) {
+		return nil
+	}
+
+	runes := []rune(emoji)
+	if len(runes) > 16 {
+		return ErrInvalidReaction
+	}
+
+	// Keycap sequences like 1️⃣ start with a digit, '#' or '*'
+	isKeycap := strings.ContainsRune(emoji, 0x20E3)
+	hasEmoji := false
+	for i, r := range runes {
+		switch {
+		case isEmojiRune(r):
+			hasEmoji = true
+		case isEmojiModifierRune(r):
+			// Modifiers and joiners can not start a sequence
+			if i == 0 {
+				return ErrInvalidReaction
+			}
+		case isKeycap && i == 0 && (r == '#' || r == '*' || (r >= '0' && r <= '9')):
+			hasEmoji = true
+		default:
+			return ErrInvalidReaction
+		}
+	}
+
+	if !hasEmoji {
+		return ErrInvalidReaction
+	}
+
+	return nil
+}
+
+// isEmojiRune reports whether r is in one of the unicode blocks used by emoji
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictographs, emoticons, transport, flags and skin tones
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Misc symbols and dingbats
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // Misc technical
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Misc symbols and arrows
+		return true
+	case r >= 0x2190 && r <= 0x21FF: // Arrows
+		return true
+	case r >= 0x25AA && r <= 0x25FE: // Geometric shapes
+		return true
+	}
+
+	switch r {
+	case 0x00A9, 0x00AE, 0x203C, 0x2049, 0x2122, 0x2139, 0x24C2, 0x3030, 0x303D, 0x3297, 0x3299:
+		return true
+	}
+
+	return false
+}
+
+// isEmojiModifierRune reports whether r joins or modifies emoji in a sequence
+func isEmojiModifierRune(r rune) bool {
+	switch {
+	case r == 0x200D: // Zero width joiner
+		return true
+	case r == 0xFE0F: // Emoji presentation selector
+		return true
+	case r == 0x20E3: // Combining enclosing keycap
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // Tag sequences for subdivision flags
+		return true
+	}
+
+	return false
+}
+
 // SanitizeInput sanitizes user input
 func SanitizeInput(input string) string {
 	// Remove leading and trailing whitespace